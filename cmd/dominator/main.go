@@ -25,6 +25,8 @@ import (
 const dirPerms = syscall.S_IRWXU
 
 var (
+	auditLogDir = flag.String("auditLogDir", "audit-log",
+		"Directory to write the audit log to, relative to stateDir. If empty, no audit log is written")
 	debug = flag.Bool("debug", false,
 		"If true, show debugging output")
 	fdLimit = flag.Uint64("fdLimit", getFdLimit(),
@@ -128,9 +130,24 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Cannot create metrics directory: %s\n", err)
 		os.Exit(1)
 	}
-	herd := herd.NewHerd(fmt.Sprintf("%s:%d", *imageServerHostname,
+	herd, err := herd.NewHerd(fmt.Sprintf("%s:%d", *imageServerHostname,
 		*imageServerPortNum), objectServer, metricsDir, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot create herd: %s\n", err)
+		os.Exit(1)
+	}
 	herd.AddHtmlWriter(logger)
+	if err := herd.OpenStateDirectory(pathJoin(*stateDir, "herd")); err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot open herd state: %s\n", err)
+		os.Exit(1)
+	}
+	if *auditLogDir != "" {
+		err := herd.OpenAuditLog(pathJoin(*stateDir, *auditLogDir))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot open audit log: %s\n", err)
+			os.Exit(1)
+		}
+	}
 	rpcd.Setup(herd, logger)
 	if err = herd.StartServer(*portNum, true); err != nil {
 		fmt.Fprintf(os.Stderr, "Unable to create http server\t%s\n", err)
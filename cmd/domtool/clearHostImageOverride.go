@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func clearHostImageOverrideSubcommand(client *srpc.Client, args []string) {
+	if err := clearHostImageOverride(client, args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error clearing host image override: %s\n",
+			err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func clearHostImageOverride(client *srpc.Client, subHostname string) error {
+	var request dominator.ClearHostImageOverrideRequest
+	var reply dominator.ClearHostImageOverrideResponse
+	request.Hostname = subHostname
+	return client.RequestReply("Dominator.ClearHostImageOverride", request,
+		&reply)
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func clearImageQuarantineSubcommand(client *srpc.Client, args []string) {
+	if err := clearImageQuarantine(client, args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error clearing image quarantine: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func clearImageQuarantine(client *srpc.Client, imageName string) error {
+	var request dominator.ClearImageQuarantineRequest
+	var reply dominator.ClearImageQuarantineResponse
+	request.ImageName = imageName
+	return client.RequestReply("Dominator.ClearImageQuarantine", request,
+		&reply)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/json"
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+var timeLayouts = []string{"2006-01-02T15:04:05", "2006-01-02"}
+
+func getAuditLogSubcommand(client *srpc.Client, args []string) {
+	if err := getAuditLog(client, args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting audit log: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range timeLayouts {
+		t, err := time.ParseInLocation(layout, value, time.Local)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("bad time: \"%s\"", value)
+}
+
+func getAuditLog(client *srpc.Client, subHostname string) error {
+	var request dominator.GetAuditLogRequest
+	var reply dominator.GetAuditLogResponse
+	request.Hostname = subHostname
+	var err error
+	if request.StartTime, err = parseTime(*startTime); err != nil {
+		return err
+	}
+	if request.EndTime, err = parseTime(*endTime); err != nil {
+		return err
+	}
+	if err := client.RequestReply("Dominator.GetAuditLog", request,
+		&reply); err != nil {
+		return err
+	}
+	return json.WriteWithIndent(os.Stdout, "  ", reply.Records)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Symantec/Dominator/lib/json"
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func getDriftSubcommand(client *srpc.Client, args []string) {
+	if err := getDrift(client, args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting drift: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func getDrift(client *srpc.Client, subHostname string) error {
+	var request dominator.GetDriftRequest
+	var reply dominator.GetDriftResponse
+	request.Hostname = subHostname
+	if err := client.RequestReply("Dominator.GetDrift", request,
+		&reply); err != nil {
+		return err
+	}
+	return json.WriteWithIndent(os.Stdout, "  ", reply.Drift)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Symantec/Dominator/lib/json"
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func getUpdateLimitsSubcommand(client *srpc.Client, args []string) {
+	if err := getUpdateLimits(client); err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting update limits: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func getUpdateLimits(client *srpc.Client) error {
+	var request dominator.GetUpdateConcurrencyLimitsRequest
+	var reply dominator.GetUpdateConcurrencyLimitsResponse
+	if err := client.RequestReply("Dominator.GetUpdateConcurrencyLimits",
+		request, &reply); err != nil {
+		return err
+	}
+	return json.WriteWithIndent(os.Stdout, "    ", reply)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Symantec/Dominator/lib/json"
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func listOverridesSubcommand(client *srpc.Client, args []string) {
+	if err := listOverrides(client); err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing overrides: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func listOverrides(client *srpc.Client) error {
+	var request dominator.ListHostImageOverridesRequest
+	var reply dominator.ListHostImageOverridesResponse
+	if err := client.RequestReply("Dominator.ListHostImageOverrides",
+		request, &reply); err != nil {
+		return err
+	}
+	return json.WriteWithIndent(os.Stdout, "    ", reply.Overrides)
+}
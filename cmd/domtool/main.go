@@ -14,13 +14,22 @@ import (
 var (
 	cpuPercent = flag.Uint("cpuPercent", 0,
 		"CPU speed as percentage of capacity (default 50)")
+	endTime = flag.String("endTime", "",
+		"End of time range for audit log (YYYY-MM-DD[THH:MM:SS])")
 	networkSpeedPercent = flag.Uint("networkSpeedPercent",
 		constants.DefaultNetworkSpeedPercent,
 		"Network speed as percentage of capacity")
+	overrideDisablesUpdates = flag.Bool("overrideDisablesUpdates", false,
+		"If true, a host image override also disables updates")
+	rolloutMaxFailures = flag.Uint("rolloutMaxFailures", 0,
+		"Pause a rollout if more than this many subs fail to update")
+	rolloutWaves     flagutil.StringList = []string{"1", "10", "50", "100"}
 	scanExcludeList  flagutil.StringList = constants.ScanExcludeList
 	scanSpeedPercent                     = flag.Uint("scanSpeedPercent",
 		constants.DefaultScanSpeedPercent,
 		"Scan speed as percentage of capacity")
+	startTime = flag.String("startTime", "",
+		"Start of time range for audit log (YYYY-MM-DD[THH:MM:SS])")
 	domHostname = flag.String("domHostname", "localhost",
 		"Hostname of dominator")
 	domPortNum = flag.Uint("domPortNum", constants.DominatorPortNumber,
@@ -28,6 +37,8 @@ var (
 )
 
 func init() {
+	flag.Var(&rolloutWaves, "rolloutWaves",
+		"Comma separated list of cumulative percentages of subs per wave")
 	flag.Var(&scanExcludeList, "scanExcludeList",
 		"Comma separated list of patterns to exclude from scanning")
 }
@@ -38,13 +49,30 @@ func printUsage() {
 	fmt.Fprintln(os.Stderr, "Common flags:")
 	flag.PrintDefaults()
 	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  abort-rollout")
+	fmt.Fprintln(os.Stderr, "  clear-host-image-override sub")
+	fmt.Fprintln(os.Stderr, "  clear-image-quarantine image")
 	fmt.Fprintln(os.Stderr, "  clear-safety-shutoff sub")
 	fmt.Fprintln(os.Stderr, "  configure-subs")
 	fmt.Fprintln(os.Stderr, "  disable-updates reason")
 	fmt.Fprintln(os.Stderr, "  enable-updates reason")
+	fmt.Fprintln(os.Stderr, "  get-audit-log sub")
 	fmt.Fprintln(os.Stderr, "  get-default-image")
+	fmt.Fprintln(os.Stderr, "  get-drift sub")
+	fmt.Fprintln(os.Stderr, "  get-rollout-status")
 	fmt.Fprintln(os.Stderr, "  get-subs-configuration")
+	fmt.Fprintln(os.Stderr, "  get-update-limits")
+	fmt.Fprintln(os.Stderr, "  list-overrides")
+	fmt.Fprintln(os.Stderr, "  pause-rollout reason")
+	fmt.Fprintln(os.Stderr, "  resume-rollout")
 	fmt.Fprintln(os.Stderr, "  set-default-image image")
+	fmt.Fprintln(os.Stderr,
+		"  set-host-image-override sub image expiry reason")
+	fmt.Fprintln(os.Stderr,
+		"  set-update-limits tagKey maxTriggeringUpdates maxReboots")
+	fmt.Fprintln(os.Stderr, "  show-planned-update sub")
+	fmt.Fprintln(os.Stderr, "  start-rollout image")
+	fmt.Fprintln(os.Stderr, "  watch-subs")
 }
 
 type commandFunc func(*srpc.Client, []string)
@@ -56,13 +84,28 @@ type subcommand struct {
 }
 
 var subcommands = []subcommand{
+	{"abort-rollout", 0, abortRolloutSubcommand},
+	{"clear-host-image-override", 1, clearHostImageOverrideSubcommand},
+	{"clear-image-quarantine", 1, clearImageQuarantineSubcommand},
 	{"clear-safety-shutoff", 1, clearSafetyShutoffSubcommand},
 	{"configure-subs", 0, configureSubsSubcommand},
 	{"disable-updates", 1, disableUpdatesSubcommand},
 	{"enable-updates", 1, enableUpdatesSubcommand},
+	{"get-audit-log", 1, getAuditLogSubcommand},
 	{"get-default-image", 0, getDefaultImageSubcommand},
+	{"get-drift", 1, getDriftSubcommand},
+	{"get-rollout-status", 0, getRolloutStatusSubcommand},
 	{"get-subs-configuration", 0, getSubsConfigurationSubcommand},
+	{"get-update-limits", 0, getUpdateLimitsSubcommand},
+	{"list-overrides", 0, listOverridesSubcommand},
+	{"pause-rollout", 1, pauseRolloutSubcommand},
+	{"resume-rollout", 0, resumeRolloutSubcommand},
 	{"set-default-image", 1, setDefaultImageSubcommand},
+	{"set-host-image-override", 4, setHostImageOverrideSubcommand},
+	{"set-update-limits", 3, setUpdateLimitsSubcommand},
+	{"show-planned-update", 1, showPlannedUpdateSubcommand},
+	{"start-rollout", 1, startRolloutSubcommand},
+	{"watch-subs", 0, watchSubsSubcommand},
 }
 
 func main() {
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func pauseRolloutSubcommand(client *srpc.Client, args []string) {
+	if err := pauseRollout(client, args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error pausing rollout: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func pauseRollout(client *srpc.Client, reason string) error {
+	if reason == "" {
+		return errors.New("cannot pause rollout: no reason given")
+	}
+	var request dominator.PauseRolloutRequest
+	var reply dominator.PauseRolloutResponse
+	request.Reason = reason
+	return client.RequestReply("Dominator.PauseRollout", request, &reply)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func resumeRolloutSubcommand(client *srpc.Client, args []string) {
+	if err := resumeRollout(client); err != nil {
+		fmt.Fprintf(os.Stderr, "Error resuming rollout: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func resumeRollout(client *srpc.Client) error {
+	var request dominator.ResumeRolloutRequest
+	var reply dominator.ResumeRolloutResponse
+	return client.RequestReply("Dominator.ResumeRollout", request, &reply)
+}
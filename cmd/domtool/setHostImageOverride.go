@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func setHostImageOverrideSubcommand(client *srpc.Client, args []string) {
+	err := setHostImageOverride(client, args[0], args[1], args[2], args[3])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error setting host image override: %s\n",
+			err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// parseExpiry accepts either a duration from now or an absolute time.
+func parseExpiry(value string) (time.Time, error) {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(duration), nil
+	}
+	expires, err := parseTime(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if expires.IsZero() {
+		return time.Time{}, fmt.Errorf("no expiry specified")
+	}
+	return expires, nil
+}
+
+func setHostImageOverride(client *srpc.Client, subHostname, imageName,
+	expiry, reason string) error {
+	var request dominator.SetHostImageOverrideRequest
+	var reply dominator.SetHostImageOverrideResponse
+	request.Hostname = subHostname
+	request.ImageName = imageName
+	request.DisableUpdates = *overrideDisablesUpdates
+	request.Reason = reason
+	var err error
+	if request.Expires, err = parseExpiry(expiry); err != nil {
+		return err
+	}
+	return client.RequestReply("Dominator.SetHostImageOverride", request,
+		&reply)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func setUpdateLimitsSubcommand(client *srpc.Client, args []string) {
+	if err := setUpdateLimits(client, args[0], args[1], args[2]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error setting update limits: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func setUpdateLimits(client *srpc.Client, tagKey, maxTriggering,
+	maxReboots string) error {
+	var request dominator.SetUpdateConcurrencyLimitsRequest
+	var reply dominator.SetUpdateConcurrencyLimitsResponse
+	request.TagKey = tagKey
+	value, err := strconv.ParseUint(maxTriggering, 10, 0)
+	if err != nil {
+		return err
+	}
+	request.MaxTriggeringUpdatesPerGroup = uint(value)
+	if value, err = strconv.ParseUint(maxReboots, 10, 0); err != nil {
+		return err
+	}
+	request.MaxRebootsPerGroup = uint(value)
+	return client.RequestReply("Dominator.SetUpdateConcurrencyLimits",
+		request, &reply)
+}
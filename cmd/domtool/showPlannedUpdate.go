@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Symantec/Dominator/lib/json"
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func showPlannedUpdateSubcommand(client *srpc.Client, args []string) {
+	if err := showPlannedUpdate(client, args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error showing planned update: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func showPlannedUpdate(client *srpc.Client, subHostname string) error {
+	var request dominator.GetPlannedUpdateRequest
+	var reply dominator.GetPlannedUpdateResponse
+	request.Hostname = subHostname
+	if err := client.RequestReply("Dominator.GetPlannedUpdate", request,
+		&reply); err != nil {
+		return err
+	}
+	return json.WriteWithIndent(os.Stdout, "  ", reply)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func startRolloutSubcommand(client *srpc.Client, args []string) {
+	if err := startRollout(client, args[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error starting rollout: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func startRollout(client *srpc.Client, imageName string) error {
+	var request dominator.StartRolloutRequest
+	var reply dominator.StartRolloutResponse
+	request.ImageName = imageName
+	request.MaxFailures = *rolloutMaxFailures
+	for _, wave := range rolloutWaves {
+		percentage, err := strconv.ParseUint(wave, 10, 0)
+		if err != nil {
+			return err
+		}
+		request.WavePercentages = append(request.WavePercentages,
+			uint(percentage))
+	}
+	return client.RequestReply("Dominator.StartRollout", request, &reply)
+}
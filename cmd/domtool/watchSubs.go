@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+
+	"github.com/Symantec/Dominator/lib/json"
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func watchSubsSubcommand(client *srpc.Client, args []string) {
+	if err := watchSubs(client); err != nil {
+		fmt.Fprintf(os.Stderr, "Error watching subs: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func watchSubs(client *srpc.Client) error {
+	conn, err := client.Call("Dominator.GetSubUpdates")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	encoder := gob.NewEncoder(conn)
+	decoder := gob.NewDecoder(conn)
+	if err := encoder.Encode(dominator.GetSubUpdatesRequest{}); err != nil {
+		return err
+	}
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+	for {
+		var update dominator.SubUpdate
+		if err := decoder.Decode(&update); err != nil {
+			return err
+		}
+		if err := json.WriteWithIndent(os.Stdout, "  ", update); err != nil {
+			return err
+		}
+	}
+}
@@ -38,6 +38,7 @@ func addImage(imageSClient *srpc.Client, name string, img *image.Image) error {
 	} else {
 		img.ExpiresAt = time.Time{}
 	}
+	img.Labels = labels
 	if err := img.Verify(); err != nil {
 		return err
 	}
@@ -97,6 +97,11 @@ func getImage(client *srpc.Client, name string) (*image.Image, error) {
 	if img == nil {
 		return nil, errors.New(name + ": not found")
 	}
+	if trustedKeys != nil {
+		if err := img.VerifySignatures(trustedKeys); err != nil {
+			return nil, errors.New(name + ": " + err.Error())
+		}
+	}
 	img.FileSystem.RebuildInodePointers()
 	return img, nil
 }
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Symantec/Dominator/imageserver/client"
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/imageserver"
+)
+
+func findImagesSubcommand(args []string) {
+	imageSClient, _ := getClients()
+	var dirname string
+	if len(args) > 0 {
+		dirname = args[0]
+	}
+	if err := findImages(imageSClient, dirname); err != nil {
+		fmt.Fprintf(os.Stderr, "Error finding images: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func findImages(imageSClient *srpc.Client, dirname string) error {
+	request := imageserver.FindImagesRequest{
+		DirectoryName:  dirname,
+		Labels:         labels,
+		PackageName:    *packageName,
+		PackageVersion: *packageVersion,
+	}
+	now := time.Now()
+	if *maxAge > 0 {
+		request.CreatedAfter = now.Add(-*maxAge)
+	}
+	if *minAge > 0 {
+		request.CreatedBefore = now.Add(-*minAge)
+	}
+	imageNames, err := client.FindImages(imageSClient, request)
+	if err != nil {
+		return err
+	}
+	for _, name := range imageNames {
+		fmt.Println(name)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Symantec/Dominator/imageserver/client"
+	"github.com/Symantec/Dominator/lib/tags"
+)
+
+func deleteImageLabelsSubcommand(args []string) {
+	imageSClient, _ := getClients()
+	err := client.ChangeImageLabels(imageSClient, args[0], nil, args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error deleting image labels: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func setImageLabelsSubcommand(args []string) {
+	imageSClient, _ := getClients()
+	labelsToSet := make(tags.Tags)
+	for _, arg := range args[1:] {
+		var label tags.Tag
+		if err := label.Set(arg); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		labelsToSet[label.Key] = label.Value
+	}
+	err := client.ChangeImageLabels(imageSClient, args[0], labelsToSet, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error setting image labels: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -31,12 +31,17 @@ func listDirectories(imageSClient *srpc.Client) error {
 		}
 	}
 	for _, directory := range directories {
-		if directory.Metadata.OwnerGroup == "" {
+		if directory.Metadata == (image.DirectoryMetadata{}) {
 			fmt.Println(directory.Name)
 			continue
 		}
-		fmt.Printf("%-*s  ", maxDirnameWidth, directory.Name)
-		fmt.Printf("OwnerGroup=%s", directory.Metadata.OwnerGroup)
+		fmt.Printf("%-*s", maxDirnameWidth, directory.Name)
+		if directory.Metadata.OwnerGroup != "" {
+			fmt.Printf("  OwnerGroup=%s", directory.Metadata.OwnerGroup)
+		}
+		if policy := directory.Metadata.RetentionPolicy; policy.IsActive() {
+			fmt.Printf("  RetentionPolicy=%s", policy)
+		}
 		fmt.Println()
 	}
 	return nil
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crypto"
 	"flag"
 	"fmt"
 	"os"
@@ -11,12 +12,14 @@ import (
 	"github.com/Symantec/Dominator/lib/filter"
 	"github.com/Symantec/Dominator/lib/flags/loadflags"
 	"github.com/Symantec/Dominator/lib/flagutil"
+	"github.com/Symantec/Dominator/lib/image"
 	"github.com/Symantec/Dominator/lib/log"
 	"github.com/Symantec/Dominator/lib/log/cmdlogger"
 	"github.com/Symantec/Dominator/lib/mbr"
 	objectclient "github.com/Symantec/Dominator/lib/objectserver/client"
 	"github.com/Symantec/Dominator/lib/srpc"
 	"github.com/Symantec/Dominator/lib/srpc/setupclient"
+	"github.com/Symantec/Dominator/lib/tags"
 )
 
 var (
@@ -46,10 +49,27 @@ var (
 	imageServerPortNum = flag.Uint("imageServerPortNum",
 		constants.ImageServerPortNumber,
 		"Port number of image server")
+	imageSigningKeys = flag.String("imageSigningKeys", "",
+		"File containing PEM encoded public keys. If specified, images fetched from the image server must be signed by one of these keys")
+	keepMdbImages = flag.Bool("keepMdbImages", false,
+		"If true, retention policy keeps images referenced by the MDB")
+	keepNewest = flag.Uint("keepNewest", 0,
+		"Number of newest images for retention policy to keep")
+	keepYoungerThan = flag.Duration("keepYoungerThan", 0,
+		"Minimum age of images for retention policy to delete")
+	labels       tags.Tags
 	makeBootable = flag.Bool("makeBootable", true,
 		"If true, make raw image bootable by installing GRUB")
+	maxAge = flag.Duration("maxAge", 0,
+		"Maximum age of images to find. Default: no limit")
+	minAge = flag.Duration("minAge", 0,
+		"Minimum age of images to find")
 	minFreeBytes = flag.Uint64("minFreeBytes", 4<<20,
 		"minimum number of free bytes in raw image")
+	packageName = flag.String("packageName", "",
+		"Name of package which images to find must contain")
+	packageVersion = flag.String("packageVersion", "",
+		"Version of package which images to find must contain")
 	releaseNotes = flag.String("releaseNotes", "",
 		"Filename or URL containing release notes")
 	requiredPaths = flagutil.StringToRuneMap(constants.RequiredPaths)
@@ -63,9 +83,12 @@ var (
 
 	logger            log.DebugLogger
 	minimumExpiration = 15 * time.Minute
+	trustedKeys       []crypto.PublicKey
 )
 
 func init() {
+	flag.Var(&labels, "labels",
+		"Comma separated list of key=value labels for added images or images to find")
 	flag.Var(&requiredPaths, "requiredPaths",
 		"Comma separated list of required path:type entries")
 	flag.Var(&tableType, "tableType", "partition table type for make-raw-image")
@@ -81,12 +104,15 @@ func printUsage() {
 	fmt.Fprintln(os.Stderr, "  addi   name imagename filterfile triggerfile")
 	fmt.Fprintln(os.Stderr, "  adds   name subname filterfile triggerfile")
 	fmt.Fprintln(os.Stderr, "  addrep name baseimage layerimage...")
+	fmt.Fprintln(os.Stderr, "  apply-retention-policies [dirname]")
 	fmt.Fprintln(os.Stderr, "  bulk-addrep layerimage...")
 	fmt.Fprintln(os.Stderr, "  check  name")
 	fmt.Fprintln(os.Stderr, "  check-directory dirname")
+	fmt.Fprintln(os.Stderr, "  check-retention-policies [dirname]")
 	fmt.Fprintln(os.Stderr, "  chown  dirname ownerGroup")
 	fmt.Fprintln(os.Stderr, "  copy   name oldimagename")
 	fmt.Fprintln(os.Stderr, "  delete name")
+	fmt.Fprintln(os.Stderr, "  delete-image-labels name key...")
 	fmt.Fprintln(os.Stderr, "  delunrefobj percentage bytes")
 	fmt.Fprintln(os.Stderr, "  diff   tool left right")
 	fmt.Fprintln(os.Stderr, "         left & right are image sources. Format:")
@@ -97,6 +123,7 @@ func printUsage() {
 	fmt.Fprintln(os.Stderr, "           l: name of file containing an Image")
 	fmt.Fprintln(os.Stderr, "           s: name of sub to poll")
 	fmt.Fprintln(os.Stderr, "  estimate-usage    name")
+	fmt.Fprintln(os.Stderr, "  find-images       [dirname]")
 	fmt.Fprintln(os.Stderr, "  find-latest-image directory")
 	fmt.Fprintln(os.Stderr, "  get               name directory")
 	fmt.Fprintln(os.Stderr, "  list")
@@ -108,8 +135,12 @@ func printUsage() {
 	fmt.Fprintln(os.Stderr, "  merge-filters     filter-file...")
 	fmt.Fprintln(os.Stderr, "  merge-triggers    triggers-file...")
 	fmt.Fprintln(os.Stderr, "  mkdir             name")
+	fmt.Fprintln(os.Stderr, "  set-image-labels  name key=value...")
+	fmt.Fprintln(os.Stderr, "  set-retention-policy dirname")
 	fmt.Fprintln(os.Stderr, "  show              name")
+	fmt.Fprintln(os.Stderr, "  show-image-diff   left right")
 	fmt.Fprintln(os.Stderr, "  showunrefobj")
+	fmt.Fprintln(os.Stderr, "  sign              name keyfile")
 	fmt.Fprintln(os.Stderr, "  tar               name [file]")
 	fmt.Fprintln(os.Stderr, "Fields:")
 	fmt.Fprintln(os.Stderr, "  m: mode")
@@ -136,15 +167,19 @@ var subcommands = []subcommand{
 	{"adds", 4, 4, addImagesubSubcommand},
 	{"addi", 4, 4, addImageimageSubcommand},
 	{"addrep", 3, -1, addReplaceImageSubcommand},
+	{"apply-retention-policies", 0, 1, applyRetentionPoliciesSubcommand},
 	{"bulk-addrep", 1, -1, bulkAddReplaceImagesSubcommand},
 	{"check", 1, 1, checkImageSubcommand},
 	{"check-directory", 1, 1, checkDirectorySubcommand},
+	{"check-retention-policies", 0, 1, checkRetentionPoliciesSubcommand},
 	{"chown", 2, 2, chownDirectorySubcommand},
 	{"copy", 2, 2, copyImageSubcommand},
 	{"delete", 1, 1, deleteImageSubcommand},
+	{"delete-image-labels", 2, -1, deleteImageLabelsSubcommand},
 	{"delunrefobj", 2, 2, deleteUnreferencedObjectsSubcommand},
 	{"diff", 3, 3, diffSubcommand},
 	{"estimate-usage", 1, 1, estimateImageUsageSubcommand},
+	{"find-images", 0, 1, findImagesSubcommand},
 	{"find-latest-image", 1, 1, findLatestImageSubcommand},
 	{"get", 2, 2, getImageSubcommand},
 	{"list", 0, 0, listImagesSubcommand},
@@ -156,8 +191,12 @@ var subcommands = []subcommand{
 	{"merge-filters", 1, -1, mergeFiltersSubcommand},
 	{"merge-triggers", 1, -1, mergeTriggersSubcommand},
 	{"mkdir", 1, 1, makeDirectorySubcommand},
+	{"set-image-labels", 2, -1, setImageLabelsSubcommand},
+	{"set-retention-policy", 1, 1, setRetentionPolicySubcommand},
 	{"show", 1, 1, showImageSubcommand},
+	{"show-image-diff", 2, 2, showImageDiffSubcommand},
 	{"showunrefobj", 0, 0, showUnreferencedObjectsSubcommand},
+	{"sign", 2, 2, signImageSubcommand},
 	{"tar", 1, 2, tarImageSubcommand},
 }
 
@@ -233,6 +272,13 @@ func main() {
 			os.Exit(2)
 		}
 	}
+	if *imageSigningKeys != "" {
+		trustedKeys, err = image.LoadVerifyingKeys(*imageSigningKeys)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
 	if err := setupclient.SetupTls(true); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
@@ -27,5 +27,11 @@ func mergeTriggers(triggerFiles []string) error {
 		mergeableTriggers.Merge(trig)
 	}
 	trig := mergeableTriggers.ExportTriggers()
+	if trig == nil {
+		trig = triggers.New()
+	}
+	if err := trig.CheckDependencies(); err != nil {
+		return err
+	}
 	return json.WriteWithIndent(os.Stdout, "    ", trig.Triggers)
 }
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Symantec/Dominator/imageserver/client"
+	"github.com/Symantec/Dominator/lib/image"
+	"github.com/Symantec/Dominator/lib/srpc"
+)
+
+func applyRetentionPoliciesSubcommand(args []string) {
+	imageSClient, _ := getClients()
+	if err := applyRetentionPolicies(imageSClient, args, false); err != nil {
+		fmt.Fprintf(os.Stderr, "Error applying retention policies: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func checkRetentionPoliciesSubcommand(args []string) {
+	imageSClient, _ := getClients()
+	if err := applyRetentionPolicies(imageSClient, args, true); err != nil {
+		fmt.Fprintf(os.Stderr, "Error checking retention policies: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func setRetentionPolicySubcommand(args []string) {
+	imageSClient, _ := getClients()
+	policy := image.RetentionPolicy{
+		KeepNewest:      *keepNewest,
+		KeepYoungerThan: *keepYoungerThan,
+		KeepMdbImages:   *keepMdbImages,
+	}
+	err := client.SetRetentionPolicy(imageSClient, args[0], policy)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error setting retention policy: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func applyRetentionPolicies(imageSClient *srpc.Client, args []string,
+	dryRun bool) error {
+	var dirname string
+	if len(args) > 0 {
+		dirname = args[0]
+	}
+	imageNames, err := client.ApplyRetentionPolicies(imageSClient, dirname,
+		dryRun)
+	for _, name := range imageNames {
+		fmt.Println(name)
+	}
+	return err
+}
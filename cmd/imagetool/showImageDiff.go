@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Symantec/Dominator/imageserver/client"
+	"github.com/Symantec/Dominator/lib/json"
+	"github.com/Symantec/Dominator/lib/srpc"
+)
+
+func showImageDiffSubcommand(args []string) {
+	imageSClient, _ := getClients()
+	if err := showImageDiff(imageSClient, args[0], args[1]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error showing image diff: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func showImageDiff(imageSClient *srpc.Client, left, right string) error {
+	diff, err := client.DiffImages(imageSClient, left, right)
+	if err != nil {
+		return err
+	}
+	return json.WriteWithIndent(os.Stdout, "    ", diff)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Symantec/Dominator/imageserver/client"
+	"github.com/Symantec/Dominator/lib/image"
+	"github.com/Symantec/Dominator/lib/srpc"
+)
+
+func signImageSubcommand(args []string) {
+	imageSClient, _ := getClients()
+	if err := signImage(imageSClient, args[0], args[1]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error signing image: %s\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func signImage(imageSClient *srpc.Client, name, keyFilename string) error {
+	signer, err := image.LoadSigningKey(keyFilename)
+	if err != nil {
+		return err
+	}
+	img, err := client.GetImage(imageSClient, name)
+	if err != nil {
+		return err
+	}
+	if img == nil {
+		return errors.New(name + ": not found")
+	}
+	signature, err := img.Sign(signer)
+	if err != nil {
+		return err
+	}
+	logger.Debugf(0, "Signing: %s with key: %s\n",
+		name, signature.KeyFingerprint())
+	return client.AddImageSignature(imageSClient, name, *signature)
+}
@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/Symantec/Dominator/lib/constants"
 	"github.com/Symantec/Dominator/lib/cpulimiter"
@@ -42,6 +43,8 @@ var (
 		"Network speed as percentage of capacity (default 10)")
 	defaultScanSpeedPercent = flag.Uint("defaultScanSpeedPercent", 0,
 		"Scan speed as percentage of capacity (default 2)")
+	fullScanInterval = flag.Duration("fullScanInterval", time.Hour,
+		"Interval between full scans if -watchFileSystem is true")
 	maxThreads = flag.Uint("maxThreads", 1,
 		"Maximum number of parallel OS threads to use")
 	permitInsecureMode = flag.Bool("permitInsecureMode", false,
@@ -57,7 +60,9 @@ var (
 		"If true, show statistics after each cycle")
 	subdDir = flag.String("subdDir", ".subd",
 		"Name of subd private directory, relative to rootDir. This must be on the same file-system as rootDir")
-	unshare = flag.Bool("unshare", true, "Internal use only.")
+	unshare         = flag.Bool("unshare", true, "Internal use only.")
+	watchFileSystem = flag.Bool("watchFileSystem", false,
+		"If true, watch for changes and rescan only the changed paths")
 )
 
 func init() {
@@ -309,6 +314,9 @@ func main() {
 	var configuration scanner.Configuration
 	configuration.CpuLimiter = cpulimiter.New(100)
 	configuration.DefaultCpuPercent = configParams.CpuPercent
+	configuration.FullScanInterval = *fullScanInterval
+	configuration.SubdDirectory = *subdDir
+	configuration.WatchForChanges = *watchFileSystem
 	// Apply built-in defaults if nothing specified.
 	if configuration.DefaultCpuPercent < 1 {
 		configuration.DefaultCpuPercent = constants.DefaultCpuPercent
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/sub"
+	"github.com/Symantec/Dominator/sub/client"
+)
+
+func lockPathsSubcommand(getSubClient getSubClientFunc, args []string) {
+	if err := lockPaths(getSubClient(), args[0], args[1]); err != nil {
+		logger.Fatalf("Error locking paths: %s\n", err)
+	}
+	os.Exit(0)
+}
+
+func unlockPathsSubcommand(getSubClient getSubClientFunc, args []string) {
+	if err := unlockPaths(getSubClient(), args[0]); err != nil {
+		logger.Fatalf("Error unlocking paths: %s\n", err)
+	}
+	os.Exit(0)
+}
+
+func lockPaths(srpcClient *srpc.Client, pattern, reason string) error {
+	request := sub.LockPathsRequest{
+		Locks: []sub.PathLock{{
+			Pattern:   pattern,
+			Reason:    reason,
+			ExpiresAt: time.Now().Add(*lockDuration),
+		}},
+	}
+	var reply sub.LockPathsResponse
+	return client.CallLockPaths(srpcClient, request, &reply)
+}
+
+func unlockPaths(srpcClient *srpc.Client, pattern string) error {
+	request := sub.LockPathsRequest{UnlockPatterns: []string{pattern}}
+	var reply sub.LockPathsResponse
+	return client.CallLockPaths(srpcClient, request, &reply)
+}
@@ -31,8 +31,12 @@ var (
 	imageServerPortNum = flag.Uint("imageServerPortNum",
 		constants.ImageServerPortNumber,
 		"Port number of image server")
+	imageSigningKeys = flag.String("imageSigningKeys", "",
+		"File containing PEM encoded public keys. If specified, pushed images must be signed by one of these keys")
 	interval = flag.Uint("interval", 1,
 		"Seconds to sleep between Polls")
+	lockDuration = flag.Duration("lockDuration", time.Hour,
+		"Time until path locks expire")
 	networkSpeedPercent = flag.Uint("networkSpeedPercent",
 		constants.DefaultNetworkSpeedPercent,
 		"Network speed as percentage of capacity")
@@ -86,13 +90,17 @@ func printUsage() {
 	fmt.Fprintln(os.Stderr, "  get-config")
 	fmt.Fprintln(os.Stderr, "  get-file remoteFile localFile")
 	fmt.Fprintln(os.Stderr, "  list-missing-objects image")
+	fmt.Fprintln(os.Stderr, "  lock-paths pattern reason")
 	fmt.Fprintln(os.Stderr, "  poll")
 	fmt.Fprintln(os.Stderr, "  push-file source dest")
 	fmt.Fprintln(os.Stderr, "  push-image image")
 	fmt.Fprintln(os.Stderr, "  push-missing-objects image")
 	fmt.Fprintln(os.Stderr, "  restart-service name")
+	fmt.Fprintln(os.Stderr, "  rollback")
 	fmt.Fprintln(os.Stderr, "  set-config")
 	fmt.Fprintln(os.Stderr, "  show-update-request image")
+	fmt.Fprintln(os.Stderr, "  unlock-paths pattern")
+	fmt.Fprintln(os.Stderr, "  verify image")
 	fmt.Fprintln(os.Stderr, "  wait-for-image image")
 }
 
@@ -144,14 +152,18 @@ var subcommands = []subcommand{
 	{"get-file", 2, getSubClient, getFileSubcommand},
 	{"list-missing-objects", 1, getSubClientRetry,
 		listMissingObjectsSubcommand},
+	{"lock-paths", 2, getSubClient, lockPathsSubcommand},
 	{"poll", 0, getSubClient, pollSubcommand},
 	{"push-file", 2, getSubClient, pushFileSubcommand},
 	{"push-image", 1, getSubClientRetry, pushImageSubcommand},
 	{"push-missing-objects", 1, getSubClientRetry,
 		pushMissingObjectsSubcommand},
 	{"restart-service", 1, getSubClient, restartServiceSubcommand},
+	{"rollback", 0, getSubClient, rollbackSubcommand},
 	{"set-config", 0, getSubClient, setConfigSubcommand},
 	{"show-update-request", 1, getSubClientRetry, showUpdateRequestSubcommand},
+	{"unlock-paths", 1, getSubClient, unlockPathsSubcommand},
+	{"verify", 1, getSubClient, verifySubcommand},
 	{"wait-for-image", 1, getSubClientRetry, waitForImageSubcommand},
 }
 
@@ -62,6 +62,19 @@ func pollSubcommand(getSubClient getSubClientFunc, args []string) {
 			fmt.Printf("Last successful image: \"%s\"\n",
 				reply.LastSuccessfulImageName)
 		}
+		for _, failure := range reply.LastTriggerFailures {
+			fmt.Printf("Trigger failure: service %s %s:\n%s\n",
+				failure.Service, failure.Action, failure.Output)
+		}
+		for _, lock := range reply.PathLocks {
+			fmt.Printf("Path lock: %s until %s by %s: %s\n", lock.Pattern,
+				lock.ExpiresAt.Format(time.RFC3339), lock.Username,
+				lock.Reason)
+		}
+		if update := reply.InterruptedUpdate; update != nil {
+			fmt.Printf("Interrupted update to image: \"%s\" started at: %s\n",
+				update.ImageName, update.StartTime)
+		}
 		if reply.FreeSpace != nil {
 			fmt.Printf("Free space: %s\n", format.FormatBytes(*reply.FreeSpace))
 		}
@@ -4,6 +4,7 @@ import (
 	"os"
 
 	"github.com/Symantec/Dominator/lib/filesystem"
+	"github.com/Symantec/Dominator/lib/fsutil"
 	objclient "github.com/Symantec/Dominator/lib/objectserver/client"
 	"github.com/Symantec/Dominator/lib/triggers"
 	"github.com/Symantec/Dominator/lib/wsyscall"
@@ -36,6 +37,10 @@ func pushFile(getSubClient getSubClientFunc, source, dest string) error {
 	if err != nil {
 		return err
 	}
+	xattrs, err := fsutil.GetXattrs(source)
+	if err != nil {
+		return err
+	}
 	newRegularInode := &filesystem.RegularInode{
 		Mode:             filesystem.FileMode(sourceStat.Mode),
 		Uid:              sourceStat.Uid,
@@ -43,7 +48,8 @@ func pushFile(getSubClient getSubClientFunc, source, dest string) error {
 		MtimeNanoSeconds: int32(sourceStat.Mtim.Nsec),
 		MtimeSeconds:     sourceStat.Mtim.Sec,
 		Size:             uint64(sourceStat.Size),
-		Hash:             hashVal}
+		Hash:             hashVal,
+		Xattrs:           xattrs}
 	newInode := sub.Inode{Name: dest, GenericInode: newRegularInode}
 	var updateRequest sub.UpdateRequest
 	var updateReply sub.UpdateResponse
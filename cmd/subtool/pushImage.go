@@ -149,6 +149,9 @@ func getImageRetry(clientName, imageName string,
 		if err != nil {
 			return nil, err
 		} else if img != nil {
+			if err := verifyImageSignatures(img); err != nil {
+				return nil, err
+			}
 			if err := img.FileSystem.RebuildInodePointers(); err != nil {
 				return nil, err
 			}
@@ -163,6 +166,17 @@ func getImageRetry(clientName, imageName string,
 	return nil, errors.New("timed out getting image")
 }
 
+func verifyImageSignatures(img *image.Image) error {
+	if *imageSigningKeys == "" {
+		return nil
+	}
+	trustedKeys, err := image.LoadVerifyingKeys(*imageSigningKeys)
+	if err != nil {
+		return err
+	}
+	return img.VerifySignatures(trustedKeys)
+}
+
 func pollFetchAndPush(subObj *lib.Sub, img *image.Image,
 	imageServerAddress string, timeoutTime time.Time,
 	logger log.DebugLogger) error {
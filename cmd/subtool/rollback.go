@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/sub"
+	"github.com/Symantec/Dominator/sub/client"
+)
+
+func rollbackSubcommand(getSubClient getSubClientFunc, args []string) {
+	if err := rollback(getSubClient()); err != nil {
+		logger.Fatalf("Error rolling back: %s\n", err)
+	}
+	os.Exit(0)
+}
+
+func rollback(srpcClient *srpc.Client) error {
+	var reply sub.RollbackResponse
+	err := client.CallRollback(srpcClient, sub.RollbackRequest{}, &reply)
+	if err != nil {
+		return err
+	}
+	if reply.ImageName != "" {
+		fmt.Printf("Rolled back to image: %s\n", reply.ImageName)
+	}
+	return nil
+}
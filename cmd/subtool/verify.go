@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Symantec/Dominator/proto/sub"
+	"github.com/Symantec/Dominator/sub/client"
+)
+
+func verifySubcommand(getSubClient getSubClientFunc, args []string) {
+	same, err := verify(getSubClient, args[0])
+	if err != nil {
+		logger.Fatalf("Error verifying: %s: %s\n", args[0], err)
+	}
+	if !same {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// verify returns true if the file-system of the sub matches the image.
+func verify(getSubClient getSubClientFunc, imageName string) (bool, error) {
+	request := sub.VerifyRequest{
+		ImageName: imageName,
+		ImageServerAddress: fmt.Sprintf("%s:%d",
+			*imageServerHostname, *imageServerPortNum),
+	}
+	var reply sub.VerifyResponse
+	if err := client.CallVerify(getSubClient(), request, &reply); err != nil {
+		return false, err
+	}
+	for _, pathname := range reply.AddedPaths {
+		fmt.Printf("Added:    %s\n", pathname)
+	}
+	for _, pathname := range reply.MissingPaths {
+		fmt.Printf("Missing:  %s\n", pathname)
+	}
+	for _, pathname := range reply.ModifiedPaths {
+		fmt.Printf("Modified: %s\n", pathname)
+	}
+	for _, change := range reply.MetadataChanges {
+		var changes []string
+		if change.ModeChanged {
+			changes = append(changes, "mode")
+		}
+		if change.UidChanged {
+			changes = append(changes, "uid")
+		}
+		if change.GidChanged {
+			changes = append(changes, "gid")
+		}
+		if change.MtimeChanged {
+			changes = append(changes, "mtime")
+		}
+		if change.XattrsChanged {
+			changes = append(changes, "xattrs")
+		}
+		fmt.Printf("Changed:  %s (%s)\n", change.Pathname,
+			strings.Join(changes, ","))
+	}
+	if len(reply.AddedPaths) > 0 || len(reply.MissingPaths) > 0 ||
+		len(reply.ModifiedPaths) > 0 || len(reply.MetadataChanges) > 0 {
+		return false, nil
+	}
+	fmt.Printf("File-system (scan %d) matches image: %s\n", reply.ScanCount,
+		imageName)
+	return true, nil
+}
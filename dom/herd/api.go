@@ -16,6 +16,7 @@ import (
 	"github.com/Symantec/Dominator/lib/objectcache"
 	"github.com/Symantec/Dominator/lib/objectserver"
 	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
 	filegenproto "github.com/Symantec/Dominator/proto/filegenerator"
 	subproto "github.com/Symantec/Dominator/proto/sub"
 	"github.com/Symantec/tricorder/go/tricorder"
@@ -56,11 +57,16 @@ const (
 	statusSendingUpdate
 	statusMissingComputedFile
 	statusUpdatesDisabled
+	statusImageQuarantined
 	statusUnsafeUpdate
+	statusWaitingForRollout
+	statusWaitingForMaintenanceWindow
+	statusWaitingForUpdateSlot
 	statusUpdating
 	statusUpdateDenied
 	statusFailedToUpdate
 	statusWaitingForNextFullPoll
+	statusLocallyPinned
 	statusSynced
 )
 
@@ -73,6 +79,7 @@ type Sub struct {
 	mdb                          mdb.Machine
 	requiredImageName            string       // Updated only by sub goroutine.
 	requiredImage                *image.Image // Updated only by sub goroutine.
+	requiredImageTime            time.Time    // When requiredImageName changed.
 	plannedImageName             string       // Updated only by sub goroutine.
 	plannedImage                 *image.Image // Updated only by sub goroutine.
 	clientResource               *srpc.ClientResource
@@ -82,6 +89,8 @@ type Sub struct {
 	busy                         bool
 	deletingFlagMutex            sync.Mutex
 	deleting                     bool
+	fullPollFlagMutex            sync.Mutex
+	fullPollRequested            bool // Consumed by sub goroutine.
 	busyStartTime                time.Time
 	busyStopTime                 time.Time
 	cancelChannel                chan struct{}
@@ -110,8 +119,20 @@ type Sub struct {
 	lastScanDuration             time.Duration
 	lastComputeUpdateCpuDuration time.Duration
 	lastUpdateTime               time.Time
+	lastUpdateImageName          string // Required image when update was sent.
 	lastSyncTime                 time.Time
 	lastSuccessfulImageName      string
+	lastUpdateHadTriggerFailures bool
+	pathLocks                    []subproto.PathLock
+	fallbackImageName            string
+	lastUpdateError              string
+	updateSlot                   *updateSlot // Protected by updateLimiter.
+	driftReportMutex             sync.Mutex
+	driftReport                  *dominator.DriftReport
+	pendingAuditRecord           *dominator.AuditRecord
+	haveRestoredState            bool
+	unsavedFileSystemMutex       sync.Mutex
+	unsavedFileSystem            []byte // Compressed, saved at checkpoint.
 }
 
 func (sub *Sub) String() string {
@@ -140,23 +161,54 @@ type Herd struct {
 	dialer                net.Dialer
 	currentScanStartTime  time.Time
 	previousScanDuration  time.Duration
+	rollout               *rolloutType
+	windowPolicies        []maintenanceWindowPolicy
+	updateLimiter         *updateLimiter
+	auditLogger           *auditLogger
+	defaultImageChangedBy string
+	configChangedBy       string
+	stateDir              string
+	savedSubStates        map[string]subState // Protected by herd lock.
+	quarantine            *quarantineType
+	hostImageOverrides    *hostImageOverridesType
+	objectPeers           *objectPeersType
+	subUpdateNotifiers    *subUpdateNotifiersType
 }
 
 func NewHerd(imageServerAddress string, objectServer objectserver.ObjectServer,
-	metricsDir *tricorder.DirectorySpec, logger log.DebugLogger) *Herd {
+	metricsDir *tricorder.DirectorySpec, logger log.DebugLogger) (
+	*Herd, error) {
 	return newHerd(imageServerAddress, objectServer, metricsDir, logger)
 }
 
+func (herd *Herd) AbortRollout() error {
+	return herd.abortRollout()
+}
+
 func (herd *Herd) AddHtmlWriter(htmlWriter HtmlWriter) {
 	herd.addHtmlWriter(htmlWriter)
 }
 
+func (herd *Herd) ClearHostImageOverride(hostname string) error {
+	return herd.clearHostImageOverride(hostname)
+}
+
+func (herd *Herd) ClearImageQuarantine(imageName string) error {
+	return herd.clearImageQuarantine(imageName)
+}
+
 func (herd *Herd) ClearSafetyShutoff(hostname string) error {
 	return herd.clearSafetyShutoff(hostname)
 }
 
-func (herd *Herd) ConfigureSubs(configuration subproto.Configuration) error {
-	return herd.configureSubs(configuration)
+func (herd *Herd) CloseSubUpdateChannel(
+	channel <-chan dominator.SubUpdate) {
+	herd.closeSubUpdateChannel(channel)
+}
+
+func (herd *Herd) ConfigureSubs(username string,
+	configuration subproto.Configuration) error {
+	return herd.configureSubs(username, configuration)
 }
 
 func (herd *Herd) DisableUpdates(username, reason string) error {
@@ -167,32 +219,100 @@ func (herd *Herd) EnableUpdates() error {
 	return herd.enableUpdates()
 }
 
+func (herd *Herd) GetAuditLog(request dominator.GetAuditLogRequest) (
+	[]dominator.AuditRecord, error) {
+	return herd.getAuditLog(request)
+}
+
 func (herd *Herd) GetDefaultImage() string {
 	return herd.defaultImageName
 }
 
+func (herd *Herd) GetDrift(hostname string) (*dominator.DriftReport, error) {
+	return herd.getDrift(hostname)
+}
+
+func (herd *Herd) GetPlannedUpdate(hostname string) (
+	*dominator.GetPlannedUpdateResponse, error) {
+	return herd.getPlannedUpdate(hostname)
+}
+
+func (herd *Herd) GetRolloutStatus() *dominator.RolloutStatus {
+	return herd.getRolloutStatus()
+}
+
+func (herd *Herd) GetUpdateConcurrencyLimits() (
+	limits dominator.UpdateConcurrencyLimits) {
+	return herd.getUpdateConcurrencyLimits()
+}
+
 func (herd *Herd) GetSubsConfiguration() subproto.Configuration {
 	return herd.getSubsConfiguration()
 }
 
+func (herd *Herd) ListHostImageOverrides() []dominator.HostImageOverride {
+	return herd.listHostImageOverrides()
+}
+
 func (herd *Herd) LockWithTimeout(timeout time.Duration) {
 	herd.lockWithTimeout(timeout)
 }
 
+func (herd *Herd) MakeSubUpdateChannel(
+	request dominator.GetSubUpdatesRequest) <-chan dominator.SubUpdate {
+	return herd.makeSubUpdateChannel(request)
+}
+
 func (herd *Herd) MdbUpdate(mdb *mdb.Mdb) {
 	herd.mdbUpdate(mdb)
 }
 
+// OpenAuditLog will open the audit log in the specified directory. Updates
+// sent to subs are recorded in the audit log.
+func (herd *Herd) OpenAuditLog(dirname string) error {
+	return herd.openAuditLog(dirname)
+}
+
+// OpenStateDirectory will load the saved state of subs from the specified
+// directory and will periodically save the state of subs to the directory.
+// It must be called before the first call to MdbUpdate.
+func (herd *Herd) OpenStateDirectory(dirname string) error {
+	return herd.openStateDirectory(dirname)
+}
+
+func (herd *Herd) PauseRollout(reason string) error {
+	return herd.pauseRollout(reason)
+}
+
 func (herd *Herd) PollNextSub() bool {
 	return herd.pollNextSub()
 }
 
+func (herd *Herd) ResumeRollout() error {
+	return herd.resumeRollout()
+}
+
 func (herd *Herd) RLockWithTimeout(timeout time.Duration) {
 	herd.rLockWithTimeout(timeout)
 }
 
-func (herd *Herd) SetDefaultImage(imageName string) error {
-	return herd.setDefaultImage(imageName)
+func (herd *Herd) SetDefaultImage(username, imageName string) error {
+	return herd.setDefaultImage(username, imageName)
+}
+
+func (herd *Herd) SetHostImageOverride(username string,
+	override dominator.HostImageOverride) error {
+	return herd.setHostImageOverride(username, override)
+}
+
+func (herd *Herd) SetUpdateConcurrencyLimits(
+	limits dominator.UpdateConcurrencyLimits) error {
+	return herd.setUpdateConcurrencyLimits(limits)
+}
+
+func (herd *Herd) StartRollout(username string,
+	request dominator.StartRolloutRequest) error {
+	return herd.startRollout(username, request)
 }
 
 func (herd *Herd) StartServer(portNum uint, daemon bool) error {
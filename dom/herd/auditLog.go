@@ -0,0 +1,344 @@
+package herd
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/triggers"
+	"github.com/Symantec/Dominator/proto/dominator"
+	subproto "github.com/Symantec/Dominator/proto/sub"
+)
+
+const (
+	auditLogDirPerms  = 0700
+	auditLogFilePerms = 0600
+	auditLogLayout    = "2006-01-02:15:04:05.000"
+)
+
+var (
+	auditLogFileMaxSize = flag.Uint("auditLogFileMaxSize", 10,
+		"Maximum size of an audit log file in MiB")
+	auditLogQuota = flag.Uint("auditLogQuota", 1024,
+		"Audit log quota in MiB. If exceeded, old audit logs are deleted")
+)
+
+// auditLogger writes audit records to a directory of files. Each file is named
+// after the time (UTC) it was created and contains one JSON encoded record per
+// line.
+type auditLogger struct {
+	sync.Mutex
+	dirname     string
+	file        *os.File
+	encoder     *json.Encoder
+	fileSize    uint64
+	maxFileSize uint64
+	quota       uint64
+}
+
+func newAuditLogger(dirname string) (*auditLogger, error) {
+	if err := os.MkdirAll(dirname, auditLogDirPerms); err != nil {
+		return nil, err
+	}
+	logger := &auditLogger{
+		dirname:     dirname,
+		maxFileSize: uint64(*auditLogFileMaxSize) << 20,
+		quota:       uint64(*auditLogQuota) << 20,
+	}
+	if err := logger.openNewFile(); err != nil {
+		return nil, err
+	}
+	return logger, nil
+}
+
+func (herd *Herd) openAuditLog(dirname string) error {
+	logger, err := newAuditLogger(dirname)
+	if err != nil {
+		return err
+	}
+	herd.auditLogger = logger
+	return nil
+}
+
+// This should be called with the lock held.
+func (logger *auditLogger) openNewFile() error {
+	if logger.file != nil {
+		logger.file.Close()
+		logger.file = nil
+	}
+	filename := path.Join(logger.dirname,
+		time.Now().UTC().Format(auditLogLayout))
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND,
+		auditLogFilePerms)
+	if err != nil {
+		return err
+	}
+	logger.file = file
+	logger.encoder = json.NewEncoder(&countingWriter{file, logger})
+	logger.fileSize = 0
+	return logger.enforceQuota()
+}
+
+type countingWriter struct {
+	writer io.Writer
+	logger *auditLogger
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	nWritten, err := w.writer.Write(p)
+	w.logger.fileSize += uint64(nWritten)
+	return nWritten, err
+}
+
+func (logger *auditLogger) listFiles() ([]string, error) {
+	file, err := os.Open(logger.dirname)
+	if err != nil {
+		return nil, err
+	}
+	names, err := file.Readdirnames(-1)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+	filenames := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, err := time.Parse(auditLogLayout, name); err == nil {
+			filenames = append(filenames, name)
+		}
+	}
+	sort.Strings(filenames)
+	return filenames, nil
+}
+
+// This should be called with the lock held.
+func (logger *auditLogger) enforceQuota() error {
+	filenames, err := logger.listFiles()
+	if err != nil {
+		return err
+	}
+	var usage uint64
+	for index := len(filenames) - 1; index >= 0; index-- {
+		filename := path.Join(logger.dirname, filenames[index])
+		fi, err := os.Lstat(filename)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		usage += uint64(fi.Size())
+		if usage > logger.quota && index < len(filenames)-1 {
+			if err := os.Remove(filename); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (logger *auditLogger) write(record *dominator.AuditRecord) error {
+	logger.Lock()
+	defer logger.Unlock()
+	if logger.fileSize >= logger.maxFileSize {
+		if err := logger.openNewFile(); err != nil {
+			return err
+		}
+	}
+	if err := logger.encoder.Encode(record); err != nil {
+		return err
+	}
+	return logger.file.Sync()
+}
+
+func (logger *auditLogger) read(request dominator.GetAuditLogRequest) (
+	[]dominator.AuditRecord, error) {
+	logger.Lock()
+	defer logger.Unlock()
+	filenames, err := logger.listFiles()
+	if err != nil {
+		return nil, err
+	}
+	records := make([]dominator.AuditRecord, 0)
+	startedRecords := make(map[auditRecordKey]int) // Index into records.
+	// Results may be appended to files created after the EndTime, so these are
+	// read as well.
+	for index, name := range filenames {
+		if !request.StartTime.IsZero() && index+1 < len(filenames) {
+			nextTime, _ := time.Parse(auditLogLayout, filenames[index+1])
+			if nextTime.Before(request.StartTime) {
+				continue
+			}
+		}
+		records, err = readAuditLogFile(path.Join(logger.dirname, name),
+			request, records, startedRecords)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+type auditRecordKey struct {
+	hostname  string
+	startTime int64
+}
+
+func readAuditLogFile(filename string, request dominator.GetAuditLogRequest,
+	records []dominator.AuditRecord,
+	startedRecords map[auditRecordKey]int) ([]dominator.AuditRecord, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return records, nil // Deleted due to quota.
+		}
+		return nil, err
+	}
+	defer file.Close()
+	decoder := json.NewDecoder(file)
+	for {
+		var record dominator.AuditRecord
+		if err := decoder.Decode(&record); err != nil {
+			if err == io.EOF {
+				return records, nil
+			}
+			return nil, fmt.Errorf("error reading: %s: %s", filename, err)
+		}
+		if request.Hostname != "" && record.Hostname != request.Hostname {
+			continue
+		}
+		if !request.StartTime.IsZero() &&
+			record.StartTime.Before(request.StartTime) {
+			continue
+		}
+		if !request.EndTime.IsZero() &&
+			record.StartTime.After(request.EndTime) {
+			continue
+		}
+		key := auditRecordKey{record.Hostname, record.StartTime.UnixNano()}
+		if index, ok := startedRecords[key]; ok && record.Completed {
+			startedRecord := &records[index]
+			startedRecord.Duration = record.Duration
+			startedRecord.Completed = true
+			startedRecord.Result = record.Result
+			startedRecord.TriggerFailures = record.TriggerFailures
+			delete(startedRecords, key)
+			continue
+		}
+		if !record.Completed {
+			startedRecords[key] = len(records)
+		}
+		records = append(records, record)
+	}
+}
+
+func (herd *Herd) getAuditLog(request dominator.GetAuditLogRequest) (
+	[]dominator.AuditRecord, error) {
+	if herd.auditLogger == nil {
+		return nil, errors.New("audit log not enabled")
+	}
+	return herd.auditLogger.read(request)
+}
+
+// getImageChangedBy returns the user who selected the required image for the
+// sub, or the empty string if it was selected by the MDB.
+func (sub *Sub) getImageChangedBy() string {
+	if rollout := sub.herd.getRollout(); rollout != nil {
+		rollout.Lock()
+		imageName, startedBy := rollout.imageName, rollout.startedBy
+		rollout.Unlock()
+		if imageName == sub.requiredImageName {
+			return startedBy
+		}
+	}
+	if sub.mdb.RequiredImage == "" {
+		sub.herd.RLock()
+		defer sub.herd.RUnlock()
+		return sub.herd.defaultImageChangedBy
+	}
+	return ""
+}
+
+// startAuditRecord will write an audit record for an update which is about to
+// be sent to the sub.
+func (sub *Sub) startAuditRecord(request subproto.UpdateRequest,
+	matchedTriggers []*triggers.Trigger) {
+	if sub.herd.auditLogger == nil {
+		return
+	}
+	record := &dominator.AuditRecord{
+		Hostname:       sub.mdb.Hostname,
+		ImageName:      request.ImageName,
+		StartTime:      time.Now(),
+		Update:         summariseUpdate(request),
+		ImageChangedBy: sub.getImageChangedBy(),
+	}
+	for _, trigger := range matchedTriggers {
+		record.TriggersFired = append(record.TriggersFired, trigger.Service)
+	}
+	sub.herd.RLock()
+	record.ConfigChangedBy = sub.herd.configChangedBy
+	sub.herd.RUnlock()
+	if err := sub.herd.auditLogger.write(record); err != nil {
+		sub.herd.logger.Printf("Error writing audit record for: %s: %s\n",
+			sub, err)
+	}
+	// Only what is needed to write the result is kept (and saved across
+	// restarts).
+	sub.pendingAuditRecord = &dominator.AuditRecord{
+		Hostname:  record.Hostname,
+		ImageName: record.ImageName,
+		StartTime: record.StartTime,
+	}
+}
+
+// finishAuditRecord will append the result of the pending update (if any) for
+// the sub to the audit log. An empty result indicates success.
+func (sub *Sub) finishAuditRecord(result string, triggerFailures bool) {
+	record := sub.pendingAuditRecord
+	if record == nil || sub.herd.auditLogger == nil {
+		return
+	}
+	sub.pendingAuditRecord = nil
+	record.Duration = time.Since(record.StartTime)
+	record.Completed = true
+	record.Result = result
+	record.TriggerFailures = triggerFailures
+	if err := sub.herd.auditLogger.write(record); err != nil {
+		sub.herd.logger.Printf("Error writing audit record for: %s: %s\n",
+			sub, err)
+	}
+}
+
+func summariseUpdate(request subproto.UpdateRequest) dominator.UpdateSummary {
+	summary := dominator.UpdateSummary{
+		NumFilesToCopyToCache: uint(len(request.FilesToCopyToCache)),
+		NumDirectoriesToMake:  uint(len(request.DirectoriesToMake)),
+		NumInodesToMake:       uint(len(request.InodesToMake)),
+		NumHardlinksToMake:    uint(len(request.HardlinksToMake)),
+		NumPathsToDelete:      uint(len(request.PathsToDelete)),
+		NumInodesToChange:     uint(len(request.InodesToChange)),
+		PathsToDelete:         request.PathsToDelete,
+	}
+	for _, inode := range request.DirectoriesToMake {
+		summary.DirectoriesToMake = append(summary.DirectoriesToMake,
+			inode.Name)
+	}
+	for _, inode := range request.InodesToMake {
+		summary.InodesToMake = append(summary.InodesToMake, inode.Name)
+	}
+	for _, hardlink := range request.HardlinksToMake {
+		summary.HardlinksToMake = append(summary.HardlinksToMake,
+			hardlink.NewLink)
+	}
+	for _, inode := range request.InodesToChange {
+		summary.InodesToChange = append(summary.InodesToChange, inode.Name)
+	}
+	return summary
+}
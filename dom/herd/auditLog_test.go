@@ -0,0 +1,74 @@
+package herd
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func TestAuditLogMergesResults(t *testing.T) {
+	dirname, err := ioutil.TempDir("", "auditLog_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dirname)
+	logger, err := newAuditLogger(dirname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	startTime := time.Now().Add(-time.Minute)
+	started := []*dominator.AuditRecord{
+		{Hostname: "sub0", ImageName: "image0", StartTime: startTime},
+		{Hostname: "sub1", ImageName: "image0", StartTime: startTime},
+	}
+	for _, record := range started {
+		if err := logger.write(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Start a new file, as if the first one filled up.
+	logger.Lock()
+	err = logger.openNewFile()
+	logger.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	completed := &dominator.AuditRecord{
+		Hostname:  "sub0",
+		ImageName: "image0",
+		StartTime: startTime,
+		Completed: true,
+		Result:    "failed",
+	}
+	if err := logger.write(completed); err != nil {
+		t.Fatal(err)
+	}
+	records, err := logger.read(dominator.GetAuditLogRequest{
+		StartTime: startTime.Add(-time.Second),
+		EndTime:   startTime.Add(time.Second),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("number of records: %d != 2", len(records))
+	}
+	if !records[0].Completed || records[0].Result != "failed" {
+		t.Errorf("result not merged: %+v", records[0])
+	}
+	if records[1].Completed {
+		t.Errorf("record without result is completed: %+v", records[1])
+	}
+	records, err = logger.read(dominator.GetAuditLogRequest{
+		StartTime: time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 0 {
+		t.Errorf("number of records after start time: %d != 0", len(records))
+	}
+}
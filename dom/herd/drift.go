@@ -0,0 +1,60 @@
+package herd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Symantec/Dominator/dom/lib"
+	"github.com/Symantec/Dominator/lib/format"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func (herd *Herd) getDrift(hostname string) (*dominator.DriftReport, error) {
+	sub := herd.getSub(hostname)
+	if sub == nil {
+		return nil, errors.New("unknown sub: " + hostname)
+	}
+	report := sub.getDriftReport()
+	if report == nil {
+		return nil, errors.New("no drift report yet for: " + hostname)
+	}
+	return report, nil
+}
+
+// computeDrift will compute and save the drift report for the sub. It must be
+// called after a full poll with the required image available. Drift is
+// computed even if updates are disabled.
+func (sub *Sub) computeDrift() {
+	report := lib.ComputeDrift(lib.Sub{
+		Hostname:       sub.mdb.Hostname,
+		FileSystem:     sub.fileSystem,
+		ComputedInodes: sub.computedInodes,
+	}, sub.requiredImage)
+	report.ImageName = sub.requiredImageName
+	report.ComputeTime = time.Now()
+	sub.driftReportMutex.Lock()
+	defer sub.driftReportMutex.Unlock()
+	sub.driftReport = report
+}
+
+func (sub *Sub) getDriftReport() *dominator.DriftReport {
+	sub.driftReportMutex.Lock()
+	defer sub.driftReportMutex.Unlock()
+	return sub.driftReport
+}
+
+func (sub *Sub) showDrift(writer io.Writer) {
+	report := sub.getDriftReport()
+	if report == nil {
+		fmt.Fprintln(writer, "    <td></td>")
+		return
+	}
+	fmt.Fprintf(writer,
+		"    <td>added: %d, missing: %d, modified: %d, metadata: %d",
+		len(report.AddedPaths), len(report.MissingPaths),
+		len(report.ModifiedPaths), len(report.MetadataChanges))
+	fmt.Fprintf(writer, " (image: %s, computed: %s ago)</td>\n",
+		report.ImageName, format.Duration(time.Since(report.ComputeTime)))
+}
@@ -1,8 +1,10 @@
 package herd
 
 import (
+	"crypto"
 	"errors"
 	"flag"
+	"fmt"
 	"net"
 	"os"
 	"runtime"
@@ -12,6 +14,7 @@ import (
 	"github.com/Symantec/Dominator/lib/constants"
 	"github.com/Symantec/Dominator/lib/cpusharer"
 	filegenclient "github.com/Symantec/Dominator/lib/filegen/client"
+	"github.com/Symantec/Dominator/lib/image"
 	"github.com/Symantec/Dominator/lib/log"
 	libnet "github.com/Symantec/Dominator/lib/net"
 	"github.com/Symantec/Dominator/lib/net/reverseconnection"
@@ -24,6 +27,8 @@ import (
 var (
 	disableUpdatesAtStartup = flag.Bool("disableUpdatesAtStartup", false,
 		"If true, updates are disabled at startup")
+	imageSigningKeys = flag.String("imageSigningKeys", "",
+		"File containing PEM encoded public keys. If specified, images must be signed by one of these keys")
 	pollSlotsPerCPU = flag.Uint("pollSlotsPerCPU", 100,
 		"Number of poll slots per CPU")
 	subConnectTimeout = flag.Uint("subConnectTimeout", 15,
@@ -31,9 +36,14 @@ var (
 )
 
 func newHerd(imageServerAddress string, objectServer objectserver.ObjectServer,
-	metricsDir *tricorder.DirectorySpec, logger log.DebugLogger) *Herd {
+	metricsDir *tricorder.DirectorySpec, logger log.DebugLogger) (
+	*Herd, error) {
 	var herd Herd
-	herd.imageManager = images.New(imageServerAddress, logger)
+	signingKeys, err := loadImageSigningKeys()
+	if err != nil {
+		return nil, err
+	}
+	herd.imageManager = images.New(imageServerAddress, signingKeys, logger)
 	herd.objectServer = objectServer
 	herd.computedFilesManager = filegenclient.New(objectServer, logger)
 	herd.logger = logger
@@ -53,8 +63,43 @@ func newHerd(imageServerAddress string, objectServer objectserver.ObjectServer,
 		nil, time.Second*30, 0, logger),
 		herd.cpuSharer)
 	herd.currentScanStartTime = time.Now()
+	if err := herd.loadMaintenanceWindowPolicies(); err != nil {
+		return nil, err
+	}
+	herd.updateLimiter = newUpdateLimiter()
+	herd.quarantine = newQuarantine()
+	herd.hostImageOverrides = newHostImageOverrides()
+	herd.objectPeers = newObjectPeers()
+	herd.subUpdateNotifiers = newSubUpdateNotifiers()
 	herd.setupMetrics(metricsDir)
-	return &herd
+	return &herd, nil
+}
+
+func loadImageSigningKeys() ([]crypto.PublicKey, error) {
+	if *imageSigningKeys == "" {
+		return nil, nil
+	}
+	keys, err := image.LoadVerifyingKeys(*imageSigningKeys)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load image signing keys: %s", err)
+	}
+	return keys, nil
+}
+
+// getImageToUse returns the image, waiting for it to be fetched if needed. An
+// error is returned if the image is unknown or if it was rejected by the image
+// manager, such as when -imageSigningKeys is specified and the image is not
+// signed by a trusted key. Images given by users must be checked with this
+// before they are used.
+func (herd *Herd) getImageToUse(imageName string) (*image.Image, error) {
+	img, err := herd.imageManager.Get(imageName, true)
+	if err != nil {
+		return nil, err
+	}
+	if img == nil {
+		return nil, errors.New("unknown image: " + imageName)
+	}
+	return img, nil
 }
 
 func (herd *Herd) clearSafetyShutoff(hostname string) error {
@@ -67,10 +112,12 @@ func (herd *Herd) clearSafetyShutoff(hostname string) error {
 	return sub.clearSafetyShutoff()
 }
 
-func (herd *Herd) configureSubs(configuration subproto.Configuration) error {
+func (herd *Herd) configureSubs(username string,
+	configuration subproto.Configuration) error {
 	herd.Lock()
 	defer herd.Unlock()
 	herd.configurationForSubs = configuration
+	herd.configChangedBy = username
 	return nil
 }
 
@@ -103,6 +150,9 @@ func (herd *Herd) pollNextSub() bool {
 	if herd.nextSubToPoll >= uint(len(herd.subsByIndex)) {
 		herd.nextSubToPoll = 0
 		herd.previousScanDuration = time.Since(herd.currentScanStartTime)
+		herd.checkRollout()
+		herd.checkImageFailures()
+		herd.expireHostImageOverrides()
 		return true
 	}
 	if herd.nextSubToPoll == 0 {
@@ -169,11 +219,12 @@ func (herd *Herd) rLockWithTimeout(timeout time.Duration) {
 	timeoutFunction(herd.RLock, timeout)
 }
 
-func (herd *Herd) setDefaultImage(imageName string) error {
+func (herd *Herd) setDefaultImage(username, imageName string) error {
 	if imageName == "" {
 		herd.Lock()
 		defer herd.Unlock()
 		herd.defaultImageName = ""
+		herd.defaultImageChangedBy = username
 		// Cancel blocking operations by affected subs.
 		for _, sub := range herd.subsByIndex {
 			if sub.mdb.RequiredImage != "" {
@@ -197,13 +248,10 @@ func (herd *Herd) setDefaultImage(imageName string) error {
 			herd.Unlock()
 		}
 	}()
-	img, err := herd.imageManager.Get(imageName, true)
+	img, err := herd.getImageToUse(imageName)
 	if err != nil {
 		return err
 	}
-	if img == nil {
-		return errors.New("unknown image: " + imageName)
-	}
 	if img.Filter != nil {
 		return errors.New("only sparse images can be set as default")
 	}
@@ -214,11 +262,12 @@ func (herd *Herd) setDefaultImage(imageName string) error {
 	herd.Lock()
 	defer herd.Unlock()
 	herd.defaultImageName = imageName
+	herd.defaultImageChangedBy = username
 	herd.nextDefaultImageName = ""
 	for _, sub := range herd.subsByIndex {
 		if sub.mdb.RequiredImage == "" {
 			sub.sendCancel()
-			if sub.status == statusSynced { // Synced to previous default image.
+			if sub.status.isSynced() { // Synced to previous default image.
 				sub.status = statusWaitingToPoll
 			}
 			if sub.status == statusImageUndefined {
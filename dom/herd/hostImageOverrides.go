@@ -0,0 +1,196 @@
+package herd
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/format"
+	"github.com/Symantec/Dominator/lib/json"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+const hostImageOverridesFile = "hostImageOverrides.json"
+
+// hostImageOverridesType holds the per-host image overrides. These take
+// precedence over the MDB until they expire.
+type hostImageOverridesType struct {
+	sync.Mutex
+	overrides map[string]dominator.HostImageOverride
+}
+
+func newHostImageOverrides() *hostImageOverridesType {
+	return &hostImageOverridesType{
+		overrides: make(map[string]dominator.HostImageOverride),
+	}
+}
+
+func (herd *Herd) loadHostImageOverrides() error {
+	filename := path.Join(herd.stateDir, hostImageOverridesFile)
+	overrides := make(map[string]dominator.HostImageOverride)
+	if err := json.ReadFromFile(filename, &overrides); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	herd.hostImageOverrides.Lock()
+	defer herd.hostImageOverrides.Unlock()
+	herd.hostImageOverrides.overrides = overrides
+	return nil
+}
+
+// This should be called with the hostImageOverrides lock held.
+func (herd *Herd) saveHostImageOverrides() {
+	if herd.stateDir == "" {
+		return
+	}
+	err := json.WriteToFile(path.Join(herd.stateDir, hostImageOverridesFile),
+		stateFilePerms, "    ", herd.hostImageOverrides.overrides)
+	if err != nil {
+		herd.logger.Printf("Error saving host image overrides: %s\n", err)
+	}
+}
+
+func (herd *Herd) setHostImageOverride(username string,
+	override dominator.HostImageOverride) error {
+	if override.Hostname == "" {
+		return errors.New("no hostname specified")
+	}
+	if override.ImageName == "" && !override.DisableUpdates {
+		return errors.New("no image specified and updates not disabled")
+	}
+	if override.Reason == "" {
+		return errors.New("no reason specified")
+	}
+	if !override.Expires.After(time.Now()) {
+		return errors.New("expiry time is not in the future")
+	}
+	if herd.getSub(override.Hostname) == nil {
+		return errors.New("unknown sub: " + override.Hostname)
+	}
+	if override.ImageName != "" {
+		if _, err := herd.getImageToUse(override.ImageName); err != nil {
+			return err
+		}
+	}
+	override.SetBy = username
+	override.SetTime = time.Now()
+	herd.hostImageOverrides.Lock()
+	herd.hostImageOverrides.overrides[override.Hostname] = override
+	herd.saveHostImageOverrides()
+	herd.hostImageOverrides.Unlock()
+	herd.forceRepollOfSub(override.Hostname)
+	return nil
+}
+
+func (herd *Herd) clearHostImageOverride(hostname string) error {
+	herd.hostImageOverrides.Lock()
+	if _, ok := herd.hostImageOverrides.overrides[hostname]; !ok {
+		herd.hostImageOverrides.Unlock()
+		return errors.New("no override for: " + hostname)
+	}
+	delete(herd.hostImageOverrides.overrides, hostname)
+	herd.saveHostImageOverrides()
+	herd.hostImageOverrides.Unlock()
+	herd.forceRepollOfSub(hostname)
+	return nil
+}
+
+func (herd *Herd) listHostImageOverrides() []dominator.HostImageOverride {
+	herd.hostImageOverrides.Lock()
+	defer herd.hostImageOverrides.Unlock()
+	hostnames := make([]string, 0, len(herd.hostImageOverrides.overrides))
+	for hostname := range herd.hostImageOverrides.overrides {
+		hostnames = append(hostnames, hostname)
+	}
+	sort.Strings(hostnames)
+	overrides := make([]dominator.HostImageOverride, 0, len(hostnames))
+	for _, hostname := range hostnames {
+		overrides = append(overrides,
+			herd.hostImageOverrides.overrides[hostname])
+	}
+	return overrides
+}
+
+// expireHostImageOverrides is called at the end of each scan cycle to remove
+// expired overrides.
+func (herd *Herd) expireHostImageOverrides() {
+	var expiredHostnames []string
+	timeNow := time.Now()
+	herd.hostImageOverrides.Lock()
+	for hostname, override := range herd.hostImageOverrides.overrides {
+		if !override.Expires.After(timeNow) {
+			delete(herd.hostImageOverrides.overrides, hostname)
+			expiredHostnames = append(expiredHostnames, hostname)
+		}
+	}
+	if len(expiredHostnames) > 0 {
+		herd.saveHostImageOverrides()
+	}
+	herd.hostImageOverrides.Unlock()
+	for _, hostname := range expiredHostnames {
+		herd.logger.Printf("Image override for: %s expired\n", hostname)
+		herd.forceRepollOfSub(hostname)
+	}
+}
+
+// addHostImageOverrideImages adds the images of the overrides to the images
+// which the image manager should keep.
+func (herd *Herd) addHostImageOverrideImages(images map[string]struct{}) {
+	herd.hostImageOverrides.Lock()
+	defer herd.hostImageOverrides.Unlock()
+	for _, override := range herd.hostImageOverrides.overrides {
+		if override.ImageName != "" {
+			images[override.ImageName] = struct{}{}
+		}
+	}
+}
+
+// forceRepollOfSub will force a full poll of the sub (if known), so that its
+// required image is re-computed.
+func (herd *Herd) forceRepollOfSub(hostname string) {
+	herd.RLock()
+	defer herd.RUnlock()
+	if sub := herd.subsByName[hostname]; sub != nil {
+		sub.requestFullPoll()
+	}
+}
+
+// getHostImageOverride returns the override for the sub, if there is one which
+// has not expired.
+func (sub *Sub) getHostImageOverride() (dominator.HostImageOverride, bool) {
+	sub.herd.hostImageOverrides.Lock()
+	defer sub.herd.hostImageOverrides.Unlock()
+	override, ok := sub.herd.hostImageOverrides.overrides[sub.mdb.Hostname]
+	if !ok || !override.Expires.After(time.Now()) {
+		return dominator.HostImageOverride{}, false
+	}
+	return override, true
+}
+
+func (sub *Sub) showHostImageOverride(writer io.Writer) {
+	override, ok := sub.getHostImageOverride()
+	if !ok {
+		fmt.Fprintln(writer, "    <td></td>")
+		return
+	}
+	description := override.ImageName
+	if override.DisableUpdates {
+		if description == "" {
+			description = "updates disabled"
+		} else {
+			description += ", updates disabled"
+		}
+	}
+	fmt.Fprintf(writer,
+		"    <td title=\"%s (by %s)\"><font color=\"#CC6600\">%s (%s left)</font></td>\n",
+		html.EscapeString(override.Reason), override.SetBy, description,
+		format.Duration(time.Until(override.Expires)))
+}
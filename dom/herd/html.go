@@ -25,6 +25,9 @@ func (herd *Herd) writeHtml(writer io.Writer) {
 		herd.writeDisableStatus(writer)
 		fmt.Fprintln(writer, "<br>")
 	}
+	herd.writeRolloutStatus(writer)
+	herd.writeUpdateLimits(writer)
+	herd.writeQuarantinedImages(writer)
 	numSubs := herd.countSelectedSubs(nil)
 	fmt.Fprintf(writer, "Time since current cycle start: %s<br>\n",
 		time.Since(herd.currentScanStartTime))
@@ -148,6 +151,14 @@ func selectDeviantSub(sub *Sub) bool {
 		return true
 	case statusUpdatesDisabled:
 		return true
+	case statusImageQuarantined:
+		return true
+	case statusWaitingForRollout:
+		return true
+	case statusWaitingForMaintenanceWindow:
+		return true
+	case statusWaitingForUpdateSlot:
+		return true
 	case statusUpdating:
 		return true
 	case statusUpdateDenied:
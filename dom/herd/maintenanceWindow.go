@@ -0,0 +1,255 @@
+package herd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/json"
+	"github.com/Symantec/Dominator/lib/tags"
+	"github.com/Symantec/Dominator/lib/triggers"
+)
+
+var (
+	maintenanceWindowTagKey = flag.String("maintenanceWindowTagKey",
+		"MaintenanceWindow",
+		"MDB tag key containing the maintenance windows for a sub")
+	maintenanceWindowsFile = flag.String("maintenanceWindowsFile", "",
+		"Optional JSON file containing default maintenance windows for subs")
+
+	dayNames = map[string]time.Weekday{
+		"Sun": time.Sunday,
+		"Mon": time.Monday,
+		"Tue": time.Tuesday,
+		"Wed": time.Wednesday,
+		"Thu": time.Thursday,
+		"Fri": time.Friday,
+		"Sat": time.Saturday,
+	}
+)
+
+// maintenanceWindowPolicy specifies the maintenance windows for subs which
+// have all the specified tags. Windows in the MDB take precedence.
+type maintenanceWindowPolicy struct {
+	Tags    tags.Tags
+	Windows string
+}
+
+type maintenanceWindow struct {
+	days     [7]bool
+	start    time.Duration // Offset since midnight.
+	end      time.Duration // Offset since midnight, may be before start.
+	location *time.Location
+}
+
+type maintenanceWindows []maintenanceWindow
+
+func loadMaintenanceWindowPolicies(filename string) (
+	[]maintenanceWindowPolicy, error) {
+	if filename == "" {
+		return nil, nil
+	}
+	var policies []maintenanceWindowPolicy
+	if err := json.ReadFromFile(filename, &policies); err != nil {
+		return nil, err
+	}
+	for _, policy := range policies {
+		if _, err := parseMaintenanceWindows(policy.Windows); err != nil {
+			return nil, err
+		}
+	}
+	return policies, nil
+}
+
+// parseMaintenanceWindows parses a specification of the form:
+//
+//	days HH:MM-HH:MM [timezone][;...]
+//
+// where days is a comma separated list of day names or ranges of day names
+// (such as "Mon-Fri,Sun") or "*" for every day. If the end time is not after
+// the start time the window extends into the following day. If no timezone is
+// given, UTC is used.
+func parseMaintenanceWindows(spec string) (maintenanceWindows, error) {
+	var windows maintenanceWindows
+	for _, windowSpec := range strings.Split(spec, ";") {
+		fields := strings.Fields(windowSpec)
+		if len(fields) < 1 {
+			continue
+		}
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("bad maintenance window: \"%s\"",
+				windowSpec)
+		}
+		var window maintenanceWindow
+		if err := window.parseDays(fields[0]); err != nil {
+			return nil, err
+		}
+		times := strings.Split(fields[1], "-")
+		if len(times) != 2 {
+			return nil, fmt.Errorf("bad time range: \"%s\"", fields[1])
+		}
+		var err error
+		if window.start, err = parseTimeOfDay(times[0]); err != nil {
+			return nil, err
+		}
+		if window.end, err = parseTimeOfDay(times[1]); err != nil {
+			return nil, err
+		}
+		window.location = time.UTC
+		if len(fields) > 2 {
+			window.location, err = time.LoadLocation(fields[2])
+			if err != nil {
+				return nil, err
+			}
+		}
+		windows = append(windows, window)
+	}
+	if len(windows) < 1 {
+		return nil, errors.New("no maintenance windows specified")
+	}
+	return windows, nil
+}
+
+func (window *maintenanceWindow) parseDays(days string) error {
+	if days == "*" {
+		for day := range window.days {
+			window.days[day] = true
+		}
+		return nil
+	}
+	for _, dayRange := range strings.Split(days, ",") {
+		limits := strings.Split(dayRange, "-")
+		if len(limits) > 2 {
+			return fmt.Errorf("bad day range: \"%s\"", dayRange)
+		}
+		first, ok := dayNames[limits[0]]
+		if !ok {
+			return fmt.Errorf("unknown day: \"%s\"", limits[0])
+		}
+		last := first
+		if len(limits) > 1 {
+			if last, ok = dayNames[limits[1]]; !ok {
+				return fmt.Errorf("unknown day: \"%s\"", limits[1])
+			}
+		}
+		for day := first; ; day = (day + 1) % 7 {
+			window.days[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func parseTimeOfDay(timeOfDay string) (time.Duration, error) {
+	var hours, minutes uint
+	_, err := fmt.Sscanf(timeOfDay, "%d:%d", &hours, &minutes)
+	if err != nil {
+		return 0, fmt.Errorf("bad time: \"%s\": %s", timeOfDay, err)
+	}
+	if hours > 24 || minutes > 59 || (hours == 24 && minutes > 0) {
+		return 0, fmt.Errorf("bad time: \"%s\"", timeOfDay)
+	}
+	return time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute, nil
+}
+
+func (windows maintenanceWindows) isOpen(t time.Time) bool {
+	for _, window := range windows {
+		if window.isOpen(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (window maintenanceWindow) isOpen(t time.Time) bool {
+	t = t.In(window.location)
+	offset := time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+	weekday := t.Weekday()
+	if window.start < window.end {
+		return window.days[weekday] &&
+			offset >= window.start && offset < window.end
+	}
+	if window.days[weekday] && offset >= window.start {
+		return true
+	}
+	return window.days[(weekday+6)%7] && offset < window.end
+}
+
+func (herd *Herd) loadMaintenanceWindowPolicies() error {
+	policies, err := loadMaintenanceWindowPolicies(*maintenanceWindowsFile)
+	if err != nil {
+		return fmt.Errorf("cannot load maintenance windows: %s", err)
+	}
+	herd.windowPolicies = policies
+	return nil
+}
+
+// getMaintenanceWindowSpec returns the maintenance window specification for
+// the sub, or the empty string if there are no restrictions.
+func (sub *Sub) getMaintenanceWindowSpec() string {
+	if spec, ok := sub.mdb.Tags[*maintenanceWindowTagKey]; ok {
+		return spec
+	}
+	for _, policy := range sub.herd.windowPolicies {
+		matched := true
+		for key, value := range policy.Tags {
+			if sub.mdb.Tags[key] != value {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return policy.Windows
+		}
+	}
+	return ""
+}
+
+// holdForMaintenanceWindow returns true if the update must be held back until
+// the maintenance window for the sub opens. Only updates which will trigger
+// high impact service restarts or reboots are held back.
+func (sub *Sub) holdForMaintenanceWindow(
+	matchedTriggers []*triggers.Trigger) bool {
+	spec := sub.getMaintenanceWindowSpec()
+	if spec == "" {
+		return false
+	}
+	highImpact := false
+	for _, trigger := range matchedTriggers {
+		if trigger.HighImpact || trigger.DoReboot {
+			highImpact = true
+			break
+		}
+	}
+	if !highImpact {
+		return false
+	}
+	windows, err := parseMaintenanceWindows(spec)
+	if err != nil {
+		sub.herd.logger.Printf("Bad maintenance window for: %s: %s\n",
+			sub, err)
+		return true // Err on the side of caution.
+	}
+	return !windows.isOpen(time.Now())
+}
+
+// maintenanceWindowIsOpen returns true if the sub has no maintenance window
+// restrictions or if the maintenance window is open.
+func (sub *Sub) maintenanceWindowIsOpen() bool {
+	spec := sub.getMaintenanceWindowSpec()
+	if spec == "" {
+		return true
+	}
+	windows, err := parseMaintenanceWindows(spec)
+	if err != nil {
+		return false
+	}
+	return windows.isOpen(time.Now())
+}
@@ -0,0 +1,51 @@
+package herd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaintenanceWindows(t *testing.T) {
+	var tests = []struct {
+		spec string
+		time string // Monday 2 Jan 2006 is a Monday.
+		want bool
+	}{
+		{"* 02:00-04:00", "2006-01-02T03:00:00Z", true},
+		{"* 02:00-04:00", "2006-01-02T04:00:00Z", false},
+		{"Mon-Fri 02:00-04:00", "2006-01-07T03:00:00Z", false},
+		{"Sat,Sun 00:00-24:00", "2006-01-07T13:00:00Z", true},
+		{"Fri-Mon 00:00-24:00", "2006-01-08T13:00:00Z", true},
+		{"Fri-Mon 00:00-24:00", "2006-01-04T13:00:00Z", false},
+		{"Sun 22:00-06:00", "2006-01-02T05:00:00Z", true},
+		{"Sun 22:00-06:00", "2006-01-03T05:00:00Z", false},
+		{"Mon 09:00-10:00;Tue 09:00-10:00", "2006-01-03T09:30:00Z", true},
+		{"Mon 09:00-10:00 America/New_York", "2006-01-02T14:30:00Z", true},
+		{"Mon 09:00-10:00 America/New_York", "2006-01-02T09:30:00Z", false},
+	}
+	for _, test := range tests {
+		windows, err := parseMaintenanceWindows(test.spec)
+		if err != nil {
+			t.Errorf("parseMaintenanceWindows(%q): %s", test.spec, err)
+			continue
+		}
+		tm, err := time.Parse(time.RFC3339, test.time)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := windows.isOpen(tm); got != test.want {
+			t.Errorf("isOpen(%q, %s) = %v", test.spec, test.time, got)
+		}
+	}
+}
+
+func TestBadMaintenanceWindows(t *testing.T) {
+	for _, spec := range []string{
+		"", "Mon", "Mon 02:00", "Mon 25:00-26:00", "Xyz 02:00-03:00",
+		"Mon-Tue-Wed 02:00-03:00", "Mon 02:00-03:00 Bad/Zone",
+	} {
+		if _, err := parseMaintenanceWindows(spec); err == nil {
+			t.Errorf("parseMaintenanceWindows(%q) succeeded", spec)
+		}
+	}
+}
@@ -67,13 +67,14 @@ func (herd *Herd) mdbUpdateGetLock(mdb *mdb.Mdb) (
 				mdb:           machine,
 				cancelChannel: make(chan struct{}),
 			}
+			sub.restoreState()
 			herd.subsByName[machine.Hostname] = sub
 			sub.fileUpdateChannel = herd.computedFilesManager.Add(
 				filegenclient.Machine{machine, sub.getComputedFiles(img)}, 16)
 			numNew++
 		} else {
 			if sub.mdb.RequiredImage != machine.RequiredImage {
-				if sub.status == statusSynced {
+				if sub.status.isSynced() {
 					sub.status = statusWaitingToPoll
 				}
 			}
@@ -88,6 +89,10 @@ func (herd *Herd) mdbUpdateGetLock(mdb *mdb.Mdb) (
 		}
 		delete(subsToDelete, machine.Hostname)
 		herd.subsByIndex = append(herd.subsByIndex, sub)
+		if imageName := herd.getConfiguredImageName(sub); imageName != "" &&
+			herd.isImageQuarantined(imageName) {
+			wantedImages[sub.getFallbackImageName(imageName)] = struct{}{}
+		}
 		img = herd.imageManager.GetNoError(machine.PlannedImage)
 		if img == nil {
 			sub.havePlannedImage = false
@@ -95,6 +100,7 @@ func (herd *Herd) mdbUpdateGetLock(mdb *mdb.Mdb) (
 			sub.havePlannedImage = true
 		}
 	}
+	herd.addHostImageOverrideImages(wantedImages)
 	delete(wantedImages, "")
 	// Delete flagged subs (those not in the new MDB).
 	clientResourcesToDelete := make([]*srpc.ClientResource, 0)
@@ -107,8 +113,14 @@ func (herd *Herd) mdbUpdateGetLock(mdb *mdb.Mdb) (
 				sub.clientResource)
 		}
 		sub.deletingFlagMutex.Unlock()
+		sub.releaseUpdateSlot()
 		herd.computedFilesManager.Remove(subHostname)
+		herd.objectPeers.remove(sub)
+		if herd.rollout != nil {
+			herd.rollout.removeSub(sub)
+		}
 		delete(herd.subsByName, subHostname)
+		herd.sendSubDeleted(sub)
 		numDeleted++
 	}
 	mdbUpdateTimeDistribution.Add(time.Since(startTime))
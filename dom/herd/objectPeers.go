@@ -0,0 +1,111 @@
+package herd
+
+import (
+	"flag"
+	"math/rand"
+	"sync"
+
+	"github.com/Symantec/Dominator/lib/hash"
+	"github.com/Symantec/Dominator/lib/objectcache"
+	subproto "github.com/Symantec/Dominator/proto/sub"
+)
+
+var (
+	maxFetchPeers = flag.Uint("maxFetchPeers", 4,
+		"Maximum number of peer subs to fetch objects from (0: use only the objectserver)")
+)
+
+// objectPeersType records the object caches of the subs, so that subs may fetch
+// objects from their peers. The object cache of a sub is replaced on each full
+// poll and objects are removed when the sub is told to clean them up. The
+// records may be stale (such as when objects are consumed by an update), in
+// which case the sub falls back to the objectserver.
+type objectPeersType struct {
+	sync.Mutex
+	objectCaches map[*Sub]map[hash.Hash]struct{}
+}
+
+func newObjectPeers() *objectPeersType {
+	return &objectPeersType{
+		objectCaches: make(map[*Sub]map[hash.Hash]struct{}),
+	}
+}
+
+func (op *objectPeersType) add(sub *Sub, objectCache objectcache.ObjectCache) {
+	if *maxFetchPeers < 1 {
+		return
+	}
+	if len(objectCache) < 1 {
+		op.remove(sub)
+		return
+	}
+	hashes := make(map[hash.Hash]struct{}, len(objectCache))
+	for _, hashVal := range objectCache {
+		hashes[hashVal] = struct{}{}
+	}
+	op.Lock()
+	defer op.Unlock()
+	op.objectCaches[sub] = hashes
+}
+
+func (op *objectPeersType) remove(sub *Sub) {
+	op.Lock()
+	defer op.Unlock()
+	delete(op.objectCaches, sub)
+}
+
+func (op *objectPeersType) removeObjects(sub *Sub, hashes []hash.Hash) {
+	op.Lock()
+	defer op.Unlock()
+	objectCache, ok := op.objectCaches[sub]
+	if !ok {
+		return
+	}
+	for _, hashVal := range hashes {
+		delete(objectCache, hashVal)
+	}
+	if len(objectCache) < 1 {
+		delete(op.objectCaches, sub)
+	}
+}
+
+// getPeers returns up to maxFetchPeers randomly chosen peers which have some
+// of the objects, so that the load is spread across the peers.
+func (op *objectPeersType) getPeers(sub *Sub,
+	hashes []hash.Hash) []subproto.FetchPeer {
+	if *maxFetchPeers < 1 {
+		return nil
+	}
+	op.Lock()
+	defer op.Unlock()
+	candidates := make([]*Sub, 0, len(op.objectCaches))
+	for peer := range op.objectCaches {
+		if peer != sub {
+			candidates = append(candidates, peer)
+		}
+	}
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	var peers []subproto.FetchPeer
+	for _, peer := range candidates {
+		objectCache := op.objectCaches[peer]
+		var peerHashes []hash.Hash
+		for _, hashVal := range hashes {
+			if _, ok := objectCache[hashVal]; ok {
+				peerHashes = append(peerHashes, hashVal)
+			}
+		}
+		if len(peerHashes) < 1 {
+			continue
+		}
+		peers = append(peers, subproto.FetchPeer{
+			Address: peer.address(),
+			Hashes:  peerHashes,
+		})
+		if uint(len(peers)) >= *maxFetchPeers {
+			break
+		}
+	}
+	return peers
+}
@@ -0,0 +1,43 @@
+package herd
+
+import (
+	"testing"
+
+	"github.com/Symantec/Dominator/lib/hash"
+	"github.com/Symantec/Dominator/lib/mdb"
+	"github.com/Symantec/Dominator/lib/objectcache"
+)
+
+func TestObjectPeers(t *testing.T) {
+	hash0 := hash.Hash{0xde, 0xad}
+	hash1 := hash.Hash{0xbe, 0xef}
+	sub0 := &Sub{mdb: mdb.Machine{Hostname: "sub0"}}
+	sub1 := &Sub{mdb: mdb.Machine{Hostname: "sub1"}}
+	sub2 := &Sub{mdb: mdb.Machine{Hostname: "sub2"}}
+	op := newObjectPeers()
+	op.add(sub1, objectcache.ObjectCache{hash0, hash1})
+	op.add(sub2, objectcache.ObjectCache{hash1})
+	peers := op.getPeers(sub0, []hash.Hash{hash0})
+	if len(peers) != 1 || peers[0].Address != sub1.address() ||
+		len(peers[0].Hashes) != 1 || peers[0].Hashes[0] != hash0 {
+		t.Errorf("peers for hash0: %v", peers)
+	}
+	if peers := op.getPeers(sub1, []hash.Hash{hash0}); len(peers) != 0 {
+		t.Errorf("sub is its own peer: %v", peers)
+	}
+	op.removeObjects(sub1, []hash.Hash{hash0})
+	if peers := op.getPeers(sub0, []hash.Hash{hash0}); len(peers) != 0 {
+		t.Errorf("cleaned up object still has peers: %v", peers)
+	}
+	if peers := op.getPeers(sub0, []hash.Hash{hash1}); len(peers) != 2 {
+		t.Errorf("number of peers for hash1: %d != 2", len(peers))
+	}
+	op.add(sub1, objectcache.ObjectCache{hash0})
+	if peers := op.getPeers(sub0, []hash.Hash{hash1}); len(peers) != 1 {
+		t.Errorf("replaced object cache still used: %v", peers)
+	}
+	op.remove(sub2)
+	if peers := op.getPeers(sub0, []hash.Hash{hash1}); len(peers) != 0 {
+		t.Errorf("removed sub still a peer: %v", peers)
+	}
+}
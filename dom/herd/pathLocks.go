@@ -0,0 +1,47 @@
+package herd
+
+import (
+	"github.com/Symantec/Dominator/lib/filter"
+	"github.com/Symantec/Dominator/lib/log"
+	subproto "github.com/Symantec/Dominator/proto/sub"
+)
+
+// getLockedPathsFilter returns a filter matching the paths which are locked on
+// the sub, or nil if there are none.
+func getLockedPathsFilter(pathLocks []subproto.PathLock,
+	logger log.Logger) *filter.Filter {
+	if len(pathLocks) < 1 {
+		return nil
+	}
+	patterns := make([]string, 0, len(pathLocks))
+	for _, pathLock := range pathLocks {
+		patterns = append(patterns, pathLock.Pattern)
+	}
+	lockedPaths, err := filter.New(patterns)
+	if err != nil {
+		logger.Printf("Error compiling path locks: %s\n", err)
+		return nil
+	}
+	return lockedPaths
+}
+
+// pathLocksChanged returns true if the locked patterns are different.
+func pathLocksChanged(oldLocks, newLocks []subproto.PathLock) bool {
+	oldPatterns := make(map[string]struct{}, len(oldLocks))
+	for _, pathLock := range oldLocks {
+		oldPatterns[pathLock.Pattern] = struct{}{}
+	}
+	newPatterns := make(map[string]struct{}, len(newLocks))
+	for _, pathLock := range newLocks {
+		newPatterns[pathLock.Pattern] = struct{}{}
+	}
+	if len(oldPatterns) != len(newPatterns) {
+		return true
+	}
+	for pattern := range newPatterns {
+		if _, ok := oldPatterns[pattern]; !ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package herd
+
+import (
+	"testing"
+	"time"
+
+	subproto "github.com/Symantec/Dominator/proto/sub"
+)
+
+func TestPathLocksChanged(t *testing.T) {
+	etc := subproto.PathLock{Pattern: "/etc/.*"}
+	etcLater := subproto.PathLock{Pattern: "/etc/.*",
+		ExpiresAt: time.Now().Add(time.Hour)}
+	bin := subproto.PathLock{Pattern: "/bin/.*"}
+	var tests = []struct {
+		name            string
+		oldLocks, locks []subproto.PathLock
+		expectedChanged bool
+	}{
+		{"none", nil, nil, false},
+		{"same", []subproto.PathLock{etc}, []subproto.PathLock{etc}, false},
+		{"extended", []subproto.PathLock{etc}, []subproto.PathLock{etcLater},
+			false},
+		{"reordered", []subproto.PathLock{etc, bin},
+			[]subproto.PathLock{bin, etc}, false},
+		{"added", nil, []subproto.PathLock{etc}, true},
+		{"expired", []subproto.PathLock{etc}, nil, true},
+		{"replaced", []subproto.PathLock{etc}, []subproto.PathLock{bin}, true},
+		{"removedOne", []subproto.PathLock{etc, bin},
+			[]subproto.PathLock{bin}, true},
+	}
+	for _, test := range tests {
+		changed := pathLocksChanged(test.oldLocks, test.locks)
+		if changed != test.expectedChanged {
+			t.Errorf("%s: changed=%v, expected %v",
+				test.name, changed, test.expectedChanged)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package herd
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Symantec/Dominator/dom/lib"
+	"github.com/Symantec/Dominator/lib/hash"
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+	subproto "github.com/Symantec/Dominator/proto/sub"
+	"github.com/Symantec/Dominator/sub/client"
+)
+
+func (herd *Herd) getPlannedUpdate(hostname string) (
+	*dominator.GetPlannedUpdateResponse, error) {
+	sub := herd.getSub(hostname)
+	if sub == nil {
+		return nil, errors.New("unknown sub: " + hostname)
+	}
+	if !sub.waitToMakeBusy(time.Minute) {
+		return nil, errors.New("timed out waiting for sub to become idle")
+	}
+	defer sub.makeUnbusy()
+	herd.cpuSharer.GrabCpu()
+	defer herd.cpuSharer.ReleaseCpu()
+	return sub.getPlannedUpdate()
+}
+
+// waitToMakeBusy will wait until the sub can be made busy. It returns false if
+// this did not happen within the timeout.
+func (sub *Sub) waitToMakeBusy(timeout time.Duration) bool {
+	stopTime := time.Now().Add(timeout)
+	for !sub.tryMakeBusy() {
+		if time.Now().After(stopTime) {
+			return false
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return true
+}
+
+// getPlannedUpdate computes the update which would be sent to the sub, without
+// sending it, and the reason the update would be held back, if any. The sub
+// must be busy.
+func (sub *Sub) getPlannedUpdate() (*dominator.GetPlannedUpdateResponse,
+	error) {
+	sub.loadConfiguration()
+	sub.processFileUpdates()
+	if sub.requiredImage == nil {
+		if sub.requiredImageName == "" {
+			return nil, errors.New("no required image")
+		}
+		return nil, errors.New("image not ready: " + sub.requiredImageName)
+	}
+	sub.deletingFlagMutex.Lock()
+	if sub.deleting {
+		sub.deletingFlagMutex.Unlock()
+		return nil, errors.New("sub is being deleted")
+	}
+	if sub.clientResource == nil {
+		sub.clientResource = srpc.NewClientResource("tcp", sub.address())
+	}
+	sub.deletingFlagMutex.Unlock()
+	srpcClient, err := sub.clientResource.GetHTTPWithDialer(nil,
+		sub.herd.dialer)
+	if err != nil {
+		return nil, err
+	}
+	defer srpcClient.Put()
+	var pollReply subproto.PollResponse
+	err = client.CallPoll(srpcClient, subproto.PollRequest{}, &pollReply)
+	if err != nil {
+		srpcClient.Close()
+		return nil, err
+	}
+	fs := pollReply.FileSystem
+	if fs == nil {
+		return nil, errors.New("sub not ready")
+	}
+	if err := fs.RebuildInodePointers(); err != nil {
+		return nil, err
+	}
+	fs.BuildEntryMap()
+	subObj := lib.Sub{
+		Hostname:       sub.mdb.Hostname,
+		FileSystem:     fs,
+		ComputedInodes: sub.computedInodes,
+		ObjectCache:    pollReply.ObjectCache,
+		LockedPaths: getLockedPathsFilter(pollReply.PathLocks,
+			sub.herd.logger),
+	}
+	objectsToFetch, objectsToPush := lib.BuildMissingLists(subObj,
+		sub.requiredImage, true, false, sub.herd.logger)
+	if objectsToPush == nil {
+		return nil, errors.New("missing computed file(s)")
+	}
+	reply := &dominator.GetPlannedUpdateResponse{
+		ImageName:      sub.requiredImageName,
+		ObjectsToFetch: make([]hash.Hash, 0, len(objectsToFetch)),
+		ObjectsToPush:  make([]hash.Hash, 0, len(objectsToPush)),
+	}
+	// Assume the missing objects have been fetched and pushed.
+	for hashVal := range objectsToFetch {
+		reply.ObjectsToFetch = append(reply.ObjectsToFetch, hashVal)
+		subObj.ObjectCache = append(subObj.ObjectCache, hashVal)
+	}
+	for hashVal := range objectsToPush {
+		reply.ObjectsToPush = append(reply.ObjectsToPush, hashVal)
+		subObj.ObjectCache = append(subObj.ObjectCache, hashVal)
+	}
+	reply.UpdateRequest.ImageName = sub.requiredImageName
+	if lib.BuildUpdateRequest(subObj, sub.requiredImage, &reply.UpdateRequest,
+		false, false, sub.herd.logger) {
+		return nil, errors.New("missing computed file(s)")
+	}
+	reply.MatchedTriggers = lib.MatchTriggers(reply.UpdateRequest)
+	reply.UnsafeUpdate = checkForUnsafeChange(sub.requiredImage, fs,
+		reply.UpdateRequest)
+	if held, status := sub.holdUpdate(fs, reply.UpdateRequest,
+		reply.MatchedTriggers); held {
+		reply.HoldReason = status.String()
+	} else if !sub.updateSlotAvailable(reply.MatchedTriggers) {
+		reply.HoldReason = subStatus(statusWaitingForUpdateSlot).String()
+	}
+	return reply, nil
+}
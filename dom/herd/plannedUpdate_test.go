@@ -0,0 +1,97 @@
+package herd
+
+import (
+	"testing"
+
+	"github.com/Symantec/Dominator/lib/image"
+	"github.com/Symantec/Dominator/lib/log/testlogger"
+	"github.com/Symantec/Dominator/lib/tags"
+	"github.com/Symantec/Dominator/lib/triggers"
+	"github.com/Symantec/Dominator/proto/dominator"
+	subproto "github.com/Symantec/Dominator/proto/sub"
+)
+
+func makeHoldTestHerd(t *testing.T) *Herd {
+	herd := &Herd{logger: testlogger.New(t)}
+	herd.updateLimiter = newUpdateLimiter()
+	herd.quarantine = newQuarantine()
+	herd.hostImageOverrides = newHostImageOverrides()
+	return herd
+}
+
+func makeHoldTestSub(herd *Herd, hostname string) *Sub {
+	sub := &Sub{
+		herd:              herd,
+		requiredImageName: "image",
+		requiredImage:     &image.Image{},
+	}
+	sub.mdb.Hostname = hostname
+	sub.mdb.Tags = tags.Tags{"rack": "rack0"}
+	return sub
+}
+
+func TestHoldUpdate(t *testing.T) {
+	var tests = []struct {
+		name   string
+		setup  func(herd *Herd, sub *Sub)
+		held   bool
+		status subStatus
+	}{
+		{"none", func(herd *Herd, sub *Sub) {}, false, statusUnknown},
+		{"disabled", func(herd *Herd, sub *Sub) {
+			sub.mdb.DisableUpdates = true
+		}, true, statusUpdatesDisabled},
+		{"herd disabled", func(herd *Herd, sub *Sub) {
+			herd.updatesDisabledReason = "testing"
+		}, true, statusUpdatesDisabled},
+		{"quarantined", func(herd *Herd, sub *Sub) {
+			herd.quarantine.images["image"] = quarantinedImage{}
+		}, true, statusImageQuarantined},
+		{"rollout", func(herd *Herd, sub *Sub) {
+			herd.rollout = &rolloutType{
+				imageName: "image",
+				admitted:  make(map[*Sub]struct{}),
+			}
+		}, true, statusWaitingForRollout},
+	}
+	for _, test := range tests {
+		herd := makeHoldTestHerd(t)
+		sub := makeHoldTestSub(herd, "sub0")
+		test.setup(herd, sub)
+		held, status := sub.holdUpdate(nil, subproto.UpdateRequest{}, nil)
+		if held != test.held || status != test.status {
+			t.Errorf("%s: held: %v (%s) != %v (%s)",
+				test.name, held, status, test.held, test.status)
+		}
+	}
+}
+
+func TestUpdateSlotAvailable(t *testing.T) {
+	herd := makeHoldTestHerd(t)
+	herd.updateLimiter.limits = dominator.UpdateConcurrencyLimits{
+		TagKey:                       "rack",
+		MaxTriggeringUpdatesPerGroup: 1,
+	}
+	sub0 := makeHoldTestSub(herd, "sub0")
+	sub1 := makeHoldTestSub(herd, "sub1")
+	matchedTriggers := []*triggers.Trigger{{Service: "service"}}
+	if !sub0.updateSlotAvailable(nil) {
+		t.Error("slot not available for update without triggers")
+	}
+	if !sub0.acquireUpdateSlot(matchedTriggers) {
+		t.Fatal("failed to acquire slot")
+	}
+	if !sub0.updateSlotAvailable(matchedTriggers) {
+		t.Error("slot not available to holder")
+	}
+	if sub1.updateSlotAvailable(matchedTriggers) {
+		t.Error("slot available beyond limit")
+	}
+	sub0.releaseUpdateSlot()
+	if !sub1.updateSlotAvailable(matchedTriggers) {
+		t.Error("slot not available after release")
+	}
+	if sub1.updateSlot != nil {
+		t.Error("slot acquired by check")
+	}
+}
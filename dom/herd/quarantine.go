@@ -0,0 +1,223 @@
+package herd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/json"
+)
+
+const quarantinedImagesFile = "quarantinedImages.json"
+
+var (
+	rollbackFailureCount = flag.Uint("rollbackFailureCount", 0,
+		"Quarantine an image if this many subs fail to update to it (0: never)")
+	rollbackFailurePercent = flag.Uint("rollbackFailurePercent", 0,
+		"Quarantine an image if this percentage of subs fail to update to it (0: never)")
+)
+
+// quarantinedImage records why an image was quarantined. Subs which require a
+// quarantined image are rolled back to their last successful image.
+type quarantinedImage struct {
+	Reason string
+	Time   time.Time
+}
+
+type quarantineType struct {
+	sync.Mutex
+	images map[string]quarantinedImage
+}
+
+type imageFailureStats struct {
+	numSubs   uint
+	numFailed uint
+}
+
+func newQuarantine() *quarantineType {
+	return &quarantineType{images: make(map[string]quarantinedImage)}
+}
+
+func (herd *Herd) isImageQuarantined(imageName string) bool {
+	herd.quarantine.Lock()
+	defer herd.quarantine.Unlock()
+	_, ok := herd.quarantine.images[imageName]
+	return ok
+}
+
+func (herd *Herd) loadQuarantinedImages() error {
+	filename := path.Join(herd.stateDir, quarantinedImagesFile)
+	images := make(map[string]quarantinedImage)
+	if err := json.ReadFromFile(filename, &images); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	herd.quarantine.Lock()
+	defer herd.quarantine.Unlock()
+	herd.quarantine.images = images
+	return nil
+}
+
+// This should be called with the quarantine lock held.
+func (herd *Herd) saveQuarantinedImages() {
+	if herd.stateDir == "" {
+		return
+	}
+	err := json.WriteToFile(path.Join(herd.stateDir, quarantinedImagesFile),
+		stateFilePerms, "    ", herd.quarantine.images)
+	if err != nil {
+		herd.logger.Printf("Error saving quarantined images: %s\n", err)
+	}
+}
+
+func (herd *Herd) clearImageQuarantine(imageName string) error {
+	herd.quarantine.Lock()
+	if _, ok := herd.quarantine.images[imageName]; !ok {
+		herd.quarantine.Unlock()
+		return errors.New("image not quarantined: " + imageName)
+	}
+	delete(herd.quarantine.images, imageName)
+	herd.saveQuarantinedImages()
+	herd.quarantine.Unlock()
+	herd.forceRepollOfImageSubs(imageName)
+	return nil
+}
+
+// checkImageFailures is called at the end of each scan cycle to quarantine
+// images which too many subs failed to update to.
+func (herd *Herd) checkImageFailures() {
+	if *rollbackFailureCount < 1 && *rollbackFailurePercent < 1 {
+		return
+	}
+	stats := make(map[string]*imageFailureStats)
+	herd.RLock()
+	for _, sub := range herd.subsByIndex {
+		imageName := sub.requiredImageName
+		if imageName == "" {
+			continue
+		}
+		imageStats := stats[imageName]
+		if imageStats == nil {
+			imageStats = &imageFailureStats{}
+			stats[imageName] = imageStats
+		}
+		imageStats.numSubs++
+		if sub.failedToUpdateTo(imageName) {
+			imageStats.numFailed++
+		}
+	}
+	herd.RUnlock()
+	for imageName, imageStats := range stats {
+		if imageStats.numFailed < 1 || herd.isImageQuarantined(imageName) {
+			continue
+		}
+		if (*rollbackFailureCount > 0 &&
+			imageStats.numFailed >= *rollbackFailureCount) ||
+			(*rollbackFailurePercent > 0 && imageStats.numFailed*100 >=
+				imageStats.numSubs**rollbackFailurePercent) {
+			herd.quarantineImage(imageName, fmt.Sprintf(
+				"%d of %d subs failed to update", imageStats.numFailed,
+				imageStats.numSubs))
+		}
+	}
+}
+
+func (herd *Herd) quarantineImage(imageName, reason string) {
+	herd.logger.Printf("Quarantining image: %s because %s\n", imageName, reason)
+	herd.quarantine.Lock()
+	herd.quarantine.images[imageName] = quarantinedImage{
+		Reason: reason,
+		Time:   time.Now(),
+	}
+	herd.saveQuarantinedImages()
+	herd.quarantine.Unlock()
+	fallbackImages := herd.forceRepollOfImageSubs(imageName)
+	go func() {
+		for imageName := range fallbackImages {
+			herd.imageManager.Get(imageName, true)
+		}
+	}()
+}
+
+// forceRepollOfImageSubs will force a full poll of subs which are configured
+// for the specified image, so that their required image is re-computed. It
+// returns the fallback images for these subs.
+func (herd *Herd) forceRepollOfImageSubs(imageName string) map[string]struct{} {
+	fallbackImages := make(map[string]struct{})
+	herd.RLock()
+	defer herd.RUnlock()
+	for _, sub := range herd.subsByIndex {
+		if herd.getConfiguredImageName(sub) != imageName {
+			continue
+		}
+		if name := sub.getFallbackImageName(imageName); name != "" {
+			fallbackImages[name] = struct{}{}
+		}
+		sub.requestFullPoll()
+	}
+	return fallbackImages
+}
+
+// getConfiguredImageName returns the image the sub should be running according
+// to the host image override, the MDB and the default image.
+func (herd *Herd) getConfiguredImageName(sub *Sub) string {
+	if override, _ := sub.getHostImageOverride(); override.ImageName != "" {
+		return override.ImageName
+	}
+	if sub.mdb.RequiredImage != "" {
+		return sub.mdb.RequiredImage
+	}
+	return herd.defaultImageName
+}
+
+// failedToUpdateTo returns true if the last update of the sub to the specified
+// image failed or had trigger failures. Only updates sent since the image
+// became the required image of the sub are considered, so that stale results
+// from earlier updates are not counted.
+func (sub *Sub) failedToUpdateTo(imageName string) bool {
+	if sub.lastUpdateImageName != imageName ||
+		sub.lastUpdateTime.Before(sub.requiredImageTime) {
+		return false
+	}
+	if sub.lastSuccessfulImageName == imageName {
+		return sub.lastUpdateHadTriggerFailures
+	}
+	return sub.publishedStatus == statusFailedToUpdate
+}
+
+// getFallbackImageName returns the image to roll back to if the specified
+// image is quarantined, or the empty string if there is none.
+func (sub *Sub) getFallbackImageName(badImageName string) string {
+	for _, name := range []string{
+		sub.lastSuccessfulImageName, sub.fallbackImageName} {
+		if name != "" && name != badImageName &&
+			!sub.herd.isImageQuarantined(name) {
+			return name
+		}
+	}
+	return ""
+}
+
+func (herd *Herd) writeQuarantinedImages(writer io.Writer) {
+	herd.quarantine.Lock()
+	defer herd.quarantine.Unlock()
+	imageNames := make([]string, 0, len(herd.quarantine.images))
+	for imageName := range herd.quarantine.images {
+		imageNames = append(imageNames, imageName)
+	}
+	sort.Strings(imageNames)
+	for _, imageName := range imageNames {
+		image := herd.quarantine.images[imageName]
+		fmt.Fprintf(writer,
+			"<font color=\"red\">Quarantined image: %s (%s, %s ago)</font><br>\n",
+			imageName, image.Reason, time.Since(image.Time))
+	}
+}
@@ -0,0 +1,80 @@
+package herd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFailedToUpdateTo(t *testing.T) {
+	now := time.Now()
+	var tests = []struct {
+		name string
+		sub  *Sub
+		fail bool
+	}{
+		{
+			name: "failed update",
+			sub: &Sub{
+				requiredImageTime:   now.Add(-time.Hour),
+				lastUpdateTime:      now,
+				lastUpdateImageName: "image",
+				publishedStatus:     statusFailedToUpdate,
+			},
+			fail: true,
+		},
+		{
+			name: "trigger failures",
+			sub: &Sub{
+				requiredImageTime:            now.Add(-time.Hour),
+				lastUpdateTime:               now,
+				lastUpdateImageName:          "image",
+				lastSuccessfulImageName:      "image",
+				lastUpdateHadTriggerFailures: true,
+			},
+			fail: true,
+		},
+		{
+			name: "synced",
+			sub: &Sub{
+				requiredImageTime:       now.Add(-time.Hour),
+				lastUpdateTime:          now,
+				lastUpdateImageName:     "image",
+				lastSuccessfulImageName: "image",
+				publishedStatus:         statusSynced,
+			},
+		},
+		{
+			name: "stale trigger failures",
+			sub: &Sub{
+				requiredImageTime:            now,
+				lastSuccessfulImageName:      "image",
+				lastUpdateHadTriggerFailures: true,
+			},
+		},
+		{
+			name: "update sent before image required",
+			sub: &Sub{
+				requiredImageTime:            now,
+				lastUpdateTime:               now.Add(-time.Hour),
+				lastUpdateImageName:          "image",
+				lastSuccessfulImageName:      "image",
+				lastUpdateHadTriggerFailures: true,
+			},
+		},
+		{
+			name: "update to other image",
+			sub: &Sub{
+				requiredImageTime:   now.Add(-time.Hour),
+				lastUpdateTime:      now,
+				lastUpdateImageName: "other",
+				publishedStatus:     statusFailedToUpdate,
+			},
+		},
+	}
+	for _, test := range tests {
+		if fail := test.sub.failedToUpdateTo("image"); fail != test.fail {
+			t.Errorf("%s: failed: %v, expected: %v", test.name, fail,
+				test.fail)
+		}
+	}
+}
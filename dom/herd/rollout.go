@@ -0,0 +1,325 @@
+package herd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+type rolloutState uint
+
+const (
+	rolloutStateRunning rolloutState = iota
+	rolloutStatePaused
+	rolloutStateAborted
+	rolloutStateCompleted
+)
+
+type rolloutType struct {
+	sync.Mutex
+	imageName       string
+	wavePercentages []uint
+	maxFailures     uint
+	currentWave     uint
+	state           rolloutState
+	pauseReason     string
+	startedBy       string
+	startTime       time.Time
+	admitted        map[*Sub]struct{}
+	numSubs         uint
+	numSynced       uint
+	numFailed       uint
+}
+
+func (state rolloutState) String() string {
+	switch state {
+	case rolloutStateRunning:
+		return "running"
+	case rolloutStatePaused:
+		return "paused"
+	case rolloutStateAborted:
+		return "aborted"
+	case rolloutStateCompleted:
+		return "completed"
+	default:
+		panic(fmt.Sprintf("unknown rollout state: %d", state))
+	}
+}
+
+func (herd *Herd) startRollout(username string,
+	request dominator.StartRolloutRequest) error {
+	if request.ImageName == "" {
+		return errors.New("no image name given")
+	}
+	if len(request.WavePercentages) < 1 {
+		return errors.New("no waves given")
+	}
+	var lastPercentage uint
+	for _, percentage := range request.WavePercentages {
+		if percentage <= lastPercentage || percentage > 100 {
+			return errors.New("wave percentages must be increasing, <= 100")
+		}
+		lastPercentage = percentage
+	}
+	if lastPercentage != 100 {
+		return errors.New("last wave must be 100 percent")
+	}
+	if _, err := herd.getImageToUse(request.ImageName); err != nil {
+		return err
+	}
+	rollout := &rolloutType{
+		imageName:       request.ImageName,
+		wavePercentages: request.WavePercentages,
+		maxFailures:     request.MaxFailures,
+		startedBy:       username,
+		startTime:       time.Now(),
+		admitted:        make(map[*Sub]struct{}),
+	}
+	herd.Lock()
+	defer herd.Unlock()
+	if herd.rollout != nil {
+		herd.rollout.Lock()
+		state := herd.rollout.state
+		herd.rollout.Unlock()
+		if state == rolloutStateRunning || state == rolloutStatePaused {
+			return errors.New("rollout already in progress for: " +
+				herd.rollout.imageName)
+		}
+	}
+	herd.rollout = rollout
+	rollout.update(herd.subsByIndex)
+	return nil
+}
+
+func (herd *Herd) getRollout() *rolloutType {
+	herd.RLock()
+	defer herd.RUnlock()
+	return herd.rollout
+}
+
+// abortRollout stops the rollout from admitting more subs. Subs which were
+// not yet admitted remain on their current image until their required image is
+// changed or a new rollout is started.
+func (herd *Herd) abortRollout() error {
+	rollout := herd.getRollout()
+	if rollout == nil {
+		return errors.New("no rollout")
+	}
+	rollout.Lock()
+	defer rollout.Unlock()
+	if rollout.state == rolloutStateCompleted ||
+		rollout.state == rolloutStateAborted {
+		return errors.New("rollout already " + rollout.state.String())
+	}
+	rollout.state = rolloutStateAborted
+	rollout.pauseReason = ""
+	return nil
+}
+
+func (herd *Herd) pauseRollout(reason string) error {
+	if reason == "" {
+		return errors.New("error pausing rollout: no reason given")
+	}
+	rollout := herd.getRollout()
+	if rollout == nil {
+		return errors.New("no rollout")
+	}
+	rollout.Lock()
+	defer rollout.Unlock()
+	if rollout.state != rolloutStateRunning {
+		return errors.New("rollout is " + rollout.state.String())
+	}
+	rollout.state = rolloutStatePaused
+	rollout.pauseReason = reason
+	return nil
+}
+
+func (herd *Herd) resumeRollout() error {
+	rollout := herd.getRollout()
+	if rollout == nil {
+		return errors.New("no rollout")
+	}
+	rollout.Lock()
+	defer rollout.Unlock()
+	if rollout.state != rolloutStatePaused {
+		return errors.New("rollout is " + rollout.state.String())
+	}
+	rollout.state = rolloutStateRunning
+	rollout.pauseReason = ""
+	return nil
+}
+
+func (herd *Herd) getRolloutStatus() *dominator.RolloutStatus {
+	rollout := herd.getRollout()
+	if rollout == nil {
+		return nil
+	}
+	rollout.Lock()
+	defer rollout.Unlock()
+	return &dominator.RolloutStatus{
+		ImageName:       rollout.imageName,
+		WavePercentages: rollout.wavePercentages,
+		MaxFailures:     rollout.maxFailures,
+		CurrentWave:     rollout.currentWave,
+		State:           rollout.state.String(),
+		PauseReason:     rollout.pauseReason,
+		StartedBy:       rollout.startedBy,
+		StartTime:       rollout.startTime,
+		NumSubs:         rollout.numSubs,
+		NumAdmitted:     uint(len(rollout.admitted)),
+		NumSynced:       rollout.numSynced,
+		NumFailed:       rollout.numFailed,
+	}
+}
+
+// checkRollout is called at the end of each scan cycle to admit more subs
+// into the rollout and to check the health of the current wave.
+func (herd *Herd) checkRollout() {
+	herd.RLock()
+	defer herd.RUnlock()
+	if herd.rollout == nil {
+		return
+	}
+	if message := herd.rollout.update(herd.subsByIndex); message != "" {
+		herd.logger.Println(message)
+	}
+}
+
+// holdForRollout returns true if the sub must not be updated because it is
+// waiting for a later wave of a rollout or the rollout was aborted before the
+// sub was admitted. Completed rollouts do not hold any subs.
+func (herd *Herd) holdForRollout(sub *Sub) bool {
+	rollout := herd.getRollout()
+	if rollout == nil {
+		return false
+	}
+	rollout.Lock()
+	defer rollout.Unlock()
+	if rollout.state == rolloutStateCompleted {
+		return false
+	}
+	if sub.requiredImageName != rollout.imageName {
+		return false
+	}
+	_, ok := rollout.admitted[sub]
+	return !ok
+}
+
+// removeSub will forget a sub which was removed from the MDB.
+func (rollout *rolloutType) removeSub(sub *Sub) {
+	rollout.Lock()
+	defer rollout.Unlock()
+	delete(rollout.admitted, sub)
+}
+
+// update must be called with the herd lock held. It returns a message
+// describing a change of state or wave, or an empty string if there was no
+// change.
+func (rollout *rolloutType) update(subs []*Sub) string {
+	rollout.Lock()
+	defer rollout.Unlock()
+	previousState := rollout.state
+	previousWave := rollout.currentWave
+	rollout.updateMembers(subs)
+	if rollout.state != previousState {
+		return fmt.Sprintf("Rollout of: %s is now %s %s",
+			rollout.imageName, rollout.state, rollout.pauseReason)
+	}
+	if rollout.currentWave != previousWave {
+		return fmt.Sprintf("Rollout of: %s advanced to wave: %d (%d%%)",
+			rollout.imageName, rollout.currentWave,
+			rollout.wavePercentages[rollout.currentWave])
+	}
+	return ""
+}
+
+// updateMembers must be called with the rollout lock held.
+func (rollout *rolloutType) updateMembers(subs []*Sub) {
+	members := make([]*Sub, 0)
+	for _, sub := range subs {
+		if sub.requiredImageName == rollout.imageName {
+			members = append(members, sub)
+		}
+	}
+	for sub := range rollout.admitted {
+		if sub.requiredImageName != rollout.imageName {
+			delete(rollout.admitted, sub)
+		}
+	}
+	rollout.numSubs = uint(len(members))
+	rollout.countAdmitted()
+	if rollout.state != rolloutStateRunning {
+		return
+	}
+	if rollout.numFailed > rollout.maxFailures {
+		rollout.state = rolloutStatePaused
+		rollout.pauseReason = fmt.Sprintf("%d subs failed to update",
+			rollout.numFailed)
+		return
+	}
+	if rollout.numSynced >= uint(len(rollout.admitted)) &&
+		uint(len(rollout.admitted)) >= rollout.waveSize() &&
+		len(rollout.admitted) > 0 {
+		if rollout.currentWave+1 >= uint(len(rollout.wavePercentages)) {
+			rollout.state = rolloutStateCompleted
+			return
+		}
+		rollout.currentWave++
+	}
+	waveSize := rollout.waveSize()
+	for _, sub := range members {
+		if uint(len(rollout.admitted)) >= waveSize {
+			break
+		}
+		rollout.admitted[sub] = struct{}{}
+	}
+}
+
+func (rollout *rolloutType) countAdmitted() {
+	rollout.numSynced = 0
+	rollout.numFailed = 0
+	for sub := range rollout.admitted {
+		if sub.lastSuccessfulImageName == rollout.imageName {
+			if sub.lastUpdateHadTriggerFailures {
+				rollout.numFailed++
+			} else if sub.publishedStatus.isSynced() {
+				rollout.numSynced++
+			}
+		} else if sub.publishedStatus == statusFailedToUpdate {
+			rollout.numFailed++
+		}
+	}
+}
+
+func (rollout *rolloutType) waveSize() uint {
+	percentage := rollout.wavePercentages[rollout.currentWave]
+	size := (rollout.numSubs*percentage + 99) / 100
+	if size < 1 && rollout.numSubs > 0 {
+		size = 1
+	}
+	return size
+}
+
+func (herd *Herd) writeRolloutStatus(writer io.Writer) {
+	status := herd.getRolloutStatus()
+	if status == nil {
+		return
+	}
+	fmt.Fprintf(writer,
+		"Rollout of: <a href=\"http://%s/showImage?%s\">%s</a> ",
+		herd.imageManager, status.ImageName, status.ImageName)
+	if status.State == "paused" {
+		fmt.Fprintf(writer, "<font color=\"red\">%s: %s</font>",
+			status.State, status.PauseReason)
+	} else {
+		fmt.Fprint(writer, status.State)
+	}
+	fmt.Fprintf(writer,
+		", wave: %d (%d%%), admitted: %d/%d, synced: %d, failed: %d<br>\n",
+		status.CurrentWave, status.WavePercentages[status.CurrentWave],
+		status.NumAdmitted, status.NumSubs, status.NumSynced, status.NumFailed)
+}
@@ -0,0 +1,148 @@
+package herd
+
+import (
+	"testing"
+
+	"github.com/Symantec/Dominator/lib/log/testlogger"
+)
+
+func makeRolloutSubs(num int, imageName string) []*Sub {
+	subs := make([]*Sub, 0, num)
+	for index := 0; index < num; index++ {
+		subs = append(subs, &Sub{requiredImageName: imageName})
+	}
+	return subs
+}
+
+func syncSubs(rollout *rolloutType) {
+	for sub := range rollout.admitted {
+		sub.lastSuccessfulImageName = sub.requiredImageName
+		sub.publishedStatus = statusSynced
+	}
+}
+
+func TestRolloutWaves(t *testing.T) {
+	subs := makeRolloutSubs(10, "image")
+	rollout := &rolloutType{
+		imageName:       "image",
+		wavePercentages: []uint{10, 50, 100},
+		admitted:        make(map[*Sub]struct{}),
+	}
+	var tests = []struct {
+		wave     uint
+		admitted int
+		state    rolloutState
+	}{
+		{0, 1, rolloutStateRunning},
+		{1, 5, rolloutStateRunning},
+		{2, 10, rolloutStateRunning},
+		{2, 10, rolloutStateCompleted},
+	}
+	for index, test := range tests {
+		if index > 0 {
+			syncSubs(rollout)
+		}
+		if message := rollout.update(subs); index > 0 && message == "" {
+			t.Errorf("step %d: no change reported", index)
+		}
+		if rollout.currentWave != test.wave {
+			t.Errorf("step %d: wave: %d != %d",
+				index, rollout.currentWave, test.wave)
+		}
+		if len(rollout.admitted) != test.admitted {
+			t.Errorf("step %d: admitted: %d != %d",
+				index, len(rollout.admitted), test.admitted)
+		}
+		if rollout.state != test.state {
+			t.Errorf("step %d: state: %s != %s",
+				index, rollout.state, test.state)
+		}
+	}
+}
+
+func TestRolloutPausesOnFailures(t *testing.T) {
+	subs := makeRolloutSubs(4, "image")
+	rollout := &rolloutType{
+		imageName:       "image",
+		wavePercentages: []uint{50, 100},
+		admitted:        make(map[*Sub]struct{}),
+	}
+	rollout.update(subs)
+	for sub := range rollout.admitted {
+		sub.publishedStatus = statusFailedToUpdate
+	}
+	if message := rollout.update(subs); message == "" {
+		t.Error("no change reported")
+	}
+	if rollout.state != rolloutStatePaused {
+		t.Errorf("state: %s != %s", rollout.state, rolloutStatePaused)
+	}
+	if len(rollout.admitted) != 2 {
+		t.Errorf("admitted: %d != 2", len(rollout.admitted))
+	}
+}
+
+func TestAbortRolloutHoldsSubs(t *testing.T) {
+	subs := makeRolloutSubs(4, "image")
+	herd := &Herd{logger: testlogger.New(t), subsByIndex: subs}
+	rollout := &rolloutType{
+		imageName:       "image",
+		wavePercentages: []uint{25, 100},
+		admitted:        make(map[*Sub]struct{}),
+	}
+	herd.rollout = rollout
+	herd.checkRollout()
+	numHeld := 0
+	for _, sub := range subs {
+		if herd.holdForRollout(sub) {
+			numHeld++
+		}
+	}
+	if numHeld != 3 {
+		t.Errorf("held before abort: %d != 3", numHeld)
+	}
+	if err := herd.abortRollout(); err != nil {
+		t.Fatal(err)
+	}
+	if err := herd.abortRollout(); err == nil {
+		t.Error("second abort did not fail")
+	}
+	herd.checkRollout()
+	numHeld = 0
+	for _, sub := range subs {
+		if herd.holdForRollout(sub) {
+			numHeld++
+		}
+	}
+	if numHeld != 3 {
+		t.Errorf("held after abort: %d != 3", numHeld)
+	}
+	if len(rollout.admitted) != 1 {
+		t.Errorf("admitted after abort: %d != 1", len(rollout.admitted))
+	}
+	for _, sub := range subs {
+		sub.requiredImageName = "previous"
+	}
+	herd.checkRollout()
+	for index, sub := range subs {
+		if herd.holdForRollout(sub) {
+			t.Errorf("sub %d held after changing image", index)
+		}
+	}
+}
+
+func TestRolloutForgetsDeletedSubs(t *testing.T) {
+	subs := makeRolloutSubs(4, "image")
+	rollout := &rolloutType{
+		imageName:       "image",
+		wavePercentages: []uint{50, 100},
+		admitted:        make(map[*Sub]struct{}),
+	}
+	rollout.update(subs)
+	for sub := range rollout.admitted {
+		rollout.removeSub(sub)
+	}
+	if len(rollout.admitted) != 0 {
+		t.Errorf("admitted after removal: %d != 0", len(rollout.admitted))
+	}
+}
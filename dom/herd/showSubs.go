@@ -64,6 +64,7 @@ func (herd *Herd) showSubs(writer io.Writer, subType string,
 	fmt.Fprintln(writer, "    <th>Name</th>")
 	fmt.Fprintln(writer, "    <th>Required Image</th>")
 	fmt.Fprintln(writer, "    <th>Planned Image</th>")
+	fmt.Fprintln(writer, "    <th>Override</th>")
 	fmt.Fprintln(writer, "    <th>Busy</th>")
 	fmt.Fprintln(writer, "    <th>Status</th>")
 	fmt.Fprintln(writer, "    <th>Uptime</th>")
@@ -94,6 +95,7 @@ func showSub(writer io.Writer, sub *Sub) {
 	fmt.Fprintf(writer, "    <td><a href=\"%s\">%s</a></td>\n", subURL, sub)
 	sub.herd.showImage(writer, sub.mdb.RequiredImage, true)
 	sub.herd.showImage(writer, sub.mdb.PlannedImage, false)
+	sub.showHostImageOverride(writer)
 	sub.showBusy(writer)
 	fmt.Fprintf(writer, "    <td><a href=\"showSub?%s\">%s</a></td>\n",
 		sub.mdb.Hostname, sub.publishedStatus.html())
@@ -163,10 +165,24 @@ func (herd *Herd) showSubHandler(w io.Writer, req *http.Request) {
 	sub.herd.showImage(w, sub.mdb.RequiredImage, true)
 	newRow(w, "Planned Image", false)
 	sub.herd.showImage(w, sub.mdb.PlannedImage, false)
+	if _, ok := sub.getHostImageOverride(); ok {
+		newRow(w, "Override", false)
+		sub.showHostImageOverride(w)
+	}
 	newRow(w, "Busy time", false)
 	sub.showBusy(w)
 	newRow(w, "Status", false)
 	fmt.Fprintf(w, "    <td>%s</td>\n", sub.publishedStatus.html())
+	if spec := sub.getMaintenanceWindowSpec(); spec != "" {
+		newRow(w, "Maintenance windows", false)
+		if sub.maintenanceWindowIsOpen() {
+			fmt.Fprintf(w, "    <td>%s (open)</td>\n", spec)
+		} else {
+			fmt.Fprintf(w, "    <td>%s (closed)</td>\n", spec)
+		}
+	}
+	newRow(w, "Drift", false)
+	sub.showDrift(w)
 	newRow(w, "Uptime", false)
 	showSince(w, sub.pollTime, sub.startTime)
 	newRow(w, "Last scan duration", false)
@@ -0,0 +1,310 @@
+package herd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"flag"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/filesystem"
+	"github.com/Symantec/Dominator/lib/fsutil"
+	"github.com/Symantec/Dominator/lib/objectcache"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+const (
+	cachedFileSystemSuffix = ".fs.gz"
+	stateDirPerms          = 0700
+	stateFilePerms         = 0600
+	subStatesFile          = "subs.gob"
+)
+
+var (
+	stateCheckpointInterval = flag.Duration("stateCheckpointInterval",
+		time.Minute*5, "Interval between checkpoints of the herd state")
+)
+
+// subState is the state of a sub which is saved across restarts.
+type subState struct {
+	Hostname                     string
+	GenerationCount              uint64
+	StartTime                    time.Time
+	PollTime                     time.Time
+	Status                       string
+	LastSuccessfulImageName      string
+	LastUpdateHadTriggerFailures bool
+	LastUpdateTime               time.Time
+	LastSyncTime                 time.Time
+	ScanCountAtLastUpdateEnd     uint64
+	FallbackImageName            string
+	PendingAuditRecord           *dominator.AuditRecord
+}
+
+type cachedFileSystemHeader struct {
+	GenerationCount uint64
+	ObjectCache     objectcache.ObjectCache
+}
+
+func (herd *Herd) openStateDirectory(dirname string) error {
+	err := os.MkdirAll(path.Join(dirname, "subs"), stateDirPerms)
+	if err != nil {
+		return err
+	}
+	herd.stateDir = dirname
+	if err := herd.loadSubStates(); err != nil {
+		return err
+	}
+	if err := herd.loadQuarantinedImages(); err != nil {
+		return err
+	}
+	if err := herd.loadHostImageOverrides(); err != nil {
+		return err
+	}
+	go herd.checkpointLoop()
+	return nil
+}
+
+func (herd *Herd) loadSubStates() error {
+	file, err := os.Open(path.Join(herd.stateDir, subStatesFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+	var states []subState
+	decoder := gob.NewDecoder(bufio.NewReader(file))
+	if err := decoder.Decode(&states); err != nil {
+		return err
+	}
+	herd.savedSubStates = make(map[string]subState, len(states))
+	for _, state := range states {
+		herd.savedSubStates[state.Hostname] = state
+	}
+	herd.logger.Printf("Loaded state for %d subs\n", len(states))
+	return nil
+}
+
+func (herd *Herd) checkpointLoop() {
+	for range time.Tick(*stateCheckpointInterval) {
+		if err := herd.checkpoint(); err != nil {
+			herd.logger.Printf("Error checkpointing herd state: %s\n", err)
+		}
+	}
+}
+
+func (herd *Herd) checkpoint() error {
+	herd.RLock()
+	states := make([]subState, 0, len(herd.subsByIndex))
+	subs := make([]*Sub, 0, len(herd.subsByIndex))
+	for _, sub := range herd.subsByIndex {
+		states = append(states, sub.getState())
+		subs = append(subs, sub)
+	}
+	herd.RUnlock()
+	for _, sub := range subs {
+		sub.saveFileSystem()
+	}
+	if err := herd.removeStaleFileSystems(states); err != nil {
+		return err
+	}
+	filename := path.Join(herd.stateDir, subStatesFile)
+	file, err := fsutil.CreateRenamingWriter(filename, stateFilePerms)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+	if err := gob.NewEncoder(writer).Encode(states); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+func (herd *Herd) removeStaleFileSystems(states []subState) error {
+	hostnames := make(map[string]struct{}, len(states))
+	for _, state := range states {
+		hostnames[state.Hostname] = struct{}{}
+	}
+	dirname := path.Join(herd.stateDir, "subs")
+	names, err := fsutil.ReadDirnames(dirname, false)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if !strings.HasSuffix(name, cachedFileSystemSuffix) {
+			continue
+		}
+		hostname := strings.TrimSuffix(name, cachedFileSystemSuffix)
+		if _, ok := hostnames[hostname]; !ok {
+			os.Remove(path.Join(dirname, name))
+		}
+	}
+	return nil
+}
+
+func (sub *Sub) getState() subState {
+	return subState{
+		Hostname:                     sub.mdb.Hostname,
+		GenerationCount:              sub.generationCount,
+		StartTime:                    sub.startTime,
+		PollTime:                     sub.pollTime,
+		Status:                       sub.publishedStatus.String(),
+		LastSuccessfulImageName:      sub.lastSuccessfulImageName,
+		LastUpdateHadTriggerFailures: sub.lastUpdateHadTriggerFailures,
+		LastUpdateTime:               sub.lastUpdateTime,
+		LastSyncTime:                 sub.lastSyncTime,
+		ScanCountAtLastUpdateEnd:     sub.scanCountAtLastUpdateEnd,
+		FallbackImageName:            sub.fallbackImageName,
+		PendingAuditRecord:           sub.pendingAuditRecord,
+	}
+}
+
+// restoreState will restore the saved state (if any) for a new sub. This must
+// be called with the herd lock held.
+func (sub *Sub) restoreState() {
+	state, ok := sub.herd.savedSubStates[sub.mdb.Hostname]
+	if !ok {
+		return
+	}
+	delete(sub.herd.savedSubStates, sub.mdb.Hostname)
+	sub.generationCount = state.GenerationCount
+	sub.startTime = state.StartTime
+	sub.pollTime = state.PollTime
+	sub.status = statusUnknown
+	for status := subStatus(statusUnknown); status <= statusSynced; status++ {
+		if status.String() == state.Status {
+			sub.status = status
+			break
+		}
+	}
+	sub.publishedStatus = sub.status
+	sub.lastSuccessfulImageName = state.LastSuccessfulImageName
+	sub.lastUpdateHadTriggerFailures = state.LastUpdateHadTriggerFailures
+	sub.lastUpdateTime = state.LastUpdateTime
+	sub.lastSyncTime = state.LastSyncTime
+	sub.scanCountAtLastUpdateEnd = state.ScanCountAtLastUpdateEnd
+	sub.fallbackImageName = state.FallbackImageName
+	sub.pendingAuditRecord = state.PendingAuditRecord
+	sub.haveRestoredState = true
+}
+
+func (sub *Sub) cachedFileSystemFilename() string {
+	return path.Join(sub.herd.stateDir, "subs",
+		sub.mdb.Hostname+cachedFileSystemSuffix)
+}
+
+// recordFileSystem will encode and compress the file-system and object cache
+// from a full poll, to be saved at the next checkpoint. Only the latest is
+// saved. Only the compressed form is kept, so that the file-system is not held
+// in memory until the checkpoint.
+func (sub *Sub) recordFileSystem() {
+	if sub.herd.stateDir == "" {
+		return
+	}
+	buffer := &bytes.Buffer{}
+	if err := sub.encodeFileSystem(buffer); err != nil {
+		sub.herd.logger.Printf("Error encoding file-system for: %s: %s\n",
+			sub, err)
+		return
+	}
+	sub.unsavedFileSystemMutex.Lock()
+	sub.unsavedFileSystem = buffer.Bytes()
+	sub.unsavedFileSystemMutex.Unlock()
+}
+
+func (sub *Sub) encodeFileSystem(writer io.Writer) error {
+	gzipWriter := gzip.NewWriter(writer)
+	header := cachedFileSystemHeader{
+		GenerationCount: sub.generationCount,
+		ObjectCache:     sub.objectCache,
+	}
+	if err := gob.NewEncoder(gzipWriter).Encode(header); err != nil {
+		return err
+	}
+	if err := sub.fileSystem.Encode(gzipWriter); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+// saveFileSystem will save the file-system and object cache recorded since
+// the last checkpoint, if any.
+func (sub *Sub) saveFileSystem() {
+	sub.unsavedFileSystemMutex.Lock()
+	data := sub.unsavedFileSystem
+	sub.unsavedFileSystem = nil
+	sub.unsavedFileSystemMutex.Unlock()
+	if data == nil {
+		return
+	}
+	err := writeCachedFileSystem(sub.cachedFileSystemFilename(), data)
+	if err != nil {
+		sub.herd.logger.Printf("Error saving file-system for: %s: %s\n",
+			sub, err)
+	}
+}
+
+func writeCachedFileSystem(filename string, data []byte) error {
+	file, err := fsutil.CreateRenamingWriter(filename, stateFilePerms)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(data)
+	return err
+}
+
+// loadFileSystem will load the saved file-system and object cache if they
+// match the specified generation. It returns true on success.
+func (sub *Sub) loadFileSystem(generationCount uint64) bool {
+	if sub.herd.stateDir == "" {
+		return false
+	}
+	file, err := os.Open(sub.cachedFileSystemFilename())
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return false
+	}
+	defer gzipReader.Close()
+	reader := bufio.NewReader(gzipReader)
+	var header cachedFileSystemHeader
+	if err := gob.NewDecoder(reader).Decode(&header); err != nil {
+		return false
+	}
+	if header.GenerationCount != generationCount {
+		return false
+	}
+	fs, err := readFileSystem(reader)
+	if err != nil {
+		sub.herd.logger.Printf("Error loading file-system for: %s: %s\n",
+			sub, err)
+		return false
+	}
+	sub.fileSystem = fs
+	sub.objectCache = header.ObjectCache
+	return true
+}
+
+func readFileSystem(reader io.Reader) (*filesystem.FileSystem, error) {
+	fs, err := filesystem.Decode(reader)
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.RebuildInodePointers(); err != nil {
+		return nil, err
+	}
+	fs.BuildEntryMap()
+	return fs, nil
+}
@@ -0,0 +1,67 @@
+package herd
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"syscall"
+	"testing"
+
+	"github.com/Symantec/Dominator/lib/filesystem"
+	"github.com/Symantec/Dominator/lib/hash"
+	"github.com/Symantec/Dominator/lib/log/testlogger"
+	"github.com/Symantec/Dominator/lib/mdb"
+	"github.com/Symantec/Dominator/lib/objectcache"
+)
+
+func TestSaveFileSystem(t *testing.T) {
+	stateDir, err := ioutil.TempDir("", "state_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(stateDir)
+	if err := os.Mkdir(path.Join(stateDir, "subs"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	herd := &Herd{logger: testlogger.New(t), stateDir: stateDir}
+	fs := &filesystem.FileSystem{
+		InodeTable: filesystem.InodeTable{
+			1: &filesystem.RegularInode{Mode: syscall.S_IFREG | 0644,
+				Size: 1, Hash: hash.Hash{1}},
+		},
+		DirectoryInode: filesystem.DirectoryInode{
+			Mode: syscall.S_IFDIR | 0755,
+			EntryList: []*filesystem.DirectoryEntry{
+				{Name: "file", InodeNumber: 1},
+			},
+		},
+	}
+	if err := fs.RebuildInodePointers(); err != nil {
+		t.Fatal(err)
+	}
+	sub := &Sub{
+		herd:            herd,
+		mdb:             mdb.Machine{Hostname: "sub"},
+		generationCount: 2,
+		fileSystem:      fs,
+		objectCache:     objectcache.ObjectCache{hash.Hash{1}},
+	}
+	sub.recordFileSystem()
+	sub.saveFileSystem()
+	if sub.unsavedFileSystem != nil {
+		t.Error("file-system still held after save")
+	}
+	loadedSub := &Sub{herd: herd, mdb: sub.mdb}
+	if loadedSub.loadFileSystem(1) {
+		t.Error("file-system loaded for wrong generation")
+	}
+	if !loadedSub.loadFileSystem(2) {
+		t.Fatal("file-system not loaded")
+	}
+	if !filesystem.CompareFileSystems(loadedSub.fileSystem, fs, nil) {
+		t.Error("loaded file-system differs")
+	}
+	if len(loadedSub.objectCache) != 1 {
+		t.Errorf("object cache: %v", loadedSub.objectCache)
+	}
+}
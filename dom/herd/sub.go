@@ -18,6 +18,7 @@ import (
 	"github.com/Symantec/Dominator/lib/objectcache"
 	"github.com/Symantec/Dominator/lib/resourcepool"
 	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/lib/triggers"
 	subproto "github.com/Symantec/Dominator/proto/sub"
 	"github.com/Symantec/Dominator/sub/client"
 )
@@ -31,6 +32,8 @@ var (
 		"If true, prefer to show IP address from MDB if available")
 	useIP = flag.Bool("useIP", true,
 		"If true, prefer to use IP address from MDB if available")
+	deltaFetchMinimumBytes = flag.Uint64("deltaFetchMinimumBytes", 0,
+		"Minimum size of changed files which subs fetch as block deltas (0: disabled)")
 
 	subPortNumber = fmt.Sprintf(":%d", constants.SubPortNumber)
 	zeroHash      hash.Hash
@@ -100,6 +103,9 @@ func (sub *Sub) connectAndPoll() {
 	if sub.processFileUpdates() {
 		sub.generationCount = 0 // Force a full poll.
 	}
+	if sub.consumeFullPollRequest() {
+		sub.generationCount = 0 // Force a full poll.
+	}
 	sub.deletingFlagMutex.Lock()
 	if sub.deleting {
 		sub.deletingFlagMutex.Unlock()
@@ -111,11 +117,11 @@ func (sub *Sub) connectAndPoll() {
 	sub.deletingFlagMutex.Unlock()
 	previousStatus := sub.status
 	timer := time.AfterFunc(time.Second, func() {
-		sub.publishedStatus = sub.status
+		sub.publishStatus()
 	})
 	defer func() {
 		timer.Stop()
-		sub.publishedStatus = sub.status
+		sub.publishStatus()
 	}()
 	sub.lastConnectionStartTime = time.Now()
 	srpcClient, err := sub.clientResource.GetHTTPWithDialer(sub.cancelChannel,
@@ -194,14 +200,27 @@ func (sub *Sub) loadConfiguration() {
 	if newRequiredImageName == "" {
 		newRequiredImageName = sub.herd.defaultImageName
 	}
+	newPlannedImageName := sub.mdb.PlannedImage
+	override, _ := sub.getHostImageOverride()
+	if override.ImageName != "" {
+		newRequiredImageName = override.ImageName
+		newPlannedImageName = ""
+	} else if sub.herd.isImageQuarantined(newRequiredImageName) {
+		// Roll back to the last good image, if known.
+		fallback := sub.getFallbackImageName(newRequiredImageName)
+		if fallback != "" {
+			newRequiredImageName = fallback
+		}
+	}
 	if newRequiredImageName != sub.requiredImageName {
 		sub.computedInodes = nil
+		sub.requiredImageTime = time.Now()
 	}
 	sub.herd.cpuSharer.ReleaseCpu()
 	defer sub.herd.cpuSharer.GrabCpu()
 	sub.requiredImageName = newRequiredImageName
 	sub.requiredImage = sub.herd.imageManager.GetNoError(sub.requiredImageName)
-	sub.plannedImageName = sub.mdb.PlannedImage
+	sub.plannedImageName = newPlannedImageName
 	sub.plannedImage = sub.herd.imageManager.GetNoError(sub.plannedImageName)
 }
 
@@ -265,14 +284,14 @@ func (sub *Sub) processFileUpdates() bool {
 
 func (sub *Sub) poll(srpcClient *srpc.Client, previousStatus subStatus) {
 	// If the planned image has just become available, force a full poll.
-	if previousStatus == statusSynced &&
+	if previousStatus.isSynced() &&
 		!sub.havePlannedImage &&
 		sub.plannedImage != nil {
 		sub.havePlannedImage = true
 		sub.generationCount = 0 // Force a full poll.
 	}
 	// If the computed files have changed since the last sync, force a full poll
-	if previousStatus == statusSynced &&
+	if previousStatus.isSynced() &&
 		sub.computedFilesChangeTime.After(sub.lastSyncTime) {
 		sub.generationCount = 0 // Force a full poll.
 	}
@@ -282,11 +301,35 @@ func (sub *Sub) poll(srpcClient *srpc.Client, previousStatus subStatus) {
 		sub.herd.updatesDisabledReason == "" && !sub.mdb.DisableUpdates {
 		sub.generationCount = 0 // Force a full poll.
 	}
+	// If the sub was waiting for a rollout and has now been admitted, force a
+	// full poll.
+	if previousStatus == statusWaitingForRollout &&
+		!sub.herd.holdForRollout(sub) {
+		sub.generationCount = 0 // Force a full poll.
+	}
+	// If the sub was waiting for the maintenance window and it is now open,
+	// force a full poll.
+	if previousStatus == statusWaitingForMaintenanceWindow &&
+		sub.maintenanceWindowIsOpen() {
+		sub.generationCount = 0 // Force a full poll.
+	}
+	// If the sub was waiting for an update slot and one may be available now,
+	// force a full poll.
+	if previousStatus == statusWaitingForUpdateSlot && sub.haveUpdateSlot() {
+		sub.generationCount = 0 // Force a full poll.
+	}
 	// If the last update was disabled due to a safety check and there is a
 	// pending SafetyClear, force a full poll to re-compute the update.
 	if previousStatus == statusUnsafeUpdate && sub.pendingSafetyClear {
 		sub.generationCount = 0 // Force a full poll.
 	}
+	// If the sub was synced before a restart but the required image has since
+	// changed (such as with an MDB or default image change while stopped),
+	// force a full poll.
+	if sub.haveRestoredState && previousStatus.isSynced() &&
+		sub.lastSuccessfulImageName != sub.requiredImageName {
+		sub.generationCount = 0 // Force a full poll.
+	}
 	var request subproto.PollRequest
 	request.HaveGeneration = sub.generationCount
 	var reply subproto.PollResponse
@@ -313,7 +356,25 @@ func (sub *Sub) poll(srpcClient *srpc.Client, previousStatus subStatus) {
 		return
 	}
 	sub.lastPollSucceededTime = time.Now()
+	if reply.LastSuccessfulImageName != sub.lastSuccessfulImageName &&
+		sub.lastSuccessfulImageName != "" &&
+		!sub.lastUpdateHadTriggerFailures {
+		sub.fallbackImageName = sub.lastSuccessfulImageName
+	}
 	sub.lastSuccessfulImageName = reply.LastSuccessfulImageName
+	sub.lastUpdateHadTriggerFailures = reply.LastUpdateHadTriggerFailures
+	if pathLocksChanged(sub.pathLocks, reply.PathLocks) {
+		// Expired and removed locks do not change the generation count, but
+		// locked paths may need to be updated: force a full poll.
+		sub.generationCount = 0
+	}
+	sub.pathLocks = reply.PathLocks
+	if !reply.UpdateInProgress {
+		sub.lastUpdateError = reply.LastUpdateError
+		sub.releaseUpdateSlot()
+		sub.finishAuditRecord(reply.LastUpdateError,
+			reply.LastUpdateHadTriggerFailures)
+	}
 	if reply.GenerationCount == 0 {
 		sub.reclaim()
 		sub.generationCount = 0
@@ -343,11 +404,32 @@ func (sub *Sub) poll(srpcClient *srpc.Client, previousStatus subStatus) {
 		fs.BuildEntryMap()
 		sub.fileSystem = fs
 		sub.objectCache = reply.ObjectCache
+		sub.herd.objectPeers.add(sub, reply.ObjectCache)
 		sub.generationCount = reply.GenerationCount
+		sub.recordFileSystem()
+		if haveImage {
+			sub.computeDrift()
+		}
 		sub.lastFullPollDuration =
 			sub.lastPollSucceededTime.Sub(sub.lastPollStartTime)
 		fullPollDistribution.Add(sub.lastFullPollDuration)
 	}
+	// After a restart, use the saved file-system if the sub has not changed
+	// and was not synced, otherwise force a full poll next cycle.
+	if sub.haveRestoredState {
+		sub.haveRestoredState = false
+		if sub.fileSystem == nil && !previousStatus.isSynced() {
+			if reply.GenerationCount > 0 &&
+				reply.GenerationCount == sub.generationCount &&
+				sub.loadFileSystem(reply.GenerationCount) {
+				if haveImage {
+					sub.computeDrift()
+				}
+			} else {
+				sub.generationCount = 0
+			}
+		}
+	}
 	sub.startTime = reply.StartTime
 	sub.pollTime = reply.PollTime
 	sub.updateConfiguration(srpcClient, reply)
@@ -443,7 +525,11 @@ func (sub *Sub) poll(srpcClient *srpc.Client, previousStatus subStatus) {
 		!sub.lastUpdateTime.IsZero() {
 		sub.lastSyncTime = time.Now()
 	}
-	sub.status = statusSynced
+	if len(sub.pathLocks) > 0 {
+		sub.status = statusLocallyPinned
+	} else {
+		sub.status = statusSynced
+	}
 	sub.cleanup(srpcClient)
 	sub.reclaim()
 }
@@ -534,10 +620,17 @@ func (sub *Sub) fetchMissingObjects(srpcClient *srpc.Client, image *image.Image,
 		if !sub.checkForEnoughSpace(freeSpace, objectsToFetch) {
 			return false, statusNotEnoughFreeSpace
 		}
-		logger.Printf("Calling %s:Subd.Fetch() for: %d objects\n",
-			sub, len(objectsToFetch))
-		err := client.Fetch(srpcClient, sub.herd.imageManager.String(),
-			objectcache.ObjectMapToCache(objectsToFetch))
+		request := subproto.FetchRequest{
+			ServerAddress: sub.herd.imageManager.String(),
+			Hashes:        objectcache.ObjectMapToCache(objectsToFetch),
+		}
+		request.Peers = sub.herd.objectPeers.getPeers(sub, request.Hashes)
+		request.Deltas = lib.BuildFetchDeltas(subObj, image, objectsToFetch,
+			*deltaFetchMinimumBytes)
+		logger.Printf("Calling %s:Subd.Fetch() for: %d objects (%d peers)\n",
+			sub, len(objectsToFetch), len(request.Peers))
+		var reply subproto.FetchResponse
+		err := client.CallFetch(srpcClient, request, &reply)
 		if err != nil {
 			srpcClient.Close()
 			logger.Printf("Error calling %s:Subd.Fetch(): %s\n", sub, err)
@@ -574,6 +667,36 @@ func (sub *Sub) fetchMissingObjects(srpcClient *srpc.Client, image *image.Image,
 	return returnAvailable, returnStatus
 }
 
+// holdUpdate returns true and the reason if the update must not be sent to the
+// sub now. It does not check for a free update slot.
+func (sub *Sub) holdUpdate(fs *filesystem.FileSystem,
+	request subproto.UpdateRequest,
+	matchedTriggers []*triggers.Trigger) (bool, subStatus) {
+	override, _ := sub.getHostImageOverride()
+	if sub.mdb.DisableUpdates || sub.herd.updatesDisabledReason != "" ||
+		override.DisableUpdates {
+		return true, statusUpdatesDisabled
+	}
+	if override.ImageName == "" &&
+		sub.herd.isImageQuarantined(sub.requiredImageName) {
+		return true, statusImageQuarantined
+	}
+	if sub.herd.holdForRollout(sub) {
+		return true, statusWaitingForRollout
+	}
+	if !sub.pendingSafetyClear {
+		// Perform a cheap safety check: if over half the inodes will be deleted
+		// then mark the update as unsafe.
+		if checkForUnsafeChange(sub.requiredImage, fs, request) {
+			return true, statusUnsafeUpdate
+		}
+	}
+	if sub.holdForMaintenanceWindow(matchedTriggers) {
+		return true, statusWaitingForMaintenanceWindow
+	}
+	return false, statusUnknown
+}
+
 // Returns true if no update needs to be performed.
 func (sub *Sub) sendUpdate(srpcClient *srpc.Client) (bool, subStatus) {
 	logger := sub.herd.logger
@@ -584,21 +707,24 @@ func (sub *Sub) sendUpdate(srpcClient *srpc.Client) (bool, subStatus) {
 	} else if idle {
 		return true, statusSynced
 	}
-	if sub.mdb.DisableUpdates || sub.herd.updatesDisabledReason != "" {
-		return false, statusUpdatesDisabled
+	matchedTriggers := lib.MatchTriggers(request)
+	if held, status := sub.holdUpdate(sub.fileSystem, request,
+		matchedTriggers); held {
+		return false, status
 	}
-	if !sub.pendingSafetyClear {
-		// Perform a cheap safety check: if over half the inodes will be deleted
-		// then mark the update as unsafe.
-		if sub.checkForUnsafeChange(request) {
-			return false, statusUnsafeUpdate
-		}
+	if !sub.acquireUpdateSlot(matchedTriggers) {
+		return false, statusWaitingForUpdateSlot
 	}
 	sub.status = statusSendingUpdate
 	sub.lastUpdateTime = time.Now()
+	sub.lastUpdateImageName = sub.requiredImageName
+	sub.startAuditRecord(request, matchedTriggers)
 	logger.Printf("Calling %s:Subd.Update() for image: %s\n",
 		sub, sub.requiredImageName)
 	if err := client.CallUpdate(srpcClient, request, &reply); err != nil {
+		sub.releaseUpdateSlot()
+		sub.finishAuditRecord(err.Error(), false)
+		sub.lastUpdateError = err.Error()
 		srpcClient.Close()
 		logger.Printf("Error calling %s:Subd.Update(): %s\n", sub, err)
 		if err == srpc.ErrorAccessToMethodDenied {
@@ -611,15 +737,16 @@ func (sub *Sub) sendUpdate(srpcClient *srpc.Client) (bool, subStatus) {
 }
 
 // Returns true if the change is unsafe (very large number of deletions).
-func (sub *Sub) checkForUnsafeChange(request subproto.UpdateRequest) bool {
-	if sub.requiredImage.Filter == nil {
+func checkForUnsafeChange(requiredImage *image.Image,
+	fileSystem *filesystem.FileSystem, request subproto.UpdateRequest) bool {
+	if requiredImage.Filter == nil {
 		return false // Sparse image: no deletions.
 	}
-	if len(sub.requiredImage.FileSystem.InodeTable) <
-		len(sub.fileSystem.InodeTable)>>1 {
+	if len(requiredImage.FileSystem.InodeTable) <
+		len(fileSystem.InodeTable)>>1 {
 		return true
 	}
-	if len(request.PathsToDelete) > len(sub.fileSystem.InodeTable)>>1 {
+	if len(request.PathsToDelete) > len(fileSystem.InodeTable)>>1 {
 		return true
 	}
 	return false
@@ -662,7 +789,9 @@ func (sub *Sub) cleanup(srpcClient *srpc.Client) {
 	if err := client.Cleanup(srpcClient, hashes); err != nil {
 		srpcClient.Close()
 		logger.Printf("Error calling %s:Subd.Cleanup(): %s\n", sub, err)
+		return
 	}
+	sub.herd.objectPeers.removeObjects(sub, hashes)
 }
 
 func (sub *Sub) checkForEnoughSpace(freeSpace *uint64,
@@ -704,6 +833,24 @@ func (sub *Sub) checkCancel() bool {
 	}
 }
 
+// requestFullPoll will make the next poll of the sub a full poll. It may be
+// called while the sub is busy, since the request is consumed by the sub
+// goroutine.
+func (sub *Sub) requestFullPoll() {
+	sub.fullPollFlagMutex.Lock()
+	sub.fullPollRequested = true
+	sub.fullPollFlagMutex.Unlock()
+	sub.sendCancel()
+}
+
+func (sub *Sub) consumeFullPollRequest() bool {
+	sub.fullPollFlagMutex.Lock()
+	defer sub.fullPollFlagMutex.Unlock()
+	requested := sub.fullPollRequested
+	sub.fullPollRequested = false
+	return requested
+}
+
 func (sub *Sub) sendCancel() {
 	select {
 	case sub.cancelChannel <- struct{}{}:
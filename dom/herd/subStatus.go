@@ -62,8 +62,16 @@ func (status subStatus) string() string {
 		return "missing computed file"
 	case statusUpdatesDisabled:
 		return "updates disabled"
+	case statusImageQuarantined:
+		return "image quarantined"
 	case statusUnsafeUpdate:
 		return "unsafe update"
+	case statusWaitingForRollout:
+		return "waiting for rollout"
+	case statusWaitingForMaintenanceWindow:
+		return "waiting for maintenance window"
+	case statusWaitingForUpdateSlot:
+		return "waiting for update slot"
 	case statusUpdating:
 		return "updating"
 	case statusUpdateDenied:
@@ -72,6 +80,8 @@ func (status subStatus) string() string {
 		return "update failed"
 	case statusWaitingForNextFullPoll:
 		return "waiting for next full poll"
+	case statusLocallyPinned:
+		return "locally pinned"
 	case statusSynced:
 		return "synced"
 	default:
@@ -79,10 +89,22 @@ func (status subStatus) string() string {
 	}
 }
 
+// isSynced returns true if the sub needs no update. A locally pinned sub is
+// synced except for the paths which are locked on the sub.
+func (status subStatus) isSynced() bool {
+	return status == statusSynced || status == statusLocallyPinned
+}
+
 func (status subStatus) html() string {
 	switch status {
+	case statusImageQuarantined:
+		return `<font color="red">` + status.String() + "</font>"
 	case statusUnsafeUpdate:
 		return `<font color="red">` + status.String() + "</font>"
+	case statusWaitingForMaintenanceWindow:
+		return `<font color="grey">` + status.String() + "</font>"
+	case statusLocallyPinned:
+		return `<font color="blue">` + status.String() + "</font>"
 	default:
 		return status.String()
 	}
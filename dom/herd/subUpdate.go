@@ -20,7 +20,8 @@ func (sub *Sub) buildUpdateRequest(request *subproto.UpdateRequest) (
 		Hostname:       sub.mdb.Hostname,
 		FileSystem:     sub.fileSystem,
 		ComputedInodes: sub.computedInodes,
-		ObjectCache:    sub.objectCache}
+		ObjectCache:    sub.objectCache,
+		LockedPaths:    getLockedPathsFilter(sub.pathLocks, sub.herd.logger)}
 	if lib.BuildUpdateRequest(subObj, sub.requiredImage, request, false, false,
 		sub.herd.logger) {
 		return false, true
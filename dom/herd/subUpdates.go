@@ -0,0 +1,123 @@
+package herd
+
+import (
+	"sync"
+
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+type subUpdateNotifier struct {
+	channel chan dominator.SubUpdate
+	request dominator.GetSubUpdatesRequest
+}
+
+type subUpdateNotifiersType struct {
+	sync.Mutex
+	notifiers map[<-chan dominator.SubUpdate]*subUpdateNotifier
+}
+
+func newSubUpdateNotifiers() *subUpdateNotifiersType {
+	return &subUpdateNotifiersType{
+		notifiers: make(map[<-chan dominator.SubUpdate]*subUpdateNotifier),
+	}
+}
+
+func (notifier *subUpdateNotifier) wantSub(sub *Sub) bool {
+	request := notifier.request
+	if len(request.Hostnames) > 0 {
+		found := false
+		for _, hostname := range request.Hostnames {
+			if hostname == sub.mdb.Hostname {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if request.TagKey != "" {
+		if value, ok := sub.mdb.Tags[request.TagKey]; !ok ||
+			value != request.TagValue {
+			return false
+		}
+	}
+	return true
+}
+
+func (herd *Herd) closeSubUpdateChannel(channel <-chan dominator.SubUpdate) {
+	herd.subUpdateNotifiers.Lock()
+	defer herd.subUpdateNotifiers.Unlock()
+	delete(herd.subUpdateNotifiers.notifiers, channel)
+}
+
+func (herd *Herd) makeSubUpdateChannel(
+	request dominator.GetSubUpdatesRequest) <-chan dominator.SubUpdate {
+	notifier := &subUpdateNotifier{
+		channel: make(chan dominator.SubUpdate, 256),
+		request: request,
+	}
+	var update dominator.SubUpdate
+	herd.RLock()
+	defer herd.RUnlock()
+	for _, sub := range herd.subsByIndex {
+		if notifier.wantSub(sub) {
+			update.ChangedSubs = append(update.ChangedSubs, sub.getSubInfo())
+		}
+	}
+	herd.subUpdateNotifiers.Lock()
+	defer herd.subUpdateNotifiers.Unlock()
+	herd.subUpdateNotifiers.notifiers[notifier.channel] = notifier
+	notifier.channel <- update
+	return notifier.channel
+}
+
+// sendSubUpdate sends the update to each interested receiver. Receivers which
+// are not keeping up have their channel closed.
+func (herd *Herd) sendSubUpdate(sub *Sub, update dominator.SubUpdate) {
+	herd.subUpdateNotifiers.Lock()
+	defer herd.subUpdateNotifiers.Unlock()
+	for readChannel, notifier := range herd.subUpdateNotifiers.notifiers {
+		if !notifier.wantSub(sub) {
+			continue
+		}
+		select {
+		case notifier.channel <- update:
+		default:
+			close(notifier.channel)
+			delete(herd.subUpdateNotifiers.notifiers, readChannel)
+		}
+	}
+}
+
+func (herd *Herd) sendSubDeleted(sub *Sub) {
+	herd.sendSubUpdate(sub,
+		dominator.SubUpdate{DeletedSubs: []string{sub.mdb.Hostname}})
+}
+
+func (sub *Sub) getSubInfo() dominator.SubInfo {
+	imageName := sub.requiredImageName
+	if imageName == "" {
+		imageName = sub.herd.getConfiguredImageName(sub)
+	}
+	return dominator.SubInfo{
+		Hostname:                     sub.mdb.Hostname,
+		Status:                       sub.publishedStatus.String(),
+		RequiredImage:                imageName,
+		PlannedImage:                 sub.mdb.PlannedImage,
+		LastUpdateError:              sub.lastUpdateError,
+		LastSuccessfulImageName:      sub.lastSuccessfulImageName,
+		LastUpdateHadTriggerFailures: sub.lastUpdateHadTriggerFailures,
+	}
+}
+
+// publishStatus will make the current status visible and notify receivers of
+// sub updates if it has changed.
+func (sub *Sub) publishStatus() {
+	if sub.publishedStatus == sub.status {
+		return
+	}
+	sub.publishedStatus = sub.status
+	sub.herd.sendSubUpdate(sub,
+		dominator.SubUpdate{ChangedSubs: []dominator.SubInfo{sub.getSubInfo()}})
+}
@@ -0,0 +1,131 @@
+package herd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Symantec/Dominator/lib/mdb"
+	"github.com/Symantec/Dominator/lib/tags"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func makeSubUpdatesHerd() *Herd {
+	herd := &Herd{
+		subsByName:         make(map[string]*Sub),
+		subUpdateNotifiers: newSubUpdateNotifiers(),
+	}
+	for _, machine := range []mdb.Machine{
+		{Hostname: "a", Tags: tags.Tags{"role": "web"}},
+		{Hostname: "b", Tags: tags.Tags{"role": "db"}},
+		{Hostname: "c", Tags: tags.Tags{"role": "web"}},
+	} {
+		sub := &Sub{herd: herd, mdb: machine, requiredImageName: "image"}
+		herd.subsByName[machine.Hostname] = sub
+		herd.subsByIndex = append(herd.subsByIndex, sub)
+	}
+	return herd
+}
+
+func getHostnames(update dominator.SubUpdate) []string {
+	hostnames := make([]string, 0, len(update.ChangedSubs))
+	for _, subInfo := range update.ChangedSubs {
+		hostnames = append(hostnames, subInfo.Hostname)
+	}
+	return hostnames
+}
+
+func TestMakeSubUpdateChannel(t *testing.T) {
+	herd := makeSubUpdatesHerd()
+	var tests = []struct {
+		name     string
+		request  dominator.GetSubUpdatesRequest
+		expected []string
+	}{
+		{"all", dominator.GetSubUpdatesRequest{}, []string{"a", "b", "c"}},
+		{"hostnames",
+			dominator.GetSubUpdatesRequest{Hostnames: []string{"b", "d"}},
+			[]string{"b"}},
+		{"tag",
+			dominator.GetSubUpdatesRequest{TagKey: "role", TagValue: "web"},
+			[]string{"a", "c"}},
+		{"hostnames and tag",
+			dominator.GetSubUpdatesRequest{Hostnames: []string{"a", "b"},
+				TagKey: "role", TagValue: "web"},
+			[]string{"a"}},
+	}
+	for _, test := range tests {
+		channel := herd.makeSubUpdateChannel(test.request)
+		hostnames := getHostnames(<-channel)
+		if !reflect.DeepEqual(hostnames, test.expected) {
+			t.Errorf("%s: subs: %v, expected: %v",
+				test.name, hostnames, test.expected)
+		}
+		herd.closeSubUpdateChannel(channel)
+	}
+	if len(herd.subUpdateNotifiers.notifiers) != 0 {
+		t.Errorf("notifiers not removed: %d",
+			len(herd.subUpdateNotifiers.notifiers))
+	}
+}
+
+func TestSendSubUpdate(t *testing.T) {
+	herd := makeSubUpdatesHerd()
+	channel := herd.makeSubUpdateChannel(
+		dominator.GetSubUpdatesRequest{Hostnames: []string{"a"}})
+	<-channel
+	subA := herd.subsByName["a"]
+	herd.sendSubUpdate(herd.subsByName["b"], dominator.SubUpdate{
+		ChangedSubs: []dominator.SubInfo{herd.subsByName["b"].getSubInfo()},
+	})
+	subA.status = statusSynced
+	subA.publishStatus()
+	herd.sendSubDeleted(subA)
+	update := <-channel
+	if len(update.ChangedSubs) != 1 ||
+		update.ChangedSubs[0].Hostname != "a" ||
+		update.ChangedSubs[0].Status != subStatus(statusSynced).String() {
+		t.Errorf("status update: %+v", update)
+	}
+	update = <-channel
+	if !reflect.DeepEqual(update.DeletedSubs, []string{"a"}) {
+		t.Errorf("deletion update: %+v", update)
+	}
+	select {
+	case update := <-channel:
+		t.Errorf("unexpected update: %+v", update)
+	default:
+	}
+}
+
+func TestSendSubUpdateClosesSlowReceiver(t *testing.T) {
+	herd := makeSubUpdatesHerd()
+	slowChannel := herd.makeSubUpdateChannel(dominator.GetSubUpdatesRequest{})
+	channel := herd.makeSubUpdateChannel(dominator.GetSubUpdatesRequest{})
+	<-channel
+	sub := herd.subsByName["a"]
+	numSent := 0
+	for ; numSent < 1000; numSent++ {
+		herd.sendSubUpdate(sub, dominator.SubUpdate{
+			DeletedSubs: []string{sub.mdb.Hostname},
+		})
+		<-channel
+		if _, ok := herd.subUpdateNotifiers.notifiers[slowChannel]; !ok {
+			break
+		}
+	}
+	if numSent >= 1000 {
+		t.Fatal("slow receiver not removed")
+	}
+	numReceived := 0
+	for range slowChannel {
+		numReceived++
+	}
+	if numReceived != numSent+1 {
+		t.Errorf("slow receiver got: %d updates, expected: %d",
+			numReceived, numSent+1)
+	}
+	if _, ok := herd.subUpdateNotifiers.notifiers[channel]; !ok {
+		t.Error("receiver which kept up was removed")
+	}
+	herd.closeSubUpdateChannel(channel)
+}
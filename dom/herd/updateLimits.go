@@ -0,0 +1,195 @@
+package herd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/Symantec/Dominator/lib/triggers"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+var (
+	failureDomainTagKey = flag.String("failureDomainTagKey", "",
+		"MDB tag key used to group subs into failure domains (e.g. rack)")
+	maxRebootsPerFailureDomain = flag.Uint("maxRebootsPerFailureDomain", 0,
+		"Maximum number of concurrent reboots per failure domain (0: no limit)")
+	maxTriggeringUpdatesPerFailureDomain = flag.Uint(
+		"maxTriggeringUpdatesPerFailureDomain", 0,
+		"Maximum concurrent triggering updates per failure domain (0: no limit)")
+)
+
+type updateSlot struct {
+	group    string
+	isReboot bool
+}
+
+type groupUsage struct {
+	numTriggering uint
+	numRebooting  uint
+}
+
+type updateLimiter struct {
+	sync.Mutex
+	limits dominator.UpdateConcurrencyLimits
+	groups map[string]*groupUsage
+}
+
+func newUpdateLimiter() *updateLimiter {
+	return &updateLimiter{
+		limits: dominator.UpdateConcurrencyLimits{
+			TagKey:                       *failureDomainTagKey,
+			MaxRebootsPerGroup:           *maxRebootsPerFailureDomain,
+			MaxTriggeringUpdatesPerGroup: *maxTriggeringUpdatesPerFailureDomain,
+		},
+		groups: make(map[string]*groupUsage),
+	}
+}
+
+func (herd *Herd) getUpdateConcurrencyLimits() (
+	limits dominator.UpdateConcurrencyLimits) {
+	herd.updateLimiter.Lock()
+	defer herd.updateLimiter.Unlock()
+	return herd.updateLimiter.limits
+}
+
+func (herd *Herd) setUpdateConcurrencyLimits(
+	limits dominator.UpdateConcurrencyLimits) error {
+	herd.updateLimiter.Lock()
+	defer herd.updateLimiter.Unlock()
+	herd.updateLimiter.limits = limits
+	return nil
+}
+
+// getUpdateGroup returns the failure domain the sub belongs to, or the empty
+// string if the sub is not limited.
+func (limiter *updateLimiter) getUpdateGroup(sub *Sub) string {
+	if limiter.limits.TagKey == "" {
+		return ""
+	}
+	return sub.mdb.Tags[limiter.limits.TagKey]
+}
+
+// acquireUpdateSlot returns false if the update would exceed the concurrency
+// limits for the failure domain of the sub. If true is returned the sub holds
+// a slot until releaseUpdateSlot is called.
+func (sub *Sub) acquireUpdateSlot(matchedTriggers []*triggers.Trigger) bool {
+	if len(matchedTriggers) < 1 {
+		return true
+	}
+	isReboot := isRebootUpdate(matchedTriggers)
+	limiter := sub.herd.updateLimiter
+	limiter.Lock()
+	defer limiter.Unlock()
+	if sub.updateSlot != nil {
+		return true
+	}
+	group := limiter.getUpdateGroup(sub)
+	if group == "" {
+		return true
+	}
+	if !limiter.slotAvailable(group, isReboot) {
+		return false
+	}
+	usage := limiter.groups[group]
+	if usage == nil {
+		usage = &groupUsage{}
+		limiter.groups[group] = usage
+	}
+	usage.numTriggering++
+	if isReboot {
+		usage.numRebooting++
+	}
+	sub.updateSlot = &updateSlot{group: group, isReboot: isReboot}
+	return true
+}
+
+// updateSlotAvailable returns true if acquireUpdateSlot would succeed, without
+// acquiring a slot.
+func (sub *Sub) updateSlotAvailable(matchedTriggers []*triggers.Trigger) bool {
+	if len(matchedTriggers) < 1 {
+		return true
+	}
+	limiter := sub.herd.updateLimiter
+	limiter.Lock()
+	defer limiter.Unlock()
+	if sub.updateSlot != nil {
+		return true
+	}
+	group := limiter.getUpdateGroup(sub)
+	if group == "" {
+		return true
+	}
+	return limiter.slotAvailable(group, isRebootUpdate(matchedTriggers))
+}
+
+func isRebootUpdate(matchedTriggers []*triggers.Trigger) bool {
+	for _, trigger := range matchedTriggers {
+		if trigger.DoReboot {
+			return true
+		}
+	}
+	return false
+}
+
+// slotAvailable must be called with the limiter lock held.
+func (limiter *updateLimiter) slotAvailable(group string, isReboot bool) bool {
+	usage := limiter.groups[group]
+	if usage == nil {
+		return true
+	}
+	maxTriggering := limiter.limits.MaxTriggeringUpdatesPerGroup
+	if maxTriggering > 0 && usage.numTriggering >= maxTriggering {
+		return false
+	}
+	maxRebooting := limiter.limits.MaxRebootsPerGroup
+	if isReboot && maxRebooting > 0 && usage.numRebooting >= maxRebooting {
+		return false
+	}
+	return true
+}
+
+func (sub *Sub) releaseUpdateSlot() {
+	limiter := sub.herd.updateLimiter
+	limiter.Lock()
+	defer limiter.Unlock()
+	if sub.updateSlot == nil {
+		return
+	}
+	if usage := limiter.groups[sub.updateSlot.group]; usage != nil {
+		usage.numTriggering--
+		if sub.updateSlot.isReboot {
+			usage.numRebooting--
+		}
+		if usage.numTriggering < 1 {
+			delete(limiter.groups, sub.updateSlot.group)
+		}
+	}
+	sub.updateSlot = nil
+}
+
+// haveUpdateSlot returns true if there is probably a free slot for the sub to
+// perform an update which runs triggers.
+func (sub *Sub) haveUpdateSlot() bool {
+	limiter := sub.herd.updateLimiter
+	limiter.Lock()
+	defer limiter.Unlock()
+	usage := limiter.groups[limiter.getUpdateGroup(sub)]
+	if usage == nil {
+		return true
+	}
+	maxTriggering := limiter.limits.MaxTriggeringUpdatesPerGroup
+	return maxTriggering < 1 || usage.numTriggering < maxTriggering
+}
+
+func (herd *Herd) writeUpdateLimits(writer io.Writer) {
+	limits := herd.getUpdateConcurrencyLimits()
+	if limits.TagKey == "" {
+		return
+	}
+	fmt.Fprintf(writer,
+		"Update limits per \"%s\": triggering updates: %d, reboots: %d<br>\n",
+		limits.TagKey, limits.MaxTriggeringUpdatesPerGroup,
+		limits.MaxRebootsPerGroup)
+}
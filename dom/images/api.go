@@ -1,7 +1,9 @@
 package images
 
 import (
+	"crypto"
 	"sync"
+	"time"
 
 	"github.com/Symantec/Dominator/lib/image"
 	"github.com/Symantec/Dominator/lib/log"
@@ -10,6 +12,7 @@ import (
 
 type Manager struct {
 	imageServerAddress string
+	trustedKeys        []crypto.PublicKey
 	logger             log.Logger
 	loggedDialFailure  bool
 	sync.RWMutex
@@ -20,10 +23,16 @@ type Manager struct {
 	imageExpireChannel   chan<- string
 	imagesByName         map[string]*image.Image
 	missingImages        map[string]error
+	// Only accessed by the manager goroutine.
+	rejectedImages map[string]time.Time // Value: time to try again.
 }
 
-func New(imageServerAddress string, logger log.Logger) *Manager {
-	return newManager(imageServerAddress, logger)
+// New creates a Manager which fetches images from the imageserver. If
+// trustedKeys is not nil, images which are not signed by one of the keys are
+// rejected.
+func New(imageServerAddress string, trustedKeys []crypto.PublicKey,
+	logger log.Logger) *Manager {
+	return newManager(imageServerAddress, trustedKeys, logger)
 }
 
 func (m *Manager) Get(name string, wait bool) (*image.Image, error) {
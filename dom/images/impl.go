@@ -1,6 +1,8 @@
 package images
 
 import (
+	"crypto"
+	"fmt"
 	"time"
 
 	"github.com/Symantec/Dominator/imageserver/client"
@@ -10,12 +12,16 @@ import (
 	"github.com/Symantec/Dominator/lib/stringutil"
 )
 
-func newManager(imageServerAddress string, logger log.Logger) *Manager {
+const rejectedImageRetryInterval = time.Minute
+
+func newManager(imageServerAddress string, trustedKeys []crypto.PublicKey,
+	logger log.Logger) *Manager {
 	imageInterestChannel := make(chan map[string]struct{})
 	imageRequestChannel := make(chan string)
 	imageExpireChannel := make(chan string, 16)
 	m := &Manager{
 		imageServerAddress:   imageServerAddress,
+		trustedKeys:          trustedKeys,
 		logger:               logger,
 		deduper:              stringutil.NewStringDeduplicator(false),
 		imageInterestChannel: imageInterestChannel,
@@ -23,6 +29,7 @@ func newManager(imageServerAddress string, logger log.Logger) *Manager {
 		imageExpireChannel:   imageExpireChannel,
 		imagesByName:         make(map[string]*image.Image),
 		missingImages:        make(map[string]error),
+		rejectedImages:       make(map[string]time.Time),
 	}
 	go m.manager(imageInterestChannel, imageRequestChannel, imageExpireChannel)
 	return m
@@ -41,11 +48,11 @@ func (m *Manager) getNoWait(name string) (*image.Image, error) {
 }
 
 func (m *Manager) getWait(name string) (*image.Image, error) {
-	if image, err := m.getNoWait(name); err != nil {
-		return nil, err
-	} else if image != nil {
+	if image, _ := m.getNoWait(name); image != nil {
 		return image, nil
 	}
+	// Request the image again even if it recently failed to load or was
+	// rejected, so that the caller gets a current result.
 	m.imageRequestChannel <- name
 	m.imageRequestChannel <- ""
 	return m.getNoWait(name)
@@ -72,6 +79,7 @@ func (m *Manager) manager(imageInterestChannel <-chan map[string]struct{},
 			if name == "" {
 				continue
 			}
+			delete(m.rejectedImages, name)
 			imageClient = m.requestImage(imageClient, name)
 		case name := <-imageExpireChannel:
 			m.Lock()
@@ -114,6 +122,7 @@ func (m *Manager) setInterest(imageClient *srpc.Client,
 			m.Lock()
 			delete(m.missingImages, name)
 			m.Unlock()
+			delete(m.rejectedImages, name)
 		}
 	}
 	if deletedSome {
@@ -127,6 +136,12 @@ func (m *Manager) requestImage(imageClient *srpc.Client,
 	if _, ok := m.imagesByName[name]; ok {
 		return imageClient
 	}
+	if retryTime, ok := m.rejectedImages[name]; ok {
+		if time.Now().Before(retryTime) {
+			return imageClient
+		}
+		delete(m.rejectedImages, name)
+	}
 	var img *image.Image
 	var err error
 	imageClient, img, err = m.loadImage(imageClient, name)
@@ -165,6 +180,15 @@ func (m *Manager) loadImage(imageClient *srpc.Client, name string) (
 	if img == nil || m.scheduleExpiration(img, name) {
 		return imageClient, nil, nil
 	}
+	if m.trustedKeys != nil {
+		// Must verify before the filter is applied to the file-system.
+		if err := img.VerifySignatures(m.trustedKeys); err != nil {
+			m.logger.Printf("Rejecting image: %s: %s\n", name, err)
+			m.rejectedImages[name] = time.Now().Add(
+				rejectedImageRetryInterval)
+			return imageClient, nil, fmt.Errorf("image: %s: %s", name, err)
+		}
+	}
 	if err := img.FileSystem.RebuildInodePointers(); err != nil {
 		m.logger.Printf("Error building inode pointers for image: %s %s",
 			name, err)
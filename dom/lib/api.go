@@ -19,6 +19,8 @@ import (
 	"github.com/Symantec/Dominator/lib/objectcache"
 	"github.com/Symantec/Dominator/lib/objectserver"
 	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/lib/triggers"
+	"github.com/Symantec/Dominator/proto/dominator"
 	subproto "github.com/Symantec/Dominator/proto/sub"
 )
 
@@ -34,6 +36,7 @@ type Sub struct {
 	ComputedInodes          map[string]*filesystem.RegularInode
 	ObjectCache             objectcache.ObjectCache
 	ObjectGetter            objectserver.ObjectGetter
+	LockedPaths             *filter.Filter // Locked on the sub: not updated.
 	requiredInodeToSubInode map[uint64]uint64
 	inodesMapped            map[uint64]struct{} // Sub inode number.
 	inodesChanged           map[uint64]struct{} // Required inode number.
@@ -62,6 +65,16 @@ func BuildMissingLists(sub Sub, image *image.Image, pushComputedFiles bool,
 		ignoreMissingComputedFiles, logger)
 }
 
+// BuildFetchDeltas will construct a list of objects to fetch which may be built
+// by the sub from an existing file at the same path and a block delta from the
+// object server. Only objects of at least minimumSize bytes are included.
+// If minimumSize is zero the list is empty.
+func BuildFetchDeltas(sub Sub, image *image.Image,
+	objectsToFetch map[hash.Hash]uint64,
+	minimumSize uint64) []subproto.FetchDelta {
+	return sub.buildFetchDeltas(image, objectsToFetch, minimumSize)
+}
+
 // BuildUpdateRequest will build an update request which can be sent to the sub.
 // If deleteMissingComputedFiles is true then missing computed files are deleted
 // on the sub, else missing computed files lead to the function failing.
@@ -75,6 +88,21 @@ func BuildUpdateRequest(sub Sub, image *image.Image,
 		ignoreMissingComputedFiles, logger)
 }
 
+// ComputeDrift will compare sub.FileSystem with the desired image and returns a
+// report of the differences. Paths excluded by the image filter are ignored.
+// Computed file metadata are specified by sub.ComputedInodes. The ImageName
+// and ComputeTime fields of the report are not filled in.
+func ComputeDrift(sub Sub, image *image.Image) *dominator.DriftReport {
+	return sub.computeDrift(image)
+}
+
+// MatchTriggers returns the triggers from request.Triggers which match the
+// paths that the sub will change when processing request. The triggers in
+// request are not modified.
+func MatchTriggers(request subproto.UpdateRequest) []*triggers.Trigger {
+	return matchTriggers(request)
+}
+
 // PushObjects will push the list of files given by objectsToPush to the sub.
 // File data are obtained from sub.ObjectGetter.
 func PushObjects(sub Sub, objectsToPush map[hash.Hash]struct{},
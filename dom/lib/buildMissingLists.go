@@ -5,6 +5,7 @@ import (
 	"github.com/Symantec/Dominator/lib/hash"
 	"github.com/Symantec/Dominator/lib/image"
 	"github.com/Symantec/Dominator/lib/log"
+	subproto "github.com/Symantec/Dominator/proto/sub"
 )
 
 func (sub *Sub) buildMissingLists(image *image.Image, pushComputedFiles bool,
@@ -48,3 +49,55 @@ func (sub *Sub) buildMissingLists(image *image.Image, pushComputedFiles bool,
 	}
 	return objectsToFetch, objectsToPush
 }
+
+func (sub *Sub) buildFetchDeltas(image *image.Image,
+	objectsToFetch map[hash.Hash]uint64,
+	minimumSize uint64) []subproto.FetchDelta {
+	if minimumSize < 1 {
+		return nil
+	}
+	haveCandidates := false
+	for _, size := range objectsToFetch {
+		if size >= minimumSize {
+			haveCandidates = true
+			break
+		}
+	}
+	if !haveCandidates {
+		return nil
+	}
+	var deltas []subproto.FetchDelta
+	added := make(map[hash.Hash]struct{})
+	subFilenameToInode := sub.FileSystem.FilenameToInodeTable()
+	for inum, filenames := range image.FileSystem.InodeToFilenamesTable() {
+		inode, ok :=
+			image.FileSystem.InodeTable[inum].(*filesystem.RegularInode)
+		if !ok || inode.Size < minimumSize {
+			continue
+		}
+		if _, ok := objectsToFetch[inode.Hash]; !ok {
+			continue
+		}
+		if _, ok := added[inode.Hash]; ok {
+			continue
+		}
+		for _, filename := range filenames {
+			subInum, ok := subFilenameToInode[filename]
+			if !ok {
+				continue
+			}
+			subInode, ok :=
+				sub.FileSystem.InodeTable[subInum].(*filesystem.RegularInode)
+			if !ok || subInode.Size < 1 {
+				continue
+			}
+			deltas = append(deltas, subproto.FetchDelta{
+				Hash:         inode.Hash,
+				BasePathname: filename,
+			})
+			added[inode.Hash] = struct{}{}
+			break
+		}
+	}
+	return deltas
+}
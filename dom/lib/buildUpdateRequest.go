@@ -30,8 +30,9 @@ func (sub *Sub) buildUpdateRequest(image *image.Image,
 	for _, hash := range sub.ObjectCache {
 		sub.subObjectCacheUsage[hash] = 0
 	}
-	if !filesystem.CompareDirectoriesMetadata(&sub.FileSystem.DirectoryInode,
-		&sub.requiredFS.DirectoryInode, nil) {
+	_, sameMetadata, _ := filesystem.CompareRequiredInodes(
+		&sub.FileSystem.DirectoryInode, &sub.requiredFS.DirectoryInode, nil)
+	if !sameMetadata {
 		makeDirectory(request, &sub.requiredFS.DirectoryInode, "/", false)
 	}
 	if sub.compareDirectories(request,
@@ -63,6 +64,9 @@ func (sub *Sub) compareDirectories(request *subproto.UpdateRequest,
 			if sub.filter.Match(pathname) {
 				continue
 			}
+			if sub.isLocked(pathname, subDirectory.EntriesByName[name]) {
+				continue
+			}
 			if _, ok := requiredDirectory.EntriesByName[name]; !ok {
 				request.PathsToDelete = append(request.PathsToDelete, pathname)
 			}
@@ -80,6 +84,9 @@ func (sub *Sub) compareDirectories(request *subproto.UpdateRequest,
 		if sub.filter != nil && sub.filter.Match(pathname) {
 			continue
 		}
+		if sub.LockedPaths != nil && sub.LockedPaths.Match(pathname) {
+			continue
+		}
 		var subEntry *filesystem.DirectoryEntry
 		if subDirectory != nil {
 			if se, ok := subDirectory.EntriesByName[name]; ok {
@@ -134,6 +141,26 @@ func (sub *Sub) compareDirectories(request *subproto.UpdateRequest,
 	return false
 }
 
+// isLocked returns true if the path or (for a directory) any path below it is
+// locked on the sub.
+func (sub *Sub) isLocked(pathname string,
+	subEntry *filesystem.DirectoryEntry) bool {
+	if sub.LockedPaths == nil {
+		return false
+	}
+	if sub.LockedPaths.Match(pathname) {
+		return true
+	}
+	if directory, ok := subEntry.Inode().(*filesystem.DirectoryInode); ok {
+		for _, entry := range directory.EntryList {
+			if sub.isLocked(path.Join(pathname, entry.Name), entry) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func setComputedFileMtime(requiredInode *filesystem.RegularInode,
 	subEntry *filesystem.DirectoryEntry) {
 	if requiredInode.MtimeSeconds >= 0 {
@@ -168,7 +195,7 @@ func (sub *Sub) compareEntries(request *subproto.UpdateRequest,
 	logger log.DebugLogger) {
 	subInode := subEntry.Inode()
 	requiredInode := requiredEntry.Inode()
-	sameType, sameMetadata, sameData := filesystem.CompareInodes(
+	sameType, sameMetadata, sameData := filesystem.CompareRequiredInodes(
 		subInode, requiredInode, nil)
 	if requiredInode, ok := requiredInode.(*filesystem.DirectoryInode); ok {
 		if sameMetadata {
@@ -267,6 +294,7 @@ func makeDirectory(request *subproto.UpdateRequest,
 	newDirectoryInode.Mode = requiredInode.Mode
 	newDirectoryInode.Uid = requiredInode.Uid
 	newDirectoryInode.Gid = requiredInode.Gid
+	newDirectoryInode.Xattrs = requiredInode.Xattrs
 	newInode.GenericInode = &newDirectoryInode
 	if create {
 		request.DirectoriesToMake = append(request.DirectoriesToMake, newInode)
@@ -297,7 +325,7 @@ func (sub *Sub) addInode(request *subproto.UpdateRequest,
 			}
 			if inum, found := subFS.FilenameToInodeTable()[name]; found {
 				subInode := sub.FileSystem.InodeTable[inum]
-				_, sameMetadata, sameData := filesystem.CompareInodes(
+				_, sameMetadata, sameData := filesystem.CompareRequiredInodes(
 					subInode, requiredInode, nil)
 				if sameMetadata && sameData {
 					logger.Debugf(0, "make sibling link: %s to %s (uid=%d)\n",
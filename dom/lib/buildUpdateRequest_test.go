@@ -259,6 +259,51 @@ func TestSplitHashes5(t *testing.T) {
 	}
 }
 
+func TestXattrsNotInImage(t *testing.T) {
+	request := makeUpdateRequest(t, testDataFileXattrs(nil),
+		testDataFileXattrs(selinuxXattrs))
+	if !reflect.DeepEqual(request, subproto.UpdateRequest{}) {
+		t.Error("Unexpected changes being made")
+	}
+}
+
+func TestStrayCapability(t *testing.T) {
+	request := makeUpdateRequest(t, testDataFileXattrs(nil),
+		testDataFileXattrs(capabilityXattrs))
+	if len(request.InodesToChange) != 1 {
+		t.Fatal("Inode not being changed")
+	}
+	inode := request.InodesToChange[0].GenericInode.(*filesystem.RegularInode)
+	if len(inode.Xattrs) > 0 {
+		t.Errorf("Capability not being removed: %v", inode.Xattrs)
+	}
+}
+
+func TestXattrsInImage(t *testing.T) {
+	request := makeUpdateRequest(t, testDataFileXattrs(selinuxXattrs),
+		testDataFileXattrs(nil))
+	if len(request.InodesToChange) != 1 {
+		t.Fatal("Inode not being changed")
+	}
+	inode := request.InodesToChange[0].GenericInode.(*filesystem.RegularInode)
+	if !filesystem.CompareXattrs(inode.Xattrs, selinuxXattrs, nil) {
+		t.Errorf("Xattrs not being set: %v", inode.Xattrs)
+	}
+}
+
+func TestDirectoryXattrs(t *testing.T) {
+	imageFS := testDataDirectory0()
+	imageFS.InodeTable[1].(*filesystem.DirectoryInode).Xattrs = selinuxXattrs
+	request := makeUpdateRequest(t, imageFS, testDataDirectory0())
+	if len(request.InodesToChange) != 1 {
+		t.Fatal("Directory not being changed")
+	}
+	inode := request.InodesToChange[0].GenericInode.(*filesystem.DirectoryInode)
+	if !filesystem.CompareXattrs(inode.Xattrs, selinuxXattrs, nil) {
+		t.Errorf("Xattrs not being set: %v", inode.Xattrs)
+	}
+}
+
 func makeUpdateRequest(t *testing.T, imageFS *filesystem.FileSystem,
 	subFS *filesystem.FileSystem) subproto.UpdateRequest {
 	fetchedObjects := make(map[hash.Hash]struct{}, len(imageFS.InodeTable))
@@ -401,6 +446,22 @@ func testDataDuplicateFiles() *filesystem.FileSystem {
 	}
 }
 
+func testDataFileXattrs(xattrs map[string][]byte) *filesystem.FileSystem {
+	return &filesystem.FileSystem{
+		InodeTable: filesystem.InodeTable{
+			1: &filesystem.RegularInode{Size: 100, Hash: hash0, Xattrs: xattrs},
+		},
+		DirectoryInode: filesystem.DirectoryInode{
+			EntryList: []*filesystem.DirectoryEntry{
+				&filesystem.DirectoryEntry{
+					Name:        "file0",
+					InodeNumber: 1,
+				},
+			},
+		},
+	}
+}
+
 func testDataLinkedFiles(nFiles int) *filesystem.FileSystem {
 	entries := make([]*filesystem.DirectoryEntry, 0, nFiles)
 	for i := 0; i < nFiles; i++ {
@@ -443,4 +504,12 @@ func testDataMulti(fInfos []fileInfo, files []uint64) *filesystem.FileSystem {
 var (
 	hash0 hash.Hash = hash.Hash{0xde, 0xad}
 	hash1 hash.Hash = hash.Hash{0xbe, 0xef}
+
+	selinuxXattrs = map[string][]byte{
+		"security.selinux": []byte("system_u:object_r:bin_t:s0"),
+	}
+	capabilityXattrs = map[string][]byte{
+		"security.capability": []byte{0, 0, 0, 2, 0, 4, 0, 0, 0, 0, 0, 0,
+			0, 0, 0, 0, 0, 0, 0, 0},
+	}
 )
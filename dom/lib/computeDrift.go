@@ -0,0 +1,21 @@
+package lib
+
+import (
+	"github.com/Symantec/Dominator/lib/image"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func (sub *Sub) computeDrift(image *image.Image) *dominator.DriftReport {
+	drift := sub.FileSystem.ComputeDrift(image.FileSystem, image.Filter,
+		sub.ComputedInodes)
+	report := &dominator.DriftReport{
+		AddedPaths:    drift.AddedPaths,
+		MissingPaths:  drift.MissingPaths,
+		ModifiedPaths: drift.ModifiedPaths,
+	}
+	for _, change := range drift.MetadataChanges {
+		report.MetadataChanges = append(report.MetadataChanges,
+			dominator.MetadataChange(change))
+	}
+	return report
+}
@@ -0,0 +1,68 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/Symantec/Dominator/lib/filesystem"
+	"github.com/Symantec/Dominator/lib/filter"
+	"github.com/Symantec/Dominator/lib/image"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func TestDriftSameFile(t *testing.T) {
+	report := makeDriftReport(t, testDataFile0(0), testDataFile0(0), nil)
+	if len(report.AddedPaths) != 0 || len(report.MissingPaths) != 0 ||
+		len(report.ModifiedPaths) != 0 || len(report.MetadataChanges) != 0 {
+		t.Errorf("unexpected drift: %v", report)
+	}
+}
+
+func TestDriftAddedAndMissing(t *testing.T) {
+	report := makeDriftReport(t, testDataFile0(0), testDataFile1(0), nil)
+	if len(report.AddedPaths) != 1 || report.AddedPaths[0] != "/file1" {
+		t.Errorf("added paths: %v != [/file1]", report.AddedPaths)
+	}
+	if len(report.MissingPaths) != 1 || report.MissingPaths[0] != "/file0" {
+		t.Errorf("missing paths: %v != [/file0]", report.MissingPaths)
+	}
+}
+
+func TestDriftFilteredAdded(t *testing.T) {
+	report := makeDriftReport(t, testDataDirectory0(), testDataDirectory01(),
+		[]string{"/dir1"})
+	if len(report.AddedPaths) != 0 {
+		t.Errorf("added paths: %v != []", report.AddedPaths)
+	}
+}
+
+func TestDriftMetadataChange(t *testing.T) {
+	report := makeDriftReport(t, testDataFile0(0), testDataFile0(1), nil)
+	if len(report.ModifiedPaths) != 0 {
+		t.Errorf("modified paths: %v != []", report.ModifiedPaths)
+	}
+	if len(report.MetadataChanges) != 1 {
+		t.Fatalf("number of metadata changes: %d != 1",
+			len(report.MetadataChanges))
+	}
+	change := report.MetadataChanges[0]
+	if change.Pathname != "/file0" || !change.UidChanged ||
+		change.ModeChanged || change.GidChanged || change.MtimeChanged {
+		t.Errorf("unexpected metadata change: %v", change)
+	}
+}
+
+func makeDriftReport(t *testing.T, imageFS *filesystem.FileSystem,
+	subFS *filesystem.FileSystem, filterLines []string) *dominator.DriftReport {
+	for _, fs := range []*filesystem.FileSystem{imageFS, subFS} {
+		if err := fs.RebuildInodePointers(); err != nil {
+			t.Fatal(err)
+		}
+		fs.BuildEntryMap()
+	}
+	imageFilter, err := filter.New(filterLines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ComputeDrift(Sub{FileSystem: subFS},
+		&image.Image{FileSystem: imageFS, Filter: imageFilter})
+}
@@ -0,0 +1,36 @@
+package lib
+
+import (
+	"sort"
+
+	"github.com/Symantec/Dominator/lib/triggers"
+	subproto "github.com/Symantec/Dominator/proto/sub"
+)
+
+func matchTriggers(request subproto.UpdateRequest) []*triggers.Trigger {
+	if request.Triggers == nil || len(request.Triggers.Triggers) < 1 {
+		return nil
+	}
+	// Work on a private copy: the triggers may be shared with other requests.
+	mergeableTriggers := &triggers.MergeableTriggers{}
+	mergeableTriggers.Merge(request.Triggers)
+	trig := mergeableTriggers.ExportTriggers()
+	for _, inode := range request.DirectoriesToMake {
+		trig.Match(inode.Name)
+	}
+	for _, inode := range request.InodesToMake {
+		trig.Match(inode.Name)
+	}
+	for _, hardlink := range request.HardlinksToMake {
+		trig.Match(hardlink.NewLink)
+	}
+	for _, pathname := range request.PathsToDelete {
+		trig.Match(pathname)
+	}
+	for _, inode := range request.InodesToChange {
+		trig.Match(inode.Name)
+	}
+	trig = &triggers.Triggers{Triggers: trig.GetMatchedTriggers()}
+	sort.Sort(trig)
+	return trig.Triggers
+}
@@ -0,0 +1,17 @@
+package rpcd
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func (t *rpcType) AbortRollout(conn *srpc.Conn,
+	request dominator.AbortRolloutRequest,
+	reply *dominator.AbortRolloutResponse) error {
+	if conn.Username() == "" {
+		t.logger.Printf("AbortRollout()\n")
+	} else {
+		t.logger.Printf("AbortRollout(): by %s\n", conn.Username())
+	}
+	return t.herd.AbortRollout()
+}
@@ -0,0 +1,18 @@
+package rpcd
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func (t *rpcType) ClearHostImageOverride(conn *srpc.Conn,
+	request dominator.ClearHostImageOverrideRequest,
+	reply *dominator.ClearHostImageOverrideResponse) error {
+	if conn.Username() == "" {
+		t.logger.Printf("ClearHostImageOverride(%s)\n", request.Hostname)
+	} else {
+		t.logger.Printf("ClearHostImageOverride(%s): by %s\n",
+			request.Hostname, conn.Username())
+	}
+	return t.herd.ClearHostImageOverride(request.Hostname)
+}
@@ -0,0 +1,18 @@
+package rpcd
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func (t *rpcType) ClearImageQuarantine(conn *srpc.Conn,
+	request dominator.ClearImageQuarantineRequest,
+	reply *dominator.ClearImageQuarantineResponse) error {
+	if conn.Username() == "" {
+		t.logger.Printf("ClearImageQuarantine(%s)\n", request.ImageName)
+	} else {
+		t.logger.Printf("ClearImageQuarantine(%s): by %s\n",
+			request.ImageName, conn.Username())
+	}
+	return t.herd.ClearImageQuarantine(request.ImageName)
+}
@@ -14,5 +14,6 @@ func (t *rpcType) ConfigureSubs(conn *srpc.Conn,
 	} else {
 		t.logger.Printf("ConfigureSubs(): by %s\n", conn.Username())
 	}
-	return t.herd.ConfigureSubs(sub.Configuration(request))
+	return t.herd.ConfigureSubs(conn.Username(),
+		sub.Configuration(request))
 }
@@ -0,0 +1,17 @@
+package rpcd
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func (t *rpcType) GetAuditLog(conn *srpc.Conn,
+	request dominator.GetAuditLogRequest,
+	reply *dominator.GetAuditLogResponse) error {
+	records, err := t.herd.GetAuditLog(request)
+	if err != nil {
+		return err
+	}
+	reply.Records = records
+	return nil
+}
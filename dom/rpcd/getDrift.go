@@ -0,0 +1,17 @@
+package rpcd
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func (t *rpcType) GetDrift(conn *srpc.Conn,
+	request dominator.GetDriftRequest,
+	reply *dominator.GetDriftResponse) error {
+	report, err := t.herd.GetDrift(request.Hostname)
+	if err != nil {
+		return err
+	}
+	reply.Drift = *report
+	return nil
+}
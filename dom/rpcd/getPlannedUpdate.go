@@ -0,0 +1,17 @@
+package rpcd
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func (t *rpcType) GetPlannedUpdate(conn *srpc.Conn,
+	request dominator.GetPlannedUpdateRequest,
+	reply *dominator.GetPlannedUpdateResponse) error {
+	response, err := t.herd.GetPlannedUpdate(request.Hostname)
+	if err != nil {
+		return err
+	}
+	*reply = *response
+	return nil
+}
@@ -0,0 +1,13 @@
+package rpcd
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func (t *rpcType) GetRolloutStatus(conn *srpc.Conn,
+	request dominator.GetRolloutStatusRequest,
+	reply *dominator.GetRolloutStatusResponse) error {
+	reply.Rollout = t.herd.GetRolloutStatus()
+	return nil
+}
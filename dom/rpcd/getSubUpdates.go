@@ -0,0 +1,49 @@
+package rpcd
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+const flushDelay = time.Millisecond * 10
+
+func (t *rpcType) GetSubUpdates(conn *srpc.Conn, decoder srpc.Decoder,
+	encoder srpc.Encoder) error {
+	var request dominator.GetSubUpdatesRequest
+	if err := decoder.Decode(&request); err != nil {
+		return err
+	}
+	closeChannel := conn.GetCloseNotifier()
+	updateChannel := t.herd.MakeSubUpdateChannel(request)
+	defer t.herd.CloseSubUpdateChannel(updateChannel)
+	flushTimer := time.NewTimer(flushDelay)
+	for {
+		select {
+		case update, ok := <-updateChannel:
+			if !ok {
+				err := errors.New("receiver not keeping up with updates")
+				t.logger.Printf("error sending update: %s\n", err)
+				return err
+			}
+			if err := encoder.Encode(update); err != nil {
+				t.logger.Printf("error sending update: %s\n", err)
+				return err
+			}
+			flushTimer.Reset(flushDelay)
+		case <-flushTimer.C:
+			if err := conn.Flush(); err != nil {
+				t.logger.Printf("error flushing update(s): %s\n", err)
+				return err
+			}
+		case err := <-closeChannel:
+			if err == nil {
+				return nil
+			}
+			t.logger.Println(err)
+			return err
+		}
+	}
+}
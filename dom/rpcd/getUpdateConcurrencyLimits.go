@@ -0,0 +1,14 @@
+package rpcd
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func (t *rpcType) GetUpdateConcurrencyLimits(conn *srpc.Conn,
+	request dominator.GetUpdateConcurrencyLimitsRequest,
+	reply *dominator.GetUpdateConcurrencyLimitsResponse) error {
+	*reply = dominator.GetUpdateConcurrencyLimitsResponse(
+		t.herd.GetUpdateConcurrencyLimits())
+	return nil
+}
@@ -0,0 +1,13 @@
+package rpcd
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func (t *rpcType) ListHostImageOverrides(conn *srpc.Conn,
+	request dominator.ListHostImageOverridesRequest,
+	reply *dominator.ListHostImageOverridesResponse) error {
+	reply.Overrides = t.herd.ListHostImageOverrides()
+	return nil
+}
@@ -0,0 +1,18 @@
+package rpcd
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func (t *rpcType) PauseRollout(conn *srpc.Conn,
+	request dominator.PauseRolloutRequest,
+	reply *dominator.PauseRolloutResponse) error {
+	if conn.Username() == "" {
+		t.logger.Printf("PauseRollout(%s)\n", request.Reason)
+	} else {
+		t.logger.Printf("PauseRollout(%s): by %s\n",
+			request.Reason, conn.Username())
+	}
+	return t.herd.PauseRollout(request.Reason)
+}
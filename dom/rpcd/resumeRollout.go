@@ -0,0 +1,17 @@
+package rpcd
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func (t *rpcType) ResumeRollout(conn *srpc.Conn,
+	request dominator.ResumeRolloutRequest,
+	reply *dominator.ResumeRolloutResponse) error {
+	if conn.Username() == "" {
+		t.logger.Printf("ResumeRollout()\n")
+	} else {
+		t.logger.Printf("ResumeRollout(): by %s\n", conn.Username())
+	}
+	return t.herd.ResumeRollout()
+}
@@ -14,5 +14,5 @@ func (t *rpcType) SetDefaultImage(conn *srpc.Conn,
 		t.logger.Printf("SetDefaultImage(%s): by %s\n",
 			request.ImageName, conn.Username())
 	}
-	return t.herd.SetDefaultImage(request.ImageName)
+	return t.herd.SetDefaultImage(conn.Username(), request.ImageName)
 }
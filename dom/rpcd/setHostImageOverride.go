@@ -0,0 +1,22 @@
+package rpcd
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func (t *rpcType) SetHostImageOverride(conn *srpc.Conn,
+	request dominator.SetHostImageOverrideRequest,
+	reply *dominator.SetHostImageOverrideResponse) error {
+	if conn.Username() == "" {
+		t.logger.Printf("SetHostImageOverride(%s, %s, %t, %s, %s)\n",
+			request.Hostname, request.ImageName, request.DisableUpdates,
+			request.Expires, request.Reason)
+	} else {
+		t.logger.Printf("SetHostImageOverride(%s, %s, %t, %s, %s): by %s\n",
+			request.Hostname, request.ImageName, request.DisableUpdates,
+			request.Expires, request.Reason, conn.Username())
+	}
+	return t.herd.SetHostImageOverride(conn.Username(),
+		dominator.HostImageOverride(request))
+}
@@ -0,0 +1,22 @@
+package rpcd
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func (t *rpcType) SetUpdateConcurrencyLimits(conn *srpc.Conn,
+	request dominator.SetUpdateConcurrencyLimitsRequest,
+	reply *dominator.SetUpdateConcurrencyLimitsResponse) error {
+	if conn.Username() == "" {
+		t.logger.Printf("SetUpdateConcurrencyLimits(%s, %d, %d)\n",
+			request.TagKey, request.MaxTriggeringUpdatesPerGroup,
+			request.MaxRebootsPerGroup)
+	} else {
+		t.logger.Printf("SetUpdateConcurrencyLimits(%s, %d, %d): by %s\n",
+			request.TagKey, request.MaxTriggeringUpdatesPerGroup,
+			request.MaxRebootsPerGroup, conn.Username())
+	}
+	return t.herd.SetUpdateConcurrencyLimits(
+		dominator.UpdateConcurrencyLimits(request))
+}
@@ -0,0 +1,19 @@
+package rpcd
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/dominator"
+)
+
+func (t *rpcType) StartRollout(conn *srpc.Conn,
+	request dominator.StartRolloutRequest,
+	reply *dominator.StartRolloutResponse) error {
+	if conn.Username() == "" {
+		t.logger.Printf("StartRollout(%s, %v)\n",
+			request.ImageName, request.WavePercentages)
+	} else {
+		t.logger.Printf("StartRollout(%s, %v): by %s\n",
+			request.ImageName, request.WavePercentages, conn.Username())
+	}
+	return t.herd.StartRollout(conn.Username(), request)
+}
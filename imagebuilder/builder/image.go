@@ -238,6 +238,11 @@ func buildImageFromManifest(client *srpc.Client, streamName, manifestDir string,
 		mergeableTriggers.Merge(manifest.sourceImageInfo.triggers)
 		mergeableTriggers.Merge(imageTriggers)
 		imageTriggers = mergeableTriggers.ExportTriggers()
+		if imageTriggers != nil {
+			if err := imageTriggers.CheckDependencies(); err != nil {
+				return "", err
+			}
+		}
 	}
 	startTime := time.Now()
 	name, err := addImage(client, streamName, rootDir, manifest.filter,
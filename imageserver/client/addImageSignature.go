@@ -0,0 +1,18 @@
+package client
+
+import (
+	"github.com/Symantec/Dominator/lib/image"
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/imageserver"
+)
+
+func addImageSignature(client *srpc.Client, name string,
+	signature image.Signature) error {
+	request := imageserver.AddImageSignatureRequest{
+		ImageName: name,
+		Signature: signature,
+	}
+	var reply imageserver.AddImageSignatureResponse
+	return client.RequestReply("ImageServer.AddImageSignature", request,
+		&reply)
+}
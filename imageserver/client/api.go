@@ -6,6 +6,8 @@ import (
 	"github.com/Symantec/Dominator/lib/hash"
 	"github.com/Symantec/Dominator/lib/image"
 	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/lib/tags"
+	"github.com/Symantec/Dominator/proto/imageserver"
 )
 
 func AddImage(client *srpc.Client, name string, img *image.Image) error {
@@ -16,6 +18,21 @@ func AddImageTrusted(client *srpc.Client, name string, img *image.Image) error {
 	return addImageTrusted(client, name, img)
 }
 
+func AddImageSignature(client *srpc.Client, name string,
+	signature image.Signature) error {
+	return addImageSignature(client, name, signature)
+}
+
+func ApplyRetentionPolicies(client *srpc.Client, dirname string,
+	dryRun bool) ([]string, error) {
+	return applyRetentionPolicies(client, dirname, dryRun)
+}
+
+func ChangeImageLabels(client *srpc.Client, name string,
+	labelsToSet tags.Tags, labelsToDelete []string) error {
+	return changeImageLabels(client, name, labelsToSet, labelsToDelete)
+}
+
 func CheckDirectory(client *srpc.Client, name string) (bool, error) {
 	return checkDirectory(client, name)
 }
@@ -37,6 +54,15 @@ func DeleteUnreferencedObjects(client *srpc.Client, percentage uint8,
 	return deleteUnreferencedObjects(client, percentage, bytes)
 }
 
+func DiffImages(client *srpc.Client, left, right string) (*image.Diff, error) {
+	return diffImages(client, left, right)
+}
+
+func FindImages(client *srpc.Client,
+	request imageserver.FindImagesRequest) ([]string, error) {
+	return findImages(client, request)
+}
+
 func FindLatestImage(client *srpc.Client, dirname string,
 	ignoreExpiring bool) (string, error) {
 	return findLatestImage(client, dirname, ignoreExpiring)
@@ -67,3 +93,8 @@ func ListUnreferencedObjects(client *srpc.Client) (
 func MakeDirectory(client *srpc.Client, dirname string) error {
 	return makeDirectory(client, dirname)
 }
+
+func SetRetentionPolicy(client *srpc.Client, dirname string,
+	policy image.RetentionPolicy) error {
+	return setRetentionPolicy(client, dirname, policy)
+}
@@ -0,0 +1,18 @@
+package client
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/imageserver"
+)
+
+func applyRetentionPolicies(client *srpc.Client, dirname string,
+	dryRun bool) ([]string, error) {
+	request := imageserver.ApplyRetentionPoliciesRequest{
+		DirectoryName: dirname,
+		DryRun:        dryRun,
+	}
+	var reply imageserver.ApplyRetentionPoliciesResponse
+	err := client.RequestReply("ImageServer.ApplyRetentionPolicies", request,
+		&reply)
+	return reply.ImageNames, err
+}
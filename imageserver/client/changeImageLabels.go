@@ -0,0 +1,19 @@
+package client
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/lib/tags"
+	"github.com/Symantec/Dominator/proto/imageserver"
+)
+
+func changeImageLabels(client *srpc.Client, name string,
+	labelsToSet tags.Tags, labelsToDelete []string) error {
+	request := imageserver.ChangeImageLabelsRequest{
+		ImageName:      name,
+		LabelsToSet:    labelsToSet,
+		LabelsToDelete: labelsToDelete,
+	}
+	var reply imageserver.ChangeImageLabelsResponse
+	return client.RequestReply("ImageServer.ChangeImageLabels", request,
+		&reply)
+}
@@ -0,0 +1,20 @@
+package client
+
+import (
+	"github.com/Symantec/Dominator/lib/image"
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/imageserver"
+)
+
+func diffImages(client *srpc.Client, left, right string) (*image.Diff, error) {
+	request := imageserver.DiffImagesRequest{
+		LeftImageName:  left,
+		RightImageName: right,
+	}
+	var reply imageserver.DiffImagesResponse
+	err := client.RequestReply("ImageServer.DiffImages", request, &reply)
+	if err != nil {
+		return nil, err
+	}
+	return reply.Diff, nil
+}
@@ -0,0 +1,16 @@
+package client
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/imageserver"
+)
+
+func findImages(client *srpc.Client,
+	request imageserver.FindImagesRequest) ([]string, error) {
+	var reply imageserver.FindImagesResponse
+	err := client.RequestReply("ImageServer.FindImages", request, &reply)
+	if err != nil {
+		return nil, err
+	}
+	return reply.ImageNames, nil
+}
@@ -0,0 +1,18 @@
+package client
+
+import (
+	"github.com/Symantec/Dominator/lib/image"
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/imageserver"
+)
+
+func setRetentionPolicy(client *srpc.Client, dirname string,
+	policy image.RetentionPolicy) error {
+	request := imageserver.SetRetentionPolicyRequest{
+		DirectoryName: dirname,
+		Policy:        policy,
+	}
+	var reply imageserver.SetRetentionPolicyResponse
+	return client.RequestReply("ImageServer.SetRetentionPolicy", request,
+		&reply)
+}
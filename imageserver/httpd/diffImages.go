@@ -0,0 +1,40 @@
+package httpd
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+
+	"github.com/Symantec/Dominator/lib/json"
+	"github.com/Symantec/Dominator/lib/url"
+)
+
+func (s state) diffImagesHandler(w http.ResponseWriter, req *http.Request) {
+	parsedQuery := url.ParseQuery(req.URL)
+	left := parsedQuery.Table["left"]
+	right := parsedQuery.Table["right"]
+	if left == "" || right == "" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintln(w, "left and right images must be specified")
+		return
+	}
+	if s.imageDataBase.GetImage(left) == nil ||
+		s.imageDataBase.GetImage(right) == nil {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	diff, err := s.imageDataBase.DiffImages(left, right)
+	if err != nil {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintln(w, err)
+		return
+	}
+	writer := bufio.NewWriter(w)
+	defer writer.Flush()
+	if err := json.WriteWithIndent(writer, "    ", diff); err != nil {
+		fmt.Fprintln(writer, err)
+	}
+}
@@ -33,6 +33,7 @@ func (s state) listDirectoriesHandler(w http.ResponseWriter,
 	fmt.Fprintln(writer, "  <tr>")
 	fmt.Fprintln(writer, "    <th>Name</th>")
 	fmt.Fprintln(writer, "    <th>Owner Group</th>")
+	fmt.Fprintln(writer, "    <th>Retention Policy</th>")
 	fmt.Fprintln(writer, "  </tr>")
 	for _, directory := range directories {
 		showDirectory(writer, directory)
@@ -45,5 +46,7 @@ func showDirectory(writer io.Writer, directory image.Directory) {
 	fmt.Fprintf(writer, "  <tr>\n")
 	fmt.Fprintf(writer, "    <td>%s</td>\n", directory.Name)
 	fmt.Fprintf(writer, "    <td>%s</td>\n", directory.Metadata.OwnerGroup)
+	fmt.Fprintf(writer, "    <td>%s</td>\n",
+		directory.Metadata.RetentionPolicy)
 	fmt.Fprintf(writer, "  </tr>\n")
 }
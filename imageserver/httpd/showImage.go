@@ -3,8 +3,10 @@ package httpd
 import (
 	"bufio"
 	"fmt"
+	"html"
 	"io"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/Symantec/Dominator/lib/format"
@@ -70,6 +72,18 @@ func (s state) showImageHandler(w http.ResponseWriter, req *http.Request) {
 			image.CreatedOn.In(time.Local).Format(timeFormat),
 			format.Duration(time.Since(image.CreatedOn)))
 	}
+	if len(image.Labels) > 0 {
+		keys := make([]string, 0, len(image.Labels))
+		for key := range image.Labels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fmt.Fprintln(writer, "Labels:<br>")
+		for _, key := range keys {
+			fmt.Fprintf(writer, "&nbsp;&nbsp;%s=%s<br>\n",
+				html.EscapeString(key), html.EscapeString(image.Labels[key]))
+		}
+	}
 	if len(image.Packages) > 0 {
 		fmt.Fprintf(writer,
 			"Packages: <a href=\"listPackages?%s\">%d</a><br>\n",
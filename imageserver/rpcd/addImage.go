@@ -14,6 +14,7 @@ func (t *srpcType) AddImage(conn *srpc.Conn,
 	reply *imageserver.AddImageResponse) error {
 	request.Image.CreatedBy = conn.Username() // Must always set this field.
 	request.Image.CreatedOn = time.Now()      // Must always set this field.
+	request.Image.Signatures = nil            // Use AddImageSignature().
 	return t.AddImageTrusted(conn, request, reply)
 }
 
@@ -0,0 +1,41 @@
+package rpcd
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/image"
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/imageserver"
+)
+
+func (t *srpcType) AddImageSignature(conn *srpc.Conn,
+	request imageserver.AddImageSignatureRequest,
+	reply *imageserver.AddImageSignatureResponse) error {
+	if err := t.checkMutability(); err != nil {
+		return err
+	}
+	img := t.imageDataBase.GetImage(request.ImageName)
+	if img == nil {
+		return errors.New("image does not exist")
+	}
+	digest, err := img.Digest()
+	if err != nil {
+		return err
+	}
+	signature := request.Signature
+	if err := signature.Verify(digest); err != nil {
+		return err
+	}
+	signature.SignedBy = conn.Username() // Must always set this field.
+	signature.SignedOn = time.Now()      // Must always set this field.
+	if signature.SignedBy == "" {
+		t.logger.Printf("AddImageSignature(%s) key: %s\n",
+			request.ImageName, signature.KeyFingerprint())
+	} else {
+		t.logger.Printf("AddImageSignature(%s) key: %s by %s\n",
+			request.ImageName, signature.KeyFingerprint(), signature.SignedBy)
+	}
+	return t.imageDataBase.AddImageSignatures(request.ImageName,
+		[]image.Signature{signature})
+}
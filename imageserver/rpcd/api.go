@@ -60,6 +60,8 @@ func Setup(imdb *scanner.ImageDataBase, replicationMaster string,
 		PublicMethods: []string{
 			"CheckDirectory",
 			"CheckImage",
+			"DiffImages",
+			"FindImages",
 			"FindLatestImage",
 			"ListDirectories",
 			"ListImages",
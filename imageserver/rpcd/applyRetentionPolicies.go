@@ -0,0 +1,30 @@
+package rpcd
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/imageserver"
+)
+
+func (t *srpcType) ApplyRetentionPolicies(conn *srpc.Conn,
+	request imageserver.ApplyRetentionPoliciesRequest,
+	reply *imageserver.ApplyRetentionPoliciesResponse) error {
+	if request.DryRun {
+		reply.ImageNames = t.imageDataBase.ListRetentionDeletions(
+			request.DirectoryName)
+		return nil
+	}
+	if err := t.checkMutability(); err != nil {
+		return err
+	}
+	username := conn.Username()
+	if username == "" {
+		t.logger.Printf("ApplyRetentionPolicies(%s)\n", request.DirectoryName)
+	} else {
+		t.logger.Printf("ApplyRetentionPolicies(%s) by %s\n",
+			request.DirectoryName, username)
+	}
+	imageNames, err := t.imageDataBase.ApplyRetentionPolicies(
+		request.DirectoryName, &username)
+	reply.ImageNames = imageNames
+	return err
+}
@@ -0,0 +1,23 @@
+package rpcd
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/imageserver"
+)
+
+func (t *srpcType) ChangeImageLabels(conn *srpc.Conn,
+	request imageserver.ChangeImageLabelsRequest,
+	reply *imageserver.ChangeImageLabelsResponse) error {
+	if err := t.checkMutability(); err != nil {
+		return err
+	}
+	username := conn.Username()
+	if username == "" {
+		t.logger.Printf("ChangeImageLabels(%s)\n", request.ImageName)
+	} else {
+		t.logger.Printf("ChangeImageLabels(%s) by %s\n",
+			request.ImageName, username)
+	}
+	return t.imageDataBase.ChangeImageLabels(request.ImageName,
+		request.LabelsToSet, request.LabelsToDelete, &username)
+}
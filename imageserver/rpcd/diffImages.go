@@ -0,0 +1,18 @@
+package rpcd
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/imageserver"
+)
+
+func (t *srpcType) DiffImages(conn *srpc.Conn,
+	request imageserver.DiffImagesRequest,
+	reply *imageserver.DiffImagesResponse) error {
+	diff, err := t.imageDataBase.DiffImages(request.LeftImageName,
+		request.RightImageName)
+	if err != nil {
+		return err
+	}
+	reply.Diff = diff
+	return nil
+}
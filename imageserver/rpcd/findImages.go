@@ -0,0 +1,21 @@
+package rpcd
+
+import (
+	"github.com/Symantec/Dominator/imageserver/scanner"
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/imageserver"
+)
+
+func (t *srpcType) FindImages(conn *srpc.Conn,
+	request imageserver.FindImagesRequest,
+	reply *imageserver.FindImagesResponse) error {
+	reply.ImageNames = t.imageDataBase.FindImages(scanner.ImageQuery{
+		DirectoryName:  request.DirectoryName,
+		Labels:         request.Labels,
+		PackageName:    request.PackageName,
+		PackageVersion: request.PackageVersion,
+		CreatedAfter:   request.CreatedAfter,
+		CreatedBefore:  request.CreatedBefore,
+	})
+	return nil
+}
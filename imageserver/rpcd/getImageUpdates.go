@@ -16,9 +16,13 @@ func (t *srpcType) GetImageUpdates(conn *srpc.Conn, decoder srpc.Decoder,
 	addChannel := t.imageDataBase.RegisterAddNotifier()
 	deleteChannel := t.imageDataBase.RegisterDeleteNotifier()
 	mkdirChannel := t.imageDataBase.RegisterMakeDirectoryNotifier()
+	signChannel := t.imageDataBase.RegisterSignNotifier()
+	labelChannel := t.imageDataBase.RegisterLabelNotifier()
 	defer t.imageDataBase.UnregisterAddNotifier(addChannel)
 	defer t.imageDataBase.UnregisterDeleteNotifier(deleteChannel)
 	defer t.imageDataBase.UnregisterMakeDirectoryNotifier(mkdirChannel)
+	defer t.imageDataBase.UnregisterSignNotifier(signChannel)
+	defer t.imageDataBase.UnregisterLabelNotifier(labelChannel)
 	directories := t.imageDataBase.ListDirectories()
 	image.SortDirectories(directories)
 	for _, directory := range directories {
@@ -37,6 +41,16 @@ func (t *srpcType) GetImageUpdates(conn *srpc.Conn, decoder srpc.Decoder,
 			t.logger.Println(err)
 			return err
 		}
+		// The client may have had the image before it was signed or
+		// labelled.
+		if err := t.sendSignatures(encoder, imageName); err != nil {
+			t.logger.Println(err)
+			return err
+		}
+		if err := t.sendLabels(encoder, imageName); err != nil {
+			t.logger.Println(err)
+			return err
+		}
 	}
 	// Signal end of initial image list.
 	if err := encoder.Encode(imageserver.ImageUpdate{}); err != nil {
@@ -69,6 +83,16 @@ func (t *srpcType) GetImageUpdates(conn *srpc.Conn, decoder srpc.Decoder,
 				t.logger.Println(err)
 				return err
 			}
+		case imageName := <-signChannel:
+			if err := t.sendSignatures(encoder, imageName); err != nil {
+				t.logger.Println(err)
+				return err
+			}
+		case imageName := <-labelChannel:
+			if err := t.sendLabels(encoder, imageName); err != nil {
+				t.logger.Println(err)
+				return err
+			}
 		case err := <-closeChannel:
 			if err == nil {
 				t.logger.Printf("Image replication client disconnected: %s\n",
@@ -95,6 +119,33 @@ func (t *srpcType) incrementNumReplicationClients(increment bool) {
 	}
 }
 
+func (t *srpcType) sendSignatures(encoder srpc.Encoder,
+	name string) error {
+	img := t.imageDataBase.GetImage(name)
+	if img == nil || len(img.Signatures) < 1 {
+		return nil
+	}
+	return encoder.Encode(imageserver.ImageUpdate{
+		Name:       name,
+		Operation:  imageserver.OperationAddImageSignatures,
+		Signatures: img.Signatures,
+	})
+}
+
+// sendLabels sends all the labels, even if there are none, so that the client
+// can remove deleted labels.
+func (t *srpcType) sendLabels(encoder srpc.Encoder, name string) error {
+	img := t.imageDataBase.GetImage(name)
+	if img == nil {
+		return nil
+	}
+	return encoder.Encode(imageserver.ImageUpdate{
+		Name:      name,
+		Operation: imageserver.OperationSetImageLabels,
+		Labels:    img.Labels,
+	})
+}
+
 func sendUpdate(encoder srpc.Encoder, name string, operation uint) error {
 	imageUpdate := imageserver.ImageUpdate{Name: name, Operation: operation}
 	return encoder.Encode(imageUpdate)
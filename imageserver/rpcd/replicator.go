@@ -104,6 +104,26 @@ func (t *srpcType) getUpdates(conn *srpc.Conn) error {
 			if err := t.imageDataBase.UpdateDirectory(*directory); err != nil {
 				return err
 			}
+		case imageserver.OperationAddImageSignatures:
+			if !t.imageDataBase.CheckImage(imageUpdate.Name) {
+				continue // May have expired or not be archived.
+			}
+			err := t.imageDataBase.AddImageSignatures(imageUpdate.Name,
+				imageUpdate.Signatures)
+			if err != nil {
+				t.logger.Printf("Replicator(%s): error adding signatures: %s\n",
+					imageUpdate.Name, err)
+			}
+		case imageserver.OperationSetImageLabels:
+			if !t.imageDataBase.CheckImage(imageUpdate.Name) {
+				continue // May have expired or not be archived.
+			}
+			err := t.imageDataBase.SetImageLabels(imageUpdate.Name,
+				imageUpdate.Labels)
+			if err != nil {
+				t.logger.Printf("Replicator(%s): error setting labels: %s\n",
+					imageUpdate.Name, err)
+			}
 		}
 	}
 }
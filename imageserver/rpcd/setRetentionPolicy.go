@@ -0,0 +1,24 @@
+package rpcd
+
+import (
+	"errors"
+
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/imageserver"
+)
+
+func (t *srpcType) SetRetentionPolicy(conn *srpc.Conn,
+	request imageserver.SetRetentionPolicyRequest,
+	reply *imageserver.SetRetentionPolicyResponse) error {
+	if err := t.checkMutability(); err != nil {
+		return err
+	}
+	username := conn.Username()
+	if username == "" {
+		return errors.New("no username: unauthenticated connection")
+	}
+	t.logger.Printf("SetRetentionPolicy(%s) to: \"%s\" by %s\n",
+		request.DirectoryName, request.Policy, username)
+	return t.imageDataBase.SetRetentionPolicy(request.DirectoryName,
+		request.Policy, username)
+}
@@ -4,12 +4,14 @@ import (
 	"flag"
 	"io"
 	"sync"
+	"time"
 
 	"github.com/Symantec/Dominator/lib/hash"
 	"github.com/Symantec/Dominator/lib/image"
 	"github.com/Symantec/Dominator/lib/log"
 	"github.com/Symantec/Dominator/lib/objectserver"
 	"github.com/Symantec/Dominator/lib/stringutil"
+	"github.com/Symantec/Dominator/lib/tags"
 )
 
 // TODO: the types should probably be moved into a separate package, leaving
@@ -23,8 +25,22 @@ var (
 		"maximum number of bytes of unreferenced objects before cleaning")
 	imageServerMaxUnrefAge = flag.Duration("imageServerMaxUnrefAge", 0,
 		"maximum age of unreferenced objects before cleaning")
+	mdbFile = flag.String("mdbFile", "",
+		"File to read MDB data from, for retention policies which keep MDB images")
+	retentionPolicyInterval = flag.Duration("retentionPolicyInterval", 0,
+		"Interval between applying directory retention policies. If zero, policies are only applied on request")
 )
 
+// ImageQuery specifies criteria for FindImages. Empty fields match all images.
+type ImageQuery struct {
+	DirectoryName  string    // Includes sub-directories.
+	Labels         tags.Tags // Images must have all of these labels.
+	PackageName    string
+	PackageVersion string // Ignored if PackageName is empty.
+	CreatedAfter   time.Time
+	CreatedBefore  time.Time
+}
+
 type notifiers map[<-chan string]chan<- string
 type makeDirectoryNotifiers map[<-chan image.Directory]chan<- image.Directory
 
@@ -37,11 +53,15 @@ type ImageDataBase struct {
 	addNotifiers        notifiers
 	deleteNotifiers     notifiers
 	mkdirNotifiers      makeDirectoryNotifiers
+	signNotifiers       notifiers
+	labelNotifiers      notifiers
 	unreferencedObjects *unreferencedObjectsList
 	// Unprotected by main lock.
 	deduperLock      sync.Mutex
 	deduper          *stringutil.StringDeduplicator
 	pendingImageLock sync.Mutex
+	mdbLock          sync.Mutex
+	mdbImages        map[string]struct{} // nil: MDB not available.
 	// Unprotected by any lock.
 	objectServer      objectserver.FullObjectServer
 	replicationMaster string
@@ -58,6 +78,31 @@ func (imdb *ImageDataBase) AddImage(image *image.Image, name string,
 	return imdb.addImage(image, name, username)
 }
 
+// AddImageSignatures will add signatures to an image, ignoring those which the
+// image already has. The updated image is saved and replicated.
+func (imdb *ImageDataBase) AddImageSignatures(name string,
+	signatures []image.Signature) error {
+	return imdb.addImageSignatures(name, signatures)
+}
+
+// ApplyRetentionPolicies will delete the images in dirname and its
+// sub-directories which the directory retention policies do not keep. If
+// dirname is empty, all directories are processed. The names of the deleted
+// images are returned. If username is not nil, the user must have permission
+// to delete the images. An error deleting one image does not stop the others
+// from being deleted.
+func (imdb *ImageDataBase) ApplyRetentionPolicies(dirname string,
+	username *string) ([]string, error) {
+	return imdb.applyRetentionPolicies(dirname, username)
+}
+
+// ChangeImageLabels will set and then delete labels for an image. If username
+// is not nil, the user must be a member of the owner group of the directory.
+func (imdb *ImageDataBase) ChangeImageLabels(name string,
+	labelsToSet tags.Tags, labelsToDelete []string, username *string) error {
+	return imdb.changeImageLabels(name, labelsToSet, labelsToDelete, username)
+}
+
 func (imdb *ImageDataBase) CheckDirectory(name string) bool {
 	return imdb.checkDirectory(name)
 }
@@ -92,11 +137,23 @@ func (imdb *ImageDataBase) DeleteUnreferencedObjects(percentage uint8,
 	return imdb.deleteUnreferencedObjects(percentage, bytes)
 }
 
+// DiffImages will return the differences between the left and right images,
+// from the point of view of a sub moving from the left to the right image.
+func (imdb *ImageDataBase) DiffImages(left, right string) (*image.Diff, error) {
+	return imdb.diffImages(left, right)
+}
+
 func (imdb *ImageDataBase) DoWithPendingImage(image *image.Image,
 	doFunc func() error) error {
 	return imdb.doWithPendingImage(image, doFunc)
 }
 
+// FindImages will return the names of the images which match all the criteria
+// in the query, sorted by creation time (oldest first).
+func (imdb *ImageDataBase) FindImages(query ImageQuery) []string {
+	return imdb.findImages(query)
+}
+
 func (imdb *ImageDataBase) FindLatestImage(dirame string,
 	ignoreExpiring bool) (string, error) {
 	return imdb.findLatestImage(dirame, ignoreExpiring)
@@ -123,6 +180,12 @@ func (imdb *ImageDataBase) ListImages() []string {
 // Note that some objects may have been recently added and the referencing image
 // may not yet be present (i.e. it may be added after missing objects are
 // uploaded).
+// ListRetentionDeletions will return the names of the images which
+// ApplyRetentionPolicies would delete. Nothing is deleted.
+func (imdb *ImageDataBase) ListRetentionDeletions(dirname string) []string {
+	return imdb.listRetentionDeletions(dirname)
+}
+
 func (imdb *ImageDataBase) ListUnreferencedObjects() map[hash.Hash]uint64 {
 	return imdb.listUnreferencedObjects()
 }
@@ -147,6 +210,26 @@ func (imdb *ImageDataBase) RegisterMakeDirectoryNotifier() <-chan image.Director
 	return imdb.registerMakeDirectoryNotifier()
 }
 
+func (imdb *ImageDataBase) RegisterLabelNotifier() <-chan string {
+	return imdb.registerLabelNotifier()
+}
+
+func (imdb *ImageDataBase) RegisterSignNotifier() <-chan string {
+	return imdb.registerSignNotifier()
+}
+
+// SetImageLabels will replace all the labels for an image.
+func (imdb *ImageDataBase) SetImageLabels(name string, labels tags.Tags) error {
+	return imdb.setImageLabels(name, labels)
+}
+
+// SetRetentionPolicy will set the retention policy for a directory. The user
+// must be a member of the owner group of the directory.
+func (imdb *ImageDataBase) SetRetentionPolicy(dirname string,
+	policy image.RetentionPolicy, username string) error {
+	return imdb.setRetentionPolicy(dirname, policy, username)
+}
+
 func (imdb *ImageDataBase) UnregisterAddNotifier(channel <-chan string) {
 	imdb.unregisterAddNotifier(channel)
 }
@@ -155,6 +238,14 @@ func (imdb *ImageDataBase) UnregisterDeleteNotifier(channel <-chan string) {
 	imdb.unregisterDeleteNotifier(channel)
 }
 
+func (imdb *ImageDataBase) UnregisterLabelNotifier(channel <-chan string) {
+	imdb.unregisterLabelNotifier(channel)
+}
+
+func (imdb *ImageDataBase) UnregisterSignNotifier(channel <-chan string) {
+	imdb.unregisterSignNotifier(channel)
+}
+
 func (imdb *ImageDataBase) UnregisterMakeDirectoryNotifier(
 	channel <-chan image.Directory) {
 	imdb.unregisterMakeDirectoryNotifier(channel)
@@ -2,13 +2,17 @@ package scanner
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/gob"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/user"
 	"path"
+	"sort"
+	"strings"
 	"syscall"
 	"time"
 
@@ -16,6 +20,7 @@ import (
 	"github.com/Symantec/Dominator/lib/hash"
 	"github.com/Symantec/Dominator/lib/image"
 	"github.com/Symantec/Dominator/lib/log"
+	"github.com/Symantec/Dominator/lib/tags"
 )
 
 const (
@@ -83,6 +88,158 @@ func (imdb *ImageDataBase) addImage(image *image.Image, name string,
 	}
 }
 
+func (imdb *ImageDataBase) addImageSignatures(name string,
+	signatures []image.Signature) error {
+	return imdb.modifyImage(name, imdb.signNotifiers, "sign",
+		func(oldImage *image.Image) (*image.Image, error) {
+			newSignatures := make([]image.Signature, len(oldImage.Signatures),
+				len(oldImage.Signatures)+len(signatures))
+			copy(newSignatures, oldImage.Signatures)
+			for _, signature := range signatures {
+				if !hasSignature(newSignatures, signature) {
+					newSignatures = append(newSignatures, signature)
+				}
+			}
+			if len(newSignatures) == len(oldImage.Signatures) {
+				return nil, nil
+			}
+			newImage := *oldImage
+			newImage.Signatures = newSignatures
+			return &newImage, nil
+		})
+}
+
+func (imdb *ImageDataBase) changeImageLabels(name string, labelsToSet tags.Tags,
+	labelsToDelete []string, username *string) error {
+	return imdb.modifyImage(name, imdb.labelNotifiers, "label",
+		func(oldImage *image.Image) (*image.Image, error) {
+			err := imdb.checkDirectoryPermissions(path.Dir(name), username)
+			if err != nil {
+				return nil, err
+			}
+			labels := oldImage.Labels.Copy()
+			labels.Merge(labelsToSet)
+			for _, key := range labelsToDelete {
+				delete(labels, key)
+			}
+			return withLabels(oldImage, labels), nil
+		})
+}
+
+func (imdb *ImageDataBase) setImageLabels(name string,
+	labels tags.Tags) error {
+	return imdb.modifyImage(name, imdb.labelNotifiers, "label",
+		func(oldImage *image.Image) (*image.Image, error) {
+			return withLabels(oldImage, labels), nil
+		})
+}
+
+// withLabels returns a copy of image with the specified labels, or nil if the
+// labels are unchanged.
+func withLabels(oldImage *image.Image, labels tags.Tags) *image.Image {
+	if len(labels) < 1 {
+		labels = nil
+	}
+	if labels.Equal(oldImage.Labels) {
+		return nil
+	}
+	newImage := *oldImage
+	newImage.Labels = labels
+	return &newImage
+}
+
+// modifyImage will save and replace an existing image with the image returned
+// by modifyFunc, which is called with the lock held. If modifyFunc returns nil
+// the image is unchanged. The image is replaced rather than modified, since it
+// may be being sent to a client without the lock held. The new image is written
+// without the lock held, so that a slow write does not block other users. If
+// the image was replaced meanwhile, modifyFunc is called again.
+func (imdb *ImageDataBase) modifyImage(name string, notifiers notifiers,
+	operation string,
+	modifyFunc func(oldImage *image.Image) (*image.Image, error)) error {
+	filename := path.Join(imdb.baseDir, name)
+	for {
+		imdb.Lock()
+		oldImage, ok := imdb.imageMap[name]
+		if !ok {
+			imdb.Unlock()
+			return errors.New("image: " + name + " does not exist")
+		}
+		newImage, err := modifyFunc(oldImage)
+		imdb.Unlock()
+		if err != nil || newImage == nil {
+			return err
+		}
+		tmpFilename, err := writeImage(filename, newImage)
+		if err != nil {
+			return err
+		}
+		imdb.Lock()
+		if imdb.imageMap[name] != oldImage {
+			imdb.Unlock()
+			os.Remove(tmpFilename)
+			continue
+		}
+		if err := os.Rename(tmpFilename, filename); err != nil {
+			imdb.Unlock()
+			os.Remove(tmpFilename)
+			return err
+		}
+		imdb.imageMap[name] = newImage
+		notifiers.sendPlain(name, operation, imdb.logger)
+		imdb.Unlock()
+		return nil
+	}
+}
+
+func hasSignature(signatures []image.Signature,
+	signature image.Signature) bool {
+	for _, existing := range signatures {
+		if bytes.Equal(existing.PublicKey, signature.PublicKey) &&
+			bytes.Equal(existing.Signature, signature.Signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeImage will write an image to a temporary file which may be renamed to
+// filename to safely replace an existing image file. The temporary file is
+// hidden so that it is ignored when loading. The name of the temporary file is
+// returned.
+func writeImage(filename string, image *image.Image) (string, error) {
+	file, err := ioutil.TempFile(path.Dir(filename),
+		"."+path.Base(filename)+"~")
+	if err != nil {
+		return "", err
+	}
+	tmpFilename := file.Name()
+	if err := writeImageToFile(file, image); err != nil {
+		os.Remove(tmpFilename)
+		return "", err
+	}
+	return tmpFilename, nil
+}
+
+func writeImageToFile(file *os.File, image *image.Image) error {
+	defer file.Close()
+	if err := file.Chmod(filePerms); err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	writer := fsutil.NewChecksumWriter(w)
+	if err := gob.NewEncoder(writer).Encode(image); err != nil {
+		return err
+	}
+	if err := writer.WriteChecksum(); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
 // This must be called with the lock held.
 func (imdb *ImageDataBase) checkDirectoryPermissions(dirname string,
 	username *string) error {
@@ -242,6 +399,20 @@ func (imdb *ImageDataBase) deleteUnreferencedObjects(percentage uint8,
 	return nil
 }
 
+func (imdb *ImageDataBase) diffImages(left, right string) (*image.Diff, error) {
+	imdb.RLock()
+	leftImage := imdb.imageMap[left]
+	rightImage := imdb.imageMap[right]
+	imdb.RUnlock()
+	if leftImage == nil {
+		return nil, errors.New("image: " + left + " does not exist")
+	}
+	if rightImage == nil {
+		return nil, errors.New("image: " + right + " does not exist")
+	}
+	return leftImage.Diff(rightImage)
+}
+
 func (imdb *ImageDataBase) doWithPendingImage(image *image.Image,
 	doFunc func() error) error {
 	imdb.pendingImageLock.Lock()
@@ -289,6 +460,80 @@ func (imdb *ImageDataBase) findLatestImage(dirname string,
 	return imageName, nil
 }
 
+type foundImage struct {
+	name      string
+	createdOn time.Time
+}
+
+type foundImageList []foundImage
+
+func (list foundImageList) Len() int {
+	return len(list)
+}
+
+// Oldest first.
+func (list foundImageList) Less(i, j int) bool {
+	return list[i].createdOn.Before(list[j].createdOn)
+}
+
+func (list foundImageList) Swap(i, j int) {
+	list[i], list[j] = list[j], list[i]
+}
+
+func (imdb *ImageDataBase) findImages(query ImageQuery) []string {
+	if query.DirectoryName != "" {
+		query.DirectoryName = path.Clean(query.DirectoryName)
+	}
+	imdb.RLock()
+	defer imdb.RUnlock()
+	var images foundImageList
+	for name, img := range imdb.imageMap {
+		if imageMatchesQuery(name, img, query) {
+			images = append(images, foundImage{name, img.CreatedOn})
+		}
+	}
+	sort.Sort(images)
+	names := make([]string, 0, len(images))
+	for _, img := range images {
+		names = append(names, img.name)
+	}
+	return names
+}
+
+func imageMatchesQuery(name string, img *image.Image,
+	query ImageQuery) bool {
+	if dirname := query.DirectoryName; dirname != "" && dirname != "." {
+		if !strings.HasPrefix(name, dirname+"/") {
+			return false
+		}
+	}
+	for key, value := range query.Labels {
+		if labelValue, ok := img.Labels[key]; !ok || labelValue != value {
+			return false
+		}
+	}
+	if !query.CreatedAfter.IsZero() &&
+		!img.CreatedOn.After(query.CreatedAfter) {
+		return false
+	}
+	if !query.CreatedBefore.IsZero() &&
+		!img.CreatedOn.Before(query.CreatedBefore) {
+		return false
+	}
+	if query.PackageName == "" {
+		return true
+	}
+	for _, pkg := range img.Packages {
+		if pkg.Name != query.PackageName {
+			continue
+		}
+		if query.PackageVersion == "" || pkg.Version == query.PackageVersion {
+			return true
+		}
+	}
+	return false
+}
+
 func (imdb *ImageDataBase) getImage(name string) *image.Image {
 	imdb.RLock()
 	defer imdb.RUnlock()
@@ -411,6 +656,22 @@ func (imdb *ImageDataBase) registerDeleteNotifier() <-chan string {
 	return channel
 }
 
+func (imdb *ImageDataBase) registerLabelNotifier() <-chan string {
+	channel := make(chan string, 1)
+	imdb.Lock()
+	defer imdb.Unlock()
+	imdb.labelNotifiers[channel] = channel
+	return channel
+}
+
+func (imdb *ImageDataBase) registerSignNotifier() <-chan string {
+	channel := make(chan string, 1)
+	imdb.Lock()
+	defer imdb.Unlock()
+	imdb.signNotifiers[channel] = channel
+	return channel
+}
+
 func (imdb *ImageDataBase) registerMakeDirectoryNotifier() <-chan image.Directory {
 	channel := make(chan image.Directory, 1)
 	imdb.Lock()
@@ -431,6 +692,18 @@ func (imdb *ImageDataBase) unregisterDeleteNotifier(channel <-chan string) {
 	delete(imdb.deleteNotifiers, channel)
 }
 
+func (imdb *ImageDataBase) unregisterLabelNotifier(channel <-chan string) {
+	imdb.Lock()
+	defer imdb.Unlock()
+	delete(imdb.labelNotifiers, channel)
+}
+
+func (imdb *ImageDataBase) unregisterSignNotifier(channel <-chan string) {
+	imdb.Lock()
+	defer imdb.Unlock()
+	delete(imdb.signNotifiers, channel)
+}
+
 func (imdb *ImageDataBase) unregisterMakeDirectoryNotifier(
 	channel <-chan image.Directory) {
 	imdb.Lock()
@@ -0,0 +1,165 @@
+package scanner
+
+import (
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/fsutil"
+	"github.com/Symantec/Dominator/lib/image"
+	"github.com/Symantec/Dominator/lib/log/testlogger"
+	"github.com/Symantec/Dominator/lib/tags"
+)
+
+func TestFindImages(t *testing.T) {
+	now := time.Now()
+	imdb := &ImageDataBase{
+		imageMap: map[string]*image.Image{
+			"app/1": {
+				CreatedOn: now.Add(-3 * time.Hour),
+				Labels:    tags.Tags{"branch": "main"},
+				Packages:  []image.Package{{Name: "nginx", Version: "1.0"}},
+			},
+			"app/2": {
+				CreatedOn: now.Add(-2 * time.Hour),
+				Labels:    tags.Tags{"branch": "test"},
+				Packages:  []image.Package{{Name: "nginx", Version: "1.1"}},
+			},
+			"app/sub/3": {
+				CreatedOn: now.Add(-time.Hour),
+				Labels:    tags.Tags{"branch": "main", "tested": "yes"},
+			},
+			"application/4": {
+				CreatedOn: now.Add(-4 * time.Hour),
+				Labels:    tags.Tags{"branch": "main"},
+			},
+		},
+	}
+	var tests = []struct {
+		name     string
+		query    ImageQuery
+		expected []string
+	}{
+		{
+			name: "all",
+			expected: []string{"application/4", "app/1", "app/2",
+				"app/sub/3"},
+		},
+		{
+			name:     "directory",
+			query:    ImageQuery{DirectoryName: "app/"},
+			expected: []string{"app/1", "app/2", "app/sub/3"},
+		},
+		{
+			name:     "label",
+			query:    ImageQuery{Labels: tags.Tags{"branch": "main"}},
+			expected: []string{"application/4", "app/1", "app/sub/3"},
+		},
+		{
+			name: "labels",
+			query: ImageQuery{
+				Labels: tags.Tags{"branch": "main", "tested": "yes"},
+			},
+			expected: []string{"app/sub/3"},
+		},
+		{
+			name:     "package",
+			query:    ImageQuery{PackageName: "nginx"},
+			expected: []string{"app/1", "app/2"},
+		},
+		{
+			name: "package version",
+			query: ImageQuery{
+				PackageName:    "nginx",
+				PackageVersion: "1.1",
+			},
+			expected: []string{"app/2"},
+		},
+		{
+			name: "created",
+			query: ImageQuery{
+				CreatedAfter:  now.Add(-150 * time.Minute),
+				CreatedBefore: now,
+			},
+			expected: []string{"app/2", "app/sub/3"},
+		},
+		{
+			name:     "no match",
+			query:    ImageQuery{PackageName: "apache"},
+			expected: []string{},
+		},
+	}
+	for _, test := range tests {
+		names := imdb.findImages(test.query)
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("%s: found: %v, expected: %v",
+				test.name, names, test.expected)
+		}
+	}
+}
+
+func TestModifyImageAfterConcurrentChange(t *testing.T) {
+	baseDir, err := ioutil.TempDir("", "imdb_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+	imdb := &ImageDataBase{
+		baseDir: baseDir,
+		imageMap: map[string]*image.Image{
+			"image": {Labels: tags.Tags{"branch": "main"}},
+		},
+		logger: testlogger.New(t),
+	}
+	numCalls := 0
+	err = imdb.modifyImage("image", nil, "label",
+		func(oldImage *image.Image) (*image.Image, error) {
+			numCalls++
+			if numCalls == 1 {
+				// Replace the image while the new image is being written.
+				imdb.imageMap["image"] = &image.Image{
+					Labels: tags.Tags{"branch": "test"},
+				}
+			}
+			labels := oldImage.Labels.Copy()
+			labels["tested"] = "yes"
+			return withLabels(oldImage, labels), nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if numCalls != 2 {
+		t.Errorf("modify function called: %d times, expected: 2", numCalls)
+	}
+	expected := tags.Tags{"branch": "test", "tested": "yes"}
+	if labels := imdb.imageMap["image"].Labels; !labels.Equal(expected) {
+		t.Errorf("labels: %v, expected: %v", labels, expected)
+	}
+	names, err := ioutil.ReadDir(baseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0].Name() != "image" {
+		t.Errorf("files: %v", names)
+	}
+	file, err := os.Open(path.Join(baseDir, "image"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	reader := fsutil.NewChecksumReader(file)
+	var savedImage image.Image
+	if err := gob.NewDecoder(reader).Decode(&savedImage); err != nil {
+		t.Fatal(err)
+	}
+	if err := reader.VerifyChecksum(); err != nil {
+		t.Fatal(err)
+	}
+	if !savedImage.Labels.Equal(expected) {
+		t.Errorf("saved labels: %v, expected: %v", savedImage.Labels, expected)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/Symantec/Dominator/lib/log"
 	"github.com/Symantec/Dominator/lib/log/logutil"
 	"github.com/Symantec/Dominator/lib/log/prefixlogger"
+	"github.com/Symantec/Dominator/lib/mdb/mdbd"
 	"github.com/Symantec/Dominator/lib/objectserver"
 	objectclient "github.com/Symantec/Dominator/lib/objectserver/client"
 	"github.com/Symantec/Dominator/lib/srpc"
@@ -40,6 +41,8 @@ func loadImageDataBase(baseDir string, objSrv objectserver.FullObjectServer,
 		addNotifiers:      make(notifiers),
 		deleteNotifiers:   make(notifiers),
 		mkdirNotifiers:    make(makeDirectoryNotifiers),
+		signNotifiers:     make(notifiers),
+		labelNotifiers:    make(notifiers),
 		deduper:           stringutil.NewStringDeduplicator(false),
 		objectServer:      objSrv,
 		replicationMaster: replicationMaster,
@@ -83,6 +86,10 @@ func loadImageDataBase(baseDir string, objSrv objectserver.FullObjectServer,
 		gcs.SetGarbageCollector(imdb.garbageCollector)
 	}
 	go imdb.periodicGarbageCollector()
+	if *mdbFile != "" {
+		go imdb.watchMdb(mdbd.StartMdbDaemon(*mdbFile, logger))
+	}
+	go imdb.periodicRetentionEnforcer()
 	return imdb, nil
 }
 
@@ -0,0 +1,166 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/image"
+	"github.com/Symantec/Dominator/lib/mdb"
+)
+
+type retentionCandidate struct {
+	name      string
+	createdOn time.Time
+}
+
+type retentionCandidateList []retentionCandidate
+
+func (list retentionCandidateList) Len() int {
+	return len(list)
+}
+
+// Newest first.
+func (list retentionCandidateList) Less(i, j int) bool {
+	return list[i].createdOn.After(list[j].createdOn)
+}
+
+func (list retentionCandidateList) Swap(i, j int) {
+	list[i], list[j] = list[j], list[i]
+}
+
+func (imdb *ImageDataBase) watchMdb(mdbChannel <-chan *mdb.Mdb) {
+	for mdb := range mdbChannel {
+		mdbImages := make(map[string]struct{})
+		for _, machine := range mdb.Machines {
+			if machine.RequiredImage != "" {
+				mdbImages[machine.RequiredImage] = struct{}{}
+			}
+			if machine.PlannedImage != "" {
+				mdbImages[machine.PlannedImage] = struct{}{}
+			}
+		}
+		imdb.mdbLock.Lock()
+		imdb.mdbImages = mdbImages
+		imdb.mdbLock.Unlock()
+	}
+}
+
+func (imdb *ImageDataBase) getMdbImages() map[string]struct{} {
+	imdb.mdbLock.Lock()
+	defer imdb.mdbLock.Unlock()
+	return imdb.mdbImages
+}
+
+// listRetentionDeletions returns the names of the images which retention
+// policies would delete, in dirname and its sub-directories. If dirname is
+// empty, all directories are processed.
+func (imdb *ImageDataBase) listRetentionDeletions(dirname string) []string {
+	if dirname != "" {
+		dirname = path.Clean(dirname)
+	}
+	mdbImages := imdb.getMdbImages()
+	imdb.RLock()
+	defer imdb.RUnlock()
+	policies := make(map[string]image.RetentionPolicy)
+	for name, metadata := range imdb.directoryMap {
+		if !metadata.RetentionPolicy.IsActive() {
+			continue
+		}
+		if dirname != "" && dirname != "." && name != dirname &&
+			!strings.HasPrefix(name, dirname+"/") {
+			continue
+		}
+		if metadata.RetentionPolicy.KeepMdbImages && mdbImages == nil {
+			imdb.logger.Printf(
+				"Ignoring retention policy for: %s: MDB not available\n", name)
+			continue
+		}
+		policies[name] = metadata.RetentionPolicy
+	}
+	candidates := make(map[string]retentionCandidateList)
+	for name, img := range imdb.imageMap {
+		dirname := path.Dir(name)
+		if _, ok := policies[dirname]; ok {
+			candidates[dirname] = append(candidates[dirname],
+				retentionCandidate{name, img.CreatedOn})
+		}
+	}
+	var imagesToDelete []string
+	for dirname, images := range candidates {
+		policy := policies[dirname]
+		sort.Sort(images)
+		for index, candidate := range images {
+			if uint(index) < policy.KeepNewest {
+				continue
+			}
+			if policy.KeepYoungerThan > 0 &&
+				time.Since(candidate.createdOn) < policy.KeepYoungerThan {
+				continue
+			}
+			if policy.KeepMdbImages {
+				if _, ok := mdbImages[candidate.name]; ok {
+					continue
+				}
+			}
+			imagesToDelete = append(imagesToDelete, candidate.name)
+		}
+	}
+	sort.Strings(imagesToDelete)
+	return imagesToDelete
+}
+
+// applyRetentionPolicies deletes the images which retention policies do not
+// keep, returning the names of the deleted images. If username is not nil,
+// the user must have permission to delete the images. Failures are logged and
+// do not stop other images from being deleted; they are returned together.
+func (imdb *ImageDataBase) applyRetentionPolicies(dirname string,
+	username *string) ([]string, error) {
+	var deletedImages, errorStrings []string
+	for _, name := range imdb.listRetentionDeletions(dirname) {
+		if err := imdb.deleteImage(name, username); err != nil {
+			imdb.logger.Printf("Error deleting image: %s: %s\n", name, err)
+			errorStrings = append(errorStrings,
+				fmt.Sprintf("%s: %s", name, err))
+			continue
+		}
+		imdb.logger.Printf("Deleted image: %s due to retention policy\n",
+			name)
+		deletedImages = append(deletedImages, name)
+	}
+	if len(errorStrings) > 0 {
+		return deletedImages, errors.New("error deleting images: " +
+			strings.Join(errorStrings, ", "))
+	}
+	return deletedImages, nil
+}
+
+func (imdb *ImageDataBase) periodicRetentionEnforcer() {
+	if *retentionPolicyInterval < 1 || imdb.replicationMaster != "" {
+		return
+	}
+	for {
+		time.Sleep(*retentionPolicyInterval)
+		imdb.applyRetentionPolicies("", nil) // Errors are logged.
+	}
+}
+
+func (imdb *ImageDataBase) setRetentionPolicy(dirname string,
+	policy image.RetentionPolicy, username string) error {
+	dirname = path.Clean(dirname)
+	imdb.Lock()
+	defer imdb.Unlock()
+	directoryMetadata, ok := imdb.directoryMap[dirname]
+	if !ok {
+		return fmt.Errorf("no metadata for: \"%s\"", dirname)
+	}
+	if err := imdb.checkDirectoryPermissions(dirname, &username); err != nil {
+		return err
+	}
+	directoryMetadata.RetentionPolicy = policy
+	return imdb.updateDirectoryMetadata(
+		image.Directory{Name: dirname, Metadata: directoryMetadata})
+}
@@ -0,0 +1,105 @@
+package scanner
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/image"
+	"github.com/Symantec/Dominator/lib/log/testlogger"
+)
+
+func makeRetentionDataBase(t *testing.T) *ImageDataBase {
+	policies := map[string]image.RetentionPolicy{
+		"newest":  {KeepNewest: 2},
+		"younger": {KeepYoungerThan: 150 * time.Minute},
+		"mdb":     {KeepNewest: 1, KeepMdbImages: true},
+		"none":    {},
+	}
+	baseDir, err := ioutil.TempDir("", "retention_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	imdb := &ImageDataBase{
+		baseDir:      baseDir,
+		directoryMap: make(map[string]image.DirectoryMetadata),
+		imageMap:     make(map[string]*image.Image),
+		logger:       testlogger.New(t),
+	}
+	now := time.Now()
+	for dirname, policy := range policies {
+		imdb.directoryMap[dirname] = image.DirectoryMetadata{
+			RetentionPolicy: policy,
+		}
+		for age := 1; age <= 4; age++ {
+			imdb.imageMap[fmt.Sprintf("%s/%d", dirname, age)] = &image.Image{
+				CreatedOn: now.Add(-time.Duration(age) * time.Hour),
+			}
+		}
+	}
+	return imdb
+}
+
+func TestListRetentionDeletions(t *testing.T) {
+	var tests = []struct {
+		name      string
+		dirname   string
+		mdbImages map[string]struct{}
+		expected  []string
+	}{
+		{
+			name:      "all",
+			mdbImages: map[string]struct{}{"mdb/3": {}},
+			expected: []string{"mdb/2", "mdb/4", "newest/3", "newest/4",
+				"younger/3", "younger/4"},
+		},
+		{
+			name: "no MDB",
+			expected: []string{"newest/3", "newest/4", "younger/3",
+				"younger/4"},
+		},
+		{
+			name:      "one directory",
+			dirname:   "newest/",
+			mdbImages: map[string]struct{}{},
+			expected:  []string{"newest/3", "newest/4"},
+		},
+		{
+			name:      "no policy",
+			dirname:   "none",
+			mdbImages: map[string]struct{}{},
+		},
+	}
+	for _, test := range tests {
+		imdb := makeRetentionDataBase(t)
+		defer os.RemoveAll(imdb.baseDir)
+		imdb.mdbImages = test.mdbImages
+		deletions := imdb.listRetentionDeletions(test.dirname)
+		if !reflect.DeepEqual(deletions, test.expected) {
+			t.Errorf("%s: deletions: %v, expected: %v",
+				test.name, deletions, test.expected)
+		}
+	}
+}
+
+func TestApplyRetentionPoliciesContinuesAfterErrors(t *testing.T) {
+	// There are no image files, so every deletion fails.
+	imdb := makeRetentionDataBase(t)
+	defer os.RemoveAll(imdb.baseDir)
+	deletedImages, err := imdb.applyRetentionPolicies("", nil)
+	if err == nil {
+		t.Fatal("no error returned")
+	}
+	if len(deletedImages) > 0 {
+		t.Errorf("deleted images: %v", deletedImages)
+	}
+	for _, name := range imdb.listRetentionDeletions("") {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error: \"%s\" does not mention: %s", err, name)
+		}
+	}
+}
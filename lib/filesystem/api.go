@@ -122,6 +122,7 @@ type DirectoryInode struct {
 	Mode          FileMode
 	Uid           uint32
 	Gid           uint32
+	Xattrs        map[string][]byte // Includes POSIX ACLs and capabilities.
 }
 
 func (directory *DirectoryInode) BuildEntryMap() {
@@ -180,6 +181,7 @@ type RegularInode struct {
 	MtimeSeconds     int64
 	Size             uint64
 	Hash             hash.Hash
+	Xattrs           map[string][]byte
 }
 
 func (inode *RegularInode) GetUid() uint32 {
@@ -229,6 +231,7 @@ type SymlinkInode struct {
 	Uid     uint32
 	Gid     uint32
 	Symlink string
+	Xattrs  map[string][]byte
 }
 
 func (inode *SymlinkInode) GetUid() uint32 {
@@ -260,6 +263,7 @@ type SpecialInode struct {
 	MtimeNanoSeconds int32
 	MtimeSeconds     int64
 	Rdev             uint64
+	Xattrs           map[string][]byte
 }
 
 func (inode *SpecialInode) GetUid() uint32 {
@@ -286,6 +290,26 @@ func (inode *SpecialInode) WriteMetadata(name string) error {
 
 type FileMode uint32
 
+// Drift describes how a file-system differs from a required file-system. Added
+// and missing directories are reported without their contents.
+type Drift struct {
+	AddedPaths      []string // Present in the file-system but not required.
+	MissingPaths    []string // Required but not present in the file-system.
+	ModifiedPaths   []string // Type or content differs.
+	MetadataChanges []MetadataChange
+}
+
+// MetadataChange describes a path whose content matches the required content
+// but whose metadata differs.
+type MetadataChange struct {
+	Pathname      string
+	ModeChanged   bool
+	UidChanged    bool
+	GidChanged    bool
+	MtimeChanged  bool
+	XattrsChanged bool // Extended attributes, including ACLs.
+}
+
 func (mode FileMode) String() string {
 	return mode.string()
 }
@@ -294,6 +318,23 @@ func CompareFileSystems(left, right *FileSystem, logWriter io.Writer) bool {
 	return compareFileSystems(left, right, logWriter)
 }
 
+// ComputeDrift will compare the file-system with requiredFS and return the
+// differences. Paths which match filter are ignored. If filter is nil (a
+// sparse image), paths which are not in requiredFS are ignored. The content of
+// computed files in requiredFS is given by computedInodes (keyed by pathname),
+// else it is assumed to match. The entry maps of both file-systems must be
+// built.
+func (fs *FileSystem) ComputeDrift(requiredFS *FileSystem,
+	filter *filter.Filter, computedInodes map[string]*RegularInode) *Drift {
+	return fs.computeDrift(requiredFS, filter, computedInodes)
+}
+
+// CompareXattrs returns true if the extended attributes are the same. A nil
+// map and an empty map are considered the same.
+func CompareXattrs(left, right map[string][]byte, logWriter io.Writer) bool {
+	return compareXattrs(left, right, logWriter)
+}
+
 func CompareDirectoryInodes(left, right *DirectoryInode,
 	logWriter io.Writer) bool {
 	return compareDirectoryInodes(left, right, logWriter)
@@ -314,6 +355,16 @@ func CompareInodes(left, right GenericInode, logWriter io.Writer) (
 	return compareInodes(left, right, logWriter)
 }
 
+// CompareRequiredInodes is like CompareInodes, except that requiredInode is the
+// desired state of inode and host owned extended attributes (such as SELinux
+// labels) of inode are ignored unless requiredInode has them, so that a host
+// may label files which the image does not label. All other extended
+// attributes (such as ACLs and file capabilities) must match.
+func CompareRequiredInodes(inode, requiredInode GenericInode,
+	logWriter io.Writer) (sameType, sameMetadata, sameData bool) {
+	return compareRequiredInodes(inode, requiredInode, logWriter)
+}
+
 func CompareRegularInodes(left, right *RegularInode, logWriter io.Writer) bool {
 	return compareRegularInodes(left, right, logWriter)
 }
@@ -71,6 +71,9 @@ func compareDirectoriesMetadata(left, right *DirectoryInode,
 		}
 		return false
 	}
+	if !compareXattrs(left.Xattrs, right.Xattrs, logWriter) {
+		return false
+	}
 	return true
 }
 
@@ -160,6 +163,16 @@ func compareInodes(left, right GenericInode, logWriter io.Writer) (
 	return
 }
 
+func compareRequiredInodes(inode, requiredInode GenericInode,
+	logWriter io.Writer) (sameType, sameMetadata, sameData bool) {
+	xattrs := getXattrs(inode)
+	managedXattrs := getManagedXattrs(xattrs, getXattrs(requiredInode))
+	if len(managedXattrs) != len(xattrs) {
+		inode = withXattrs(inode, managedXattrs)
+	}
+	return compareInodes(inode, requiredInode, logWriter)
+}
+
 func compareRegularInodes(left, right *RegularInode, logWriter io.Writer) bool {
 	if left == right {
 		return true
@@ -193,6 +206,9 @@ func compareRegularInodesMetadata(left, right *RegularInode,
 		}
 		return false
 	}
+	if !compareXattrs(left.Xattrs, right.Xattrs, logWriter) {
+		return false
+	}
 	var leftMtime, rightMtime timespec
 	leftMtime.Sec = left.MtimeSeconds
 	leftMtime.Nsec = left.MtimeNanoSeconds
@@ -311,6 +327,9 @@ func compareSymlinkInodesMetadata(left, right *SymlinkInode,
 		}
 		return false
 	}
+	if !compareXattrs(left.Xattrs, right.Xattrs, logWriter) {
+		return false
+	}
 	return true
 }
 
@@ -362,6 +381,9 @@ func compareSpecialInodesMetadata(left, right *SpecialInode,
 		}
 		return false
 	}
+	if !compareXattrs(left.Xattrs, right.Xattrs, logWriter) {
+		return false
+	}
 	var leftMtime, rightMtime timespec
 	leftMtime.Sec = left.MtimeSeconds
 	leftMtime.Nsec = left.MtimeNanoSeconds
@@ -391,3 +413,86 @@ func compareSpecialInodesData(left, right *SpecialInode,
 	}
 	return true
 }
+
+func getXattrs(inode GenericInode) map[string][]byte {
+	switch inode := inode.(type) {
+	case *DirectoryInode:
+		return inode.Xattrs
+	case *RegularInode:
+		return inode.Xattrs
+	case *SymlinkInode:
+		return inode.Xattrs
+	case *SpecialInode:
+		return inode.Xattrs
+	}
+	return nil
+}
+
+// getManagedXattrs returns the extended attributes in xattrs which are
+// managed, given the required extended attributes. Host owned extended
+// attributes are only managed if they are required.
+func getManagedXattrs(xattrs,
+	requiredXattrs map[string][]byte) map[string][]byte {
+	var managedXattrs map[string][]byte
+	for name, value := range xattrs {
+		if _, ok := hostXattrNames[name]; ok {
+			if _, ok := requiredXattrs[name]; !ok {
+				continue
+			}
+		}
+		if managedXattrs == nil {
+			managedXattrs = make(map[string][]byte, len(xattrs))
+		}
+		managedXattrs[name] = value
+	}
+	return managedXattrs
+}
+
+// withXattrs returns a shallow copy of inode with the specified extended
+// attributes.
+func withXattrs(inode GenericInode, xattrs map[string][]byte) GenericInode {
+	switch inode := inode.(type) {
+	case *DirectoryInode:
+		newInode := *inode
+		newInode.Xattrs = xattrs
+		return &newInode
+	case *RegularInode:
+		newInode := *inode
+		newInode.Xattrs = xattrs
+		return &newInode
+	case *SymlinkInode:
+		newInode := *inode
+		newInode.Xattrs = xattrs
+		return &newInode
+	case *SpecialInode:
+		newInode := *inode
+		newInode.Xattrs = xattrs
+		return &newInode
+	}
+	return inode
+}
+
+func compareXattrs(left, right map[string][]byte, logWriter io.Writer) bool {
+	if len(left) != len(right) {
+		if logWriter != nil {
+			fmt.Fprintf(logWriter, "Xattrs: left vs. right: %d vs. %d\n",
+				len(left), len(right))
+		}
+		return false
+	}
+	for name, leftValue := range left {
+		if rightValue, ok := right[name]; !ok {
+			if logWriter != nil {
+				fmt.Fprintf(logWriter, "Xattr: %s missing on right\n", name)
+			}
+			return false
+		} else if !bytes.Equal(leftValue, rightValue) {
+			if logWriter != nil {
+				fmt.Fprintf(logWriter, "Xattr: %s: left vs. right: %x vs. %x\n",
+					name, leftValue, rightValue)
+			}
+			return false
+		}
+	}
+	return true
+}
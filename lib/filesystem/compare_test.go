@@ -0,0 +1,87 @@
+package filesystem
+
+import (
+	"testing"
+)
+
+var (
+	aclXattrs = map[string][]byte{
+		"system.posix_acl_access": []byte{2, 0, 0, 0},
+	}
+	capabilityXattrs = map[string][]byte{
+		"security.capability": []byte{0, 0, 0, 2, 0, 4, 0, 0, 0, 0, 0, 0,
+			0, 0, 0, 0, 0, 0, 0, 0},
+	}
+	selinuxXattrs = map[string][]byte{
+		"security.selinux": []byte("system_u:object_r:bin_t:s0"),
+	}
+	selinuxAclXattrs = map[string][]byte{
+		"security.selinux":        []byte("system_u:object_r:bin_t:s0"),
+		"system.posix_acl_access": []byte{2, 0, 0, 0},
+	}
+	otherSelinuxXattrs = map[string][]byte{
+		"security.selinux": []byte("system_u:object_r:etc_t:s0"),
+	}
+)
+
+func TestCompareXattrs(t *testing.T) {
+	tests := []struct {
+		name        string
+		left, right map[string][]byte
+		same        bool
+	}{
+		{"nil", nil, nil, true},
+		{"nilAndEmpty", nil, map[string][]byte{}, true},
+		{"same", selinuxXattrs, selinuxXattrs, true},
+		{"missingOnLeft", nil, selinuxXattrs, false},
+		{"missingOnRight", selinuxXattrs, nil, false},
+		{"differentNames", selinuxXattrs, aclXattrs, false},
+		{"differentValues", selinuxXattrs, otherSelinuxXattrs, false},
+		{"extraOnRight", selinuxXattrs, selinuxAclXattrs, false},
+	}
+	for _, test := range tests {
+		if same := compareXattrs(test.left, test.right, nil); same != test.same {
+			t.Errorf("%s: same=%v, expected %v", test.name, same, test.same)
+		}
+	}
+}
+
+func TestCompareRequiredInodes(t *testing.T) {
+	tests := []struct {
+		name           string
+		xattrs         map[string][]byte
+		requiredXattrs map[string][]byte
+		sameMetadata   bool
+	}{
+		{"notManaged", selinuxXattrs, nil, true},
+		{"same", selinuxXattrs, selinuxXattrs, true},
+		{"missing", nil, selinuxXattrs, false},
+		{"different", otherSelinuxXattrs, selinuxXattrs, false},
+		{"extra", selinuxAclXattrs, selinuxXattrs, false},
+		{"strayAcl", aclXattrs, nil, false},
+		{"strayCapability", capabilityXattrs, nil, false},
+	}
+	for _, test := range tests {
+		inode := &RegularInode{Mode: 0644, Size: 1, Xattrs: test.xattrs}
+		requiredInode := &RegularInode{Mode: 0644, Size: 1,
+			Xattrs: test.requiredXattrs}
+		sameType, sameMetadata, sameData := compareRequiredInodes(inode,
+			requiredInode, nil)
+		if !sameType || !sameData {
+			t.Errorf("%s: type or data differ", test.name)
+		}
+		if sameMetadata != test.sameMetadata {
+			t.Errorf("%s: sameMetadata=%v, expected %v",
+				test.name, sameMetadata, test.sameMetadata)
+		}
+		if len(test.xattrs) > 0 && len(inode.Xattrs) < 1 {
+			t.Errorf("%s: inode modified", test.name)
+		}
+	}
+	inode := &RegularInode{Mode: 0644, Xattrs: selinuxXattrs}
+	_, sameMetadata, _ := compareRequiredInodes(inode,
+		&RegularInode{Mode: 0755}, nil)
+	if sameMetadata {
+		t.Error("mode change ignored when xattrs are not managed")
+	}
+}
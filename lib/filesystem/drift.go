@@ -0,0 +1,193 @@
+package filesystem
+
+import (
+	"bytes"
+	"path"
+	"sort"
+	"syscall"
+
+	"github.com/Symantec/Dominator/lib/filter"
+)
+
+type driftComputer struct {
+	drift          *Drift
+	filter         *filter.Filter
+	computedInodes map[string]*RegularInode
+}
+
+func (fs *FileSystem) computeDrift(requiredFS *FileSystem,
+	filter *filter.Filter, computedInodes map[string]*RegularInode) *Drift {
+	computer := &driftComputer{
+		drift:          &Drift{},
+		filter:         filter,
+		computedInodes: computedInodes,
+	}
+	computer.compareDirectories(&fs.DirectoryInode,
+		&requiredFS.DirectoryInode, "/")
+	return computer.drift
+}
+
+func (computer *driftComputer) compareDirectories(
+	directory, requiredDirectory *DirectoryInode, myPathName string) {
+	drift := computer.drift
+	if metadataDiffers(directory, requiredDirectory) {
+		drift.addMetadataChange(myPathName, directory, requiredDirectory)
+	}
+	// Paths which are not in a sparse image (no filter) are not managed.
+	if computer.filter != nil {
+		computer.findAddedPaths(directory, requiredDirectory, myPathName)
+	}
+	for _, name := range sortedNames(requiredDirectory) {
+		pathname := path.Join(myPathName, name)
+		if computer.filter != nil && computer.filter.Match(pathname) {
+			continue
+		}
+		entry, ok := directory.EntriesByName[name]
+		if !ok {
+			drift.MissingPaths = append(drift.MissingPaths, pathname)
+			continue
+		}
+		inode := entry.Inode()
+		requiredInode := requiredDirectory.EntriesByName[name].Inode()
+		if computed, ok := requiredInode.(*ComputedRegularInode); ok {
+			requiredInode = computer.getComputedInode(pathname, computed, inode)
+		}
+		if !compareContent(inode, requiredInode) {
+			drift.ModifiedPaths = append(drift.ModifiedPaths, pathname)
+			continue
+		}
+		if subdirectory, ok := inode.(*DirectoryInode); ok {
+			computer.compareDirectories(subdirectory,
+				requiredInode.(*DirectoryInode), pathname)
+		} else if metadataDiffers(inode, requiredInode) {
+			drift.addMetadataChange(pathname, inode, requiredInode)
+		}
+	}
+}
+
+func (computer *driftComputer) findAddedPaths(
+	directory, requiredDirectory *DirectoryInode, myPathName string) {
+	for _, name := range sortedNames(directory) {
+		pathname := path.Join(myPathName, name)
+		if computer.filter.Match(pathname) {
+			continue
+		}
+		if _, ok := requiredDirectory.EntriesByName[name]; !ok {
+			computer.drift.AddedPaths = append(computer.drift.AddedPaths,
+				pathname)
+		}
+	}
+}
+
+// getComputedInode returns the expected inode for a computed file. The mtime of
+// computed files is not managed. If the computed file is not available its
+// content is assumed to match.
+func (computer *driftComputer) getComputedInode(pathname string,
+	computedInode *ComputedRegularInode, inode GenericInode) GenericInode {
+	requiredInode := &RegularInode{
+		Mode: computedInode.Mode,
+		Uid:  computedInode.Uid,
+		Gid:  computedInode.Gid,
+	}
+	computed, haveComputed := computer.computedInodes[pathname]
+	if haveComputed {
+		*requiredInode = *computed
+	}
+	if inode, ok := inode.(*RegularInode); ok {
+		if !haveComputed {
+			requiredInode.Size = inode.Size
+			requiredInode.Hash = inode.Hash
+		}
+		requiredInode.MtimeSeconds = inode.MtimeSeconds
+		requiredInode.MtimeNanoSeconds = inode.MtimeNanoSeconds
+	}
+	return requiredInode
+}
+
+func sortedNames(directory *DirectoryInode) []string {
+	names := make([]string, 0, len(directory.EntriesByName))
+	for name := range directory.EntriesByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// compareContent returns true if the inodes have the same type and content.
+func compareContent(inode, requiredInode GenericInode) bool {
+	switch requiredInode := requiredInode.(type) {
+	case *DirectoryInode:
+		_, ok := inode.(*DirectoryInode)
+		return ok
+	case *RegularInode:
+		if inode, ok := inode.(*RegularInode); ok {
+			return inode.Size == requiredInode.Size &&
+				(inode.Size < 1 ||
+					bytes.Equal(inode.Hash[:], requiredInode.Hash[:]))
+		}
+	case *SymlinkInode:
+		if inode, ok := inode.(*SymlinkInode); ok {
+			return inode.Symlink == requiredInode.Symlink
+		}
+	case *SpecialInode:
+		if inode, ok := inode.(*SpecialInode); ok {
+			return inode.Mode&syscall.S_IFMT ==
+				requiredInode.Mode&syscall.S_IFMT &&
+				inode.Rdev == requiredInode.Rdev
+		}
+	}
+	return false
+}
+
+type inodeMetadata struct {
+	mode             FileMode
+	uid              uint32
+	gid              uint32
+	mtimeSeconds     int64
+	mtimeNanoSeconds int32
+}
+
+func getMetadata(inode GenericInode) inodeMetadata {
+	metadata := inodeMetadata{uid: inode.GetUid(), gid: inode.GetGid()}
+	switch inode := inode.(type) {
+	case *DirectoryInode:
+		metadata.mode = inode.Mode
+	case *RegularInode:
+		metadata.mode = inode.Mode
+		metadata.mtimeSeconds = inode.MtimeSeconds
+		metadata.mtimeNanoSeconds = inode.MtimeNanoSeconds
+	case *SpecialInode:
+		metadata.mode = inode.Mode
+		metadata.mtimeSeconds = inode.MtimeSeconds
+		metadata.mtimeNanoSeconds = inode.MtimeNanoSeconds
+	}
+	return metadata
+}
+
+// xattrsDiffer returns true if the managed extended attributes differ. Host
+// owned extended attributes are ignored unless the required inode has them.
+func xattrsDiffer(inode, requiredInode GenericInode) bool {
+	requiredXattrs := getXattrs(requiredInode)
+	return !compareXattrs(getManagedXattrs(getXattrs(inode), requiredXattrs),
+		requiredXattrs, nil)
+}
+
+func metadataDiffers(inode, requiredInode GenericInode) bool {
+	return getMetadata(inode) != getMetadata(requiredInode) ||
+		xattrsDiffer(inode, requiredInode)
+}
+
+func (drift *Drift) addMetadataChange(pathname string,
+	inode, requiredInode GenericInode) {
+	metadata := getMetadata(inode)
+	requiredMetadata := getMetadata(requiredInode)
+	drift.MetadataChanges = append(drift.MetadataChanges, MetadataChange{
+		Pathname:    pathname,
+		ModeChanged: metadata.mode != requiredMetadata.mode,
+		UidChanged:  metadata.uid != requiredMetadata.uid,
+		GidChanged:  metadata.gid != requiredMetadata.gid,
+		MtimeChanged: metadata.mtimeSeconds != requiredMetadata.mtimeSeconds ||
+			metadata.mtimeNanoSeconds != requiredMetadata.mtimeNanoSeconds,
+		XattrsChanged: xattrsDiffer(inode, requiredInode),
+	})
+}
@@ -0,0 +1,133 @@
+package filesystem
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/Symantec/Dominator/lib/filter"
+	"github.com/Symantec/Dominator/lib/hash"
+)
+
+func makeDriftFileSystem(t *testing.T, inode GenericInode) *FileSystem {
+	fs := &FileSystem{
+		InodeTable: InodeTable{1: inode},
+		DirectoryInode: DirectoryInode{
+			Mode: syscall.S_IFDIR | 0755,
+			EntryList: []*DirectoryEntry{
+				{Name: "file", InodeNumber: 1},
+			},
+		},
+	}
+	if err := fs.RebuildInodePointers(); err != nil {
+		t.Fatal(err)
+	}
+	fs.BuildEntryMap()
+	return fs
+}
+
+func TestComputeDrift(t *testing.T) {
+	file := &RegularInode{
+		Mode:         syscall.S_IFREG | 0644,
+		MtimeSeconds: 100,
+		Size:         1,
+		Hash:         hash.Hash{1},
+		Xattrs:       selinuxXattrs,
+	}
+	computed := &ComputedRegularInode{Mode: syscall.S_IFREG | 0644}
+	var tests = []struct {
+		name           string
+		requiredInode  GenericInode
+		computedInodes map[string]*RegularInode
+		modified       bool
+		metadataChange *MetadataChange
+	}{
+		{"same", file, nil, false, nil},
+		{"content", &RegularInode{Mode: file.Mode, MtimeSeconds: 100,
+			Size: 1, Hash: hash.Hash{2}}, nil, true, nil},
+		{"no xattrs in image", &RegularInode{Mode: file.Mode,
+			MtimeSeconds: 100, Size: 1, Hash: hash.Hash{1}}, nil, false, nil},
+		{"xattrs", &RegularInode{Mode: file.Mode, MtimeSeconds: 100, Size: 1,
+			Hash: hash.Hash{1}, Xattrs: otherSelinuxXattrs}, nil, false,
+			&MetadataChange{Pathname: "/file", XattrsChanged: true}},
+		{"mode and mtime", &RegularInode{Mode: syscall.S_IFREG | 0600,
+			Size: 1, Hash: hash.Hash{1}, Xattrs: selinuxXattrs}, nil, false,
+			&MetadataChange{Pathname: "/file", ModeChanged: true,
+				MtimeChanged: true}},
+		{"computed unknown", computed, nil, false, nil},
+		{"computed same", computed, map[string]*RegularInode{"/file": file},
+			false, nil},
+		{"computed content", computed, map[string]*RegularInode{
+			"/file": {Mode: file.Mode, Size: 2}}, true, nil},
+		{"symlink", &SymlinkInode{Symlink: "target"}, nil, true, nil},
+	}
+	fs := makeDriftFileSystem(t, file)
+	for _, test := range tests {
+		requiredFS := makeDriftFileSystem(t, test.requiredInode)
+		drift := fs.ComputeDrift(requiredFS, nil, test.computedInodes)
+		if len(drift.AddedPaths) != 0 || len(drift.MissingPaths) != 0 {
+			t.Errorf("%s: unexpected added/missing paths: %v", test.name, drift)
+		}
+		if modified := len(drift.ModifiedPaths) > 0; modified != test.modified {
+			t.Errorf("%s: modified: %v != %v", test.name, modified,
+				test.modified)
+		}
+		if test.metadataChange == nil {
+			if len(drift.MetadataChanges) != 0 {
+				t.Errorf("%s: unexpected metadata changes: %v",
+					test.name, drift.MetadataChanges)
+			}
+		} else if len(drift.MetadataChanges) != 1 {
+			t.Errorf("%s: metadata changes: %v", test.name,
+				drift.MetadataChanges)
+		} else if drift.MetadataChanges[0] != *test.metadataChange {
+			t.Errorf("%s: metadata change: %v != %v", test.name,
+				drift.MetadataChanges[0], *test.metadataChange)
+		}
+	}
+}
+
+func TestComputeDriftSparseImage(t *testing.T) {
+	file := &RegularInode{Mode: syscall.S_IFREG | 0644}
+	fs := &FileSystem{
+		InodeTable: InodeTable{1: file, 2: file},
+		DirectoryInode: DirectoryInode{
+			Mode: syscall.S_IFDIR | 0755,
+			EntryList: []*DirectoryEntry{
+				{Name: "extra", InodeNumber: 2},
+				{Name: "file", InodeNumber: 1},
+			},
+		},
+	}
+	if err := fs.RebuildInodePointers(); err != nil {
+		t.Fatal(err)
+	}
+	fs.BuildEntryMap()
+	requiredFS := makeDriftFileSystem(t, file)
+	drift := fs.ComputeDrift(requiredFS, nil, nil)
+	if len(drift.AddedPaths) != 0 {
+		t.Errorf("sparse image: added paths: %v", drift.AddedPaths)
+	}
+	emptyFilter, err := filter.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	drift = fs.ComputeDrift(requiredFS, emptyFilter, nil)
+	if len(drift.AddedPaths) != 1 || drift.AddedPaths[0] != "/extra" {
+		t.Errorf("added paths: %v, expected: [/extra]", drift.AddedPaths)
+	}
+}
+
+func TestComputeDriftStrayCapability(t *testing.T) {
+	file := &RegularInode{Mode: syscall.S_IFREG | 0644, Size: 1,
+		Hash: hash.Hash{1}}
+	fs := makeDriftFileSystem(t, &RegularInode{Mode: file.Mode, Size: 1,
+		Hash: hash.Hash{1}, Xattrs: capabilityXattrs})
+	drift := fs.ComputeDrift(makeDriftFileSystem(t, file), nil, nil)
+	expected := MetadataChange{Pathname: "/file", XattrsChanged: true}
+	if len(drift.MetadataChanges) != 1 {
+		t.Errorf("metadata changes: %v", drift.MetadataChanges)
+	} else if drift.MetadataChanges[0] != expected {
+		t.Errorf("metadata change: %v != %v", drift.MetadataChanges[0],
+			expected)
+	}
+}
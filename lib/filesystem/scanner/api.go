@@ -1,6 +1,7 @@
 package scanner
 
 import (
+	"errors"
 	"io"
 
 	"github.com/Symantec/Dominator/lib/cpulimiter"
@@ -10,6 +11,10 @@ import (
 	"github.com/Symantec/Dominator/lib/hash"
 )
 
+// ErrRescanRequired is returned by ScanFileSystemChanges if the changes could
+// not be applied incrementally and a full scan is required.
+var ErrRescanRequired = errors.New("full rescan required")
+
 type Hasher interface {
 	Hash(reader io.Reader, length uint64) (hash.Hash, error)
 }
@@ -29,6 +34,8 @@ type FileSystem struct {
 	hasher                  Hasher
 	dev                     uint64
 	inodeNumber             uint64
+	changedPaths            map[string]struct{}
+	dirtyDirectories        map[string]struct{}
 	filesystem.FileSystem
 }
 
@@ -37,7 +44,19 @@ func ScanFileSystem(rootDirectoryName string,
 	checkScanDisableRequest func() bool, hasher Hasher, oldFS *FileSystem) (
 	*FileSystem, error) {
 	return scanFileSystem(rootDirectoryName, fsScanContext, scanFilter,
-		checkScanDisableRequest, hasher, oldFS)
+		checkScanDisableRequest, hasher, oldFS, nil)
+}
+
+// ScanFileSystemChanges is like ScanFileSystem, except that only the
+// directories containing the paths in changedPaths (and their parents) are
+// read. The remainder of the tree is copied from oldFS and regular files are
+// only hashed if they are in changedPaths or their metadata changed.
+func ScanFileSystemChanges(rootDirectoryName string,
+	fsScanContext *fsrateio.ReaderContext, scanFilter *filter.Filter,
+	checkScanDisableRequest func() bool, hasher Hasher, oldFS *FileSystem,
+	changedPaths map[string]struct{}) (*FileSystem, error) {
+	return scanFileSystemChanges(rootDirectoryName, fsScanContext, scanFilter,
+		checkScanDisableRequest, hasher, oldFS, changedPaths)
 }
 
 func (fs *FileSystem) GetObject(hashVal hash.Hash) (
@@ -0,0 +1,113 @@
+package scanner
+
+import (
+	"path"
+	"sort"
+
+	"github.com/Symantec/Dominator/lib/filesystem"
+	"github.com/Symantec/Dominator/lib/filter"
+	"github.com/Symantec/Dominator/lib/fsrateio"
+	"github.com/Symantec/Dominator/lib/wsyscall"
+)
+
+func scanFileSystemChanges(rootDirectoryName string,
+	fsScanContext *fsrateio.ReaderContext, scanFilter *filter.Filter,
+	checkScanDisableRequest func() bool, hasher Hasher, oldFS *FileSystem,
+	changedPaths map[string]struct{}) (*FileSystem, error) {
+	if oldFS == nil || oldFS.InodeTable == nil {
+		return nil, ErrRescanRequired
+	}
+	if changedPaths == nil {
+		changedPaths = make(map[string]struct{})
+	}
+	return scanFileSystem(rootDirectoryName, fsScanContext, scanFilter,
+		checkScanDisableRequest, hasher, oldFS, changedPaths)
+}
+
+// makeDirtyDirectories returns the set of directories which must be read: the
+// changed paths and all their parents.
+func makeDirtyDirectories(
+	changedPaths map[string]struct{}) map[string]struct{} {
+	dirtyDirectories := make(map[string]struct{})
+	for pathname := range changedPaths {
+		for {
+			if _, ok := dirtyDirectories[pathname]; ok {
+				break
+			}
+			dirtyDirectories[pathname] = struct{}{}
+			if pathname == "/" {
+				break
+			}
+			pathname = path.Dir(pathname)
+		}
+	}
+	return dirtyDirectories
+}
+
+// isDirty returns true if the directory must be read, rather than copied from
+// the previous scan.
+func (fileSystem *FileSystem) isDirty(dirname string) bool {
+	if fileSystem.dirtyDirectories == nil {
+		return true
+	}
+	_, ok := fileSystem.dirtyDirectories[dirname]
+	return ok
+}
+
+// copyDirectory will add the inodes in an unchanged directory tree from the
+// previous scan to the inode table.
+func (fileSystem *FileSystem) copyDirectory(
+	directory *filesystem.DirectoryInode) error {
+	for _, dirent := range directory.EntryList {
+		inode := dirent.Inode()
+		if tableInode, ok := fileSystem.InodeTable[dirent.InodeNumber]; ok {
+			if tableInode != inode {
+				// Hardlinked to an inode which changed.
+				return ErrRescanRequired
+			}
+			continue
+		}
+		fileSystem.InodeTable[dirent.InodeNumber] = inode
+		if inode, ok := inode.(*filesystem.DirectoryInode); ok {
+			fileSystem.DirectoryCount++
+			if err := fileSystem.copyDirectory(inode); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getUnchangedInode returns the inode from the previous scan if the file was
+// not reported as changed and its metadata are the same, else nil.
+func (fileSystem *FileSystem) getUnchangedInode(oldFS *FileSystem,
+	inode *filesystem.RegularInode, pathname string,
+	stat *wsyscall.Stat_t) *filesystem.RegularInode {
+	if fileSystem.changedPaths == nil {
+		return nil
+	}
+	if _, ok := fileSystem.changedPaths[pathname]; ok {
+		return nil
+	}
+	oldInode, ok := oldFS.InodeTable[stat.Ino].(*filesystem.RegularInode)
+	if !ok || !isSameRegularInode(inode, oldInode) {
+		return nil
+	}
+	return oldInode
+}
+
+func isSameRegularInode(left, right *filesystem.RegularInode) bool {
+	return left.Size == right.Size &&
+		filesystem.CompareRegularInodesMetadata(left, right, nil)
+}
+
+func findDirent(entryList []*filesystem.DirectoryEntry,
+	name string) *filesystem.DirectoryEntry {
+	index := sort.Search(len(entryList), func(index int) bool {
+		return entryList[index].Name >= name
+	})
+	if index < len(entryList) && entryList[index].Name == name {
+		return entryList[index]
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package scanner
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/Symantec/Dominator/lib/filesystem"
+)
+
+func makeTree(t *testing.T) string {
+	rootDir, err := ioutil.TempDir("", "rescan_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dirname := range []string{"a", "b"} {
+		if err := os.Mkdir(path.Join(rootDir, dirname), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(t, rootDir, "/a/file", "old data")
+	writeFile(t, rootDir, "/a/other", "other data")
+	writeFile(t, rootDir, "/b/file", "unchanged data")
+	return rootDir
+}
+
+func writeFile(t *testing.T, rootDir, pathname, data string) {
+	err := ioutil.WriteFile(path.Join(rootDir, pathname), []byte(data), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func getInode(t *testing.T, fs *FileSystem,
+	pathname string) filesystem.GenericInode {
+	inodeNumber, ok := fs.FilenameToInodeTable()[pathname]
+	if !ok {
+		t.Fatalf("%s: not found", pathname)
+	}
+	return fs.InodeTable[inodeNumber]
+}
+
+func scan(t *testing.T, rootDir string, oldFS *FileSystem,
+	changedPaths map[string]struct{}) (*FileSystem, error) {
+	if changedPaths == nil {
+		return ScanFileSystem(rootDir, nil, nil, nil, nil, oldFS)
+	}
+	return ScanFileSystemChanges(rootDir, nil, nil, nil, nil, oldFS,
+		changedPaths)
+}
+
+func TestRescanCopiesUnchangedDirectories(t *testing.T) {
+	rootDir := makeTree(t)
+	defer os.RemoveAll(rootDir)
+	oldFS, err := scan(t, rootDir, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, rootDir, "/a/file", "new data")
+	newFS, err := scan(t, rootDir, oldFS,
+		map[string]struct{}{"/a/file": {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldInode := getInode(t, oldFS, "/a/file").(*filesystem.RegularInode)
+	newInode := getInode(t, newFS, "/a/file").(*filesystem.RegularInode)
+	if newInode.Hash == oldInode.Hash {
+		t.Error("/a/file: changed file was not hashed again")
+	}
+	for _, pathname := range []string{"/a/other", "/b", "/b/file"} {
+		if getInode(t, newFS, pathname) != getInode(t, oldFS, pathname) {
+			t.Errorf("%s: not copied from previous scan", pathname)
+		}
+	}
+	fullFS, err := scan(t, rootDir, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !filesystem.CompareFileSystems(&newFS.FileSystem, &fullFS.FileSystem,
+		nil) {
+		t.Error("incremental scan differs from full scan")
+	}
+}
+
+func TestRescanHardlinks(t *testing.T) {
+	rootDir := makeTree(t)
+	defer os.RemoveAll(rootDir)
+	err := os.Link(path.Join(rootDir, "a", "file"),
+		path.Join(rootDir, "b", "link"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldFS, err := scan(t, rootDir, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, rootDir, "/a/other", "new other data")
+	newFS, err := scan(t, rootDir, oldFS,
+		map[string]struct{}{"/a/other": {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if getInode(t, newFS, "/a/file") != getInode(t, newFS, "/b/link") {
+		t.Error("hardlinked files do not share an inode")
+	}
+	writeFile(t, rootDir, "/a/file", "new data")
+	_, err = scan(t, rootDir, newFS, map[string]struct{}{"/a/file": {}})
+	if err != ErrRescanRequired {
+		t.Errorf("changed hardlink: got error: %v, expected: %s",
+			err, ErrRescanRequired)
+	}
+}
+
+func TestRescanRequiresPreviousScan(t *testing.T) {
+	rootDir := makeTree(t)
+	defer os.RemoveAll(rootDir)
+	_, err := scan(t, rootDir, nil, map[string]struct{}{"/a/file": {}})
+	if err != ErrRescanRequired {
+		t.Errorf("got error: %v, expected: %s", err, ErrRescanRequired)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/Symantec/Dominator/lib/filesystem"
 	"github.com/Symantec/Dominator/lib/filter"
 	"github.com/Symantec/Dominator/lib/fsrateio"
+	"github.com/Symantec/Dominator/lib/fsutil"
 	"github.com/Symantec/Dominator/lib/hash"
 	"github.com/Symantec/Dominator/lib/wsyscall"
 )
@@ -59,8 +60,8 @@ func makeSpecialInode(stat *wsyscall.Stat_t) *filesystem.SpecialInode {
 
 func scanFileSystem(rootDirectoryName string,
 	fsScanContext *fsrateio.ReaderContext, scanFilter *filter.Filter,
-	checkScanDisableRequest func() bool, hasher Hasher, oldFS *FileSystem) (
-	*FileSystem, error) {
+	checkScanDisableRequest func() bool, hasher Hasher, oldFS *FileSystem,
+	changedPaths map[string]struct{}) (*FileSystem, error) {
 	if checkScanDisableRequest != nil && checkScanDisableRequest() {
 		return nil, errors.New("DisableScan")
 	}
@@ -69,6 +70,10 @@ func scanFileSystem(rootDirectoryName string,
 	fileSystem.fsScanContext = fsScanContext
 	fileSystem.scanFilter = scanFilter
 	fileSystem.checkScanDisableRequest = checkScanDisableRequest
+	if changedPaths != nil {
+		fileSystem.changedPaths = changedPaths
+		fileSystem.dirtyDirectories = makeDirtyDirectories(changedPaths)
+	}
 	if hasher == nil {
 		fileSystem.hasher = GetSimpleHasher(false)
 	} else {
@@ -84,6 +89,11 @@ func scanFileSystem(rootDirectoryName string,
 	fileSystem.Mode = filesystem.FileMode(stat.Mode)
 	fileSystem.Uid = stat.Uid
 	fileSystem.Gid = stat.Gid
+	if xattrs, err := fsutil.GetXattrs(rootDirectoryName); err != nil {
+		return nil, err
+	} else {
+		fileSystem.Xattrs = xattrs
+	}
 	fileSystem.DirectoryCount++
 	var tmpInode filesystem.RegularInode
 	if sha512.New().Size() != len(tmpInode.Hash) {
@@ -109,6 +119,13 @@ func scanFileSystem(rootDirectoryName string,
 
 func scanDirectory(directory, oldDirectory *filesystem.DirectoryInode,
 	fileSystem, oldFS *FileSystem, myPathName string) (error, bool) {
+	if oldDirectory != nil && !fileSystem.isDirty(myPathName) {
+		if err := fileSystem.copyDirectory(oldDirectory); err != nil {
+			return err, false
+		}
+		directory.EntryList = oldDirectory.EntryList
+		return nil, true
+	}
 	file, err := os.Open(path.Join(fileSystem.rootDirectoryName, myPathName))
 	if err != nil {
 		return err, false
@@ -156,6 +173,8 @@ func scanDirectory(directory, oldDirectory *filesystem.DirectoryInode,
 			if len(oldDirectory.EntryList) > index &&
 				oldDirectory.EntryList[index].Name == name {
 				oldDirent = oldDirectory.EntryList[index]
+			} else if fileSystem.changedPaths != nil {
+				oldDirent = findDirent(oldDirectory.EntryList, name)
 			}
 		}
 		if stat.Mode&syscall.S_IFMT == syscall.S_IFDIR {
@@ -168,7 +187,8 @@ func scanDirectory(directory, oldDirectory *filesystem.DirectoryInode,
 		} else if stat.Mode&syscall.S_IFMT == syscall.S_IFSOCK {
 			continue
 		} else {
-			err = addSpecialFile(dirent, fileSystem, oldFS, &stat)
+			err = addSpecialFile(dirent, fileSystem, oldFS, myPathName,
+				&stat)
 		}
 		if err != nil {
 			if err == syscall.ENOENT {
@@ -208,6 +228,10 @@ func addDirectory(dirent, oldDirent *filesystem.DirectoryEntry,
 	inode.Mode = filesystem.FileMode(stat.Mode)
 	inode.Uid = stat.Uid
 	inode.Gid = stat.Gid
+	err := scanXattrs(&inode.Xattrs, fileSystem, myPathName)
+	if err != nil {
+		return err
+	}
 	var oldInode *filesystem.DirectoryInode
 	if oldDirent != nil {
 		if oi, ok := oldDirent.Inode().(*filesystem.DirectoryInode); ok {
@@ -229,17 +253,35 @@ func addDirectory(dirent, oldDirent *filesystem.DirectoryEntry,
 func addRegularFile(dirent *filesystem.DirectoryEntry,
 	fileSystem, oldFS *FileSystem,
 	directoryPathName string, stat *wsyscall.Stat_t) error {
-	if inode, ok := fileSystem.InodeTable[stat.Ino]; ok {
-		if inode, ok := inode.(*filesystem.RegularInode); ok {
-			dirent.SetInode(inode)
+	inode := makeRegularInode(stat)
+	pathname := path.Join(directoryPathName, dirent.Name)
+	if err := scanXattrs(&inode.Xattrs, fileSystem, pathname); err != nil {
+		return err
+	}
+	if tableInode, ok := fileSystem.InodeTable[stat.Ino]; ok {
+		if tableInode, ok := tableInode.(*filesystem.RegularInode); ok {
+			if fileSystem.changedPaths != nil &&
+				!isSameRegularInode(inode, tableInode) {
+				// Hardlinked to an inode copied from the previous scan.
+				return ErrRescanRequired
+			}
+			dirent.SetInode(tableInode)
 			return nil
 		}
 		return errors.New("inode changed type: " + dirent.Name)
 	}
-	inode := makeRegularInode(stat)
+	if oldInode := fileSystem.getUnchangedInode(oldFS, inode, pathname,
+		stat); oldInode != nil {
+		dirent.SetInode(oldInode)
+		fileSystem.InodeTable[stat.Ino] = oldInode
+		return nil
+	}
+	if fileSystem.changedPaths != nil && stat.Nlink > 1 {
+		// Other links may be in directories copied from the previous scan.
+		return ErrRescanRequired
+	}
 	if inode.Size > 0 {
-		err := scanRegularInode(inode, fileSystem,
-			path.Join(directoryPathName, dirent.Name))
+		err := scanRegularInode(inode, fileSystem, pathname)
 		if err != nil {
 			return err
 		}
@@ -269,9 +311,11 @@ func addSymlink(dirent *filesystem.DirectoryEntry,
 		return errors.New("inode changed type: " + dirent.Name)
 	}
 	inode := makeSymlinkInode(stat)
-	err := scanSymlinkInode(inode, fileSystem,
-		path.Join(directoryPathName, dirent.Name))
-	if err != nil {
+	pathname := path.Join(directoryPathName, dirent.Name)
+	if err := scanSymlinkInode(inode, fileSystem, pathname); err != nil {
+		return err
+	}
+	if err := scanXattrs(&inode.Xattrs, fileSystem, pathname); err != nil {
 		return err
 	}
 	if oldFS != nil && oldFS.InodeTable != nil {
@@ -289,7 +333,8 @@ func addSymlink(dirent *filesystem.DirectoryEntry,
 }
 
 func addSpecialFile(dirent *filesystem.DirectoryEntry,
-	fileSystem, oldFS *FileSystem, stat *wsyscall.Stat_t) error {
+	fileSystem, oldFS *FileSystem,
+	directoryPathName string, stat *wsyscall.Stat_t) error {
 	if inode, ok := fileSystem.InodeTable[stat.Ino]; ok {
 		if inode, ok := inode.(*filesystem.SpecialInode); ok {
 			dirent.SetInode(inode)
@@ -298,6 +343,11 @@ func addSpecialFile(dirent *filesystem.DirectoryEntry,
 		return errors.New("inode changed type: " + dirent.Name)
 	}
 	inode := makeSpecialInode(stat)
+	err := scanXattrs(&inode.Xattrs, fileSystem,
+		path.Join(directoryPathName, dirent.Name))
+	if err != nil {
+		return err
+	}
 	if oldFS != nil && oldFS.InodeTable != nil {
 		if oldInode, found := oldFS.InodeTable[stat.Ino]; found {
 			if oldInode, ok := oldInode.(*filesystem.SpecialInode); ok {
@@ -357,6 +407,17 @@ func scanRegularInode(inode *filesystem.RegularInode, fileSystem *FileSystem,
 	return nil
 }
 
+func scanXattrs(xattrs *map[string][]byte, fileSystem *FileSystem,
+	myPathName string) error {
+	value, err := fsutil.GetXattrs(path.Join(fileSystem.rootDirectoryName,
+		myPathName))
+	if err != nil {
+		return err
+	}
+	*xattrs = value
+	return nil
+}
+
 func scanSymlinkInode(inode *filesystem.SymlinkInode, fileSystem *FileSystem,
 	myPathName string) error {
 	target, err := os.Readlink(path.Join(fileSystem.rootDirectoryName,
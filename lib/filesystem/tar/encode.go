@@ -12,6 +12,8 @@ import (
 	"github.com/Symantec/Dominator/lib/objectserver"
 )
 
+const paxXattrPrefix = "SCHILY.xattr."
+
 func encode(tarWriter *tar.Writer, fileSystem *filesystem.FileSystem,
 	objectsGetter objectserver.ObjectsGetter) error {
 	hashList := getOrderedObjectsList(fileSystem)
@@ -58,6 +60,7 @@ func writeDirectory(tarWriter *tar.Writer, fileSystem *filesystem.FileSystem,
 		Gid:      int(inode.Gid),
 		Typeflag: tar.TypeDir,
 	}
+	setXattrs(&header, inode.Xattrs)
 	if err := tarWriter.WriteHeader(&header); err != nil {
 		return err
 	}
@@ -85,6 +88,7 @@ func writeRegularFile(tarWriter *tar.Writer, fileSystem *filesystem.FileSystem,
 		ModTime:  time.Unix(inode.MtimeSeconds, int64(inode.MtimeNanoSeconds)),
 		Typeflag: tar.TypeReg,
 	}
+	setXattrs(&header, inode.Xattrs)
 	err := writeHeader(tarWriter, fileSystem, &header, inodeNumber,
 		inodeTable)
 	if err != nil {
@@ -171,6 +175,7 @@ func writeSpecial(tarWriter *tar.Writer, fileSystem *filesystem.FileSystem,
 	} else {
 		return fmt.Errorf("unsupported inode mode: %d", inode.Mode)
 	}
+	setXattrs(&header, inode.Xattrs)
 	return writeHeader(tarWriter, fileSystem, &header, inodeNumber, inodeTable)
 }
 
@@ -185,5 +190,18 @@ func writeSymlink(tarWriter *tar.Writer, fileSystem *filesystem.FileSystem,
 		Typeflag: tar.TypeSymlink,
 		Linkname: inode.Symlink,
 	}
+	setXattrs(&header, inode.Xattrs)
 	return writeHeader(tarWriter, fileSystem, &header, inodeNumber, inodeTable)
 }
+
+// setXattrs records extended attributes using the PAX format used by GNU tar
+// and bsdtar.
+func setXattrs(header *tar.Header, xattrs map[string][]byte) {
+	if len(xattrs) < 1 {
+		return
+	}
+	header.PAXRecords = make(map[string]string, len(xattrs))
+	for name, value := range xattrs {
+		header.PAXRecords[paxXattrPrefix+name] = string(value)
+	}
+}
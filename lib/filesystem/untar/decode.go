@@ -13,6 +13,8 @@ import (
 	"github.com/Symantec/Dominator/lib/filter"
 )
 
+const paxXattrPrefix = "SCHILY.xattr."
+
 type decoderData struct {
 	nextInodeNumber uint64
 	fileSystem      filesystem.FileSystem
@@ -114,6 +116,7 @@ func (decoderData *decoderData) addRegularFile(tarReader *tar.Reader,
 	newInode.MtimeNanoSeconds = int32(header.ModTime.Nanosecond())
 	newInode.MtimeSeconds = header.ModTime.Unix()
 	newInode.Size = uint64(header.Size)
+	newInode.Xattrs = getXattrs(header)
 	if header.Size > 0 {
 		var err error
 		newInode.Hash, err = hasher.Hash(tarReader, uint64(header.Size))
@@ -132,6 +135,7 @@ func (decoderData *decoderData) addDirectory(header *tar.Header,
 		syscall.S_IFDIR)
 	newInode.Uid = uint32(header.Uid)
 	newInode.Gid = uint32(header.Gid)
+	newInode.Xattrs = getXattrs(header)
 	if header.Name == "/" {
 		*decoderData.directoryTable[header.Name] = newInode
 		return nil
@@ -162,6 +166,7 @@ func (decoderData *decoderData) addSymlink(header *tar.Header,
 	newInode.Uid = uint32(header.Uid)
 	newInode.Gid = uint32(header.Gid)
 	newInode.Symlink = header.Linkname
+	newInode.Xattrs = getXattrs(header)
 	decoderData.addEntry(parent, header.Name, name, &newInode)
 	return nil
 }
@@ -190,10 +195,26 @@ func (decoderData *decoderData) addSpecialFile(header *tar.Header,
 			header.Devminor))
 	}
 	newInode.Rdev = uint64(header.Devmajor<<8 | header.Devminor)
+	newInode.Xattrs = getXattrs(header)
 	decoderData.addEntry(parent, header.Name, name, &newInode)
 	return nil
 }
 
+// getXattrs returns the extended attributes recorded in PAX records.
+func getXattrs(header *tar.Header) map[string][]byte {
+	var xattrs map[string][]byte
+	for key, value := range header.PAXRecords {
+		if !strings.HasPrefix(key, paxXattrPrefix) {
+			continue
+		}
+		if xattrs == nil {
+			xattrs = make(map[string][]byte)
+		}
+		xattrs[key[len(paxXattrPrefix):]] = []byte(value)
+	}
+	return xattrs
+}
+
 func (decoderData *decoderData) addEntry(parent *filesystem.DirectoryInode,
 	fullName, name string, inode filesystem.GenericInode) {
 	var newEntry filesystem.DirectoryEntry
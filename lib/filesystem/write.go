@@ -11,6 +11,13 @@ import (
 
 var modePerm FileMode = syscall.S_IRWXU | syscall.S_IRWXG | syscall.S_IRWXO
 
+// hostXattrNames are the extended attributes which the host may set itself
+// (such as SELinux labels). They are only managed for inodes which have them in
+// the image. All other extended attributes are always managed.
+var hostXattrNames = map[string]struct{}{
+	"security.selinux": {},
+}
+
 func forceWriteMetadata(inode GenericInode, name string) error {
 	err := inode.WriteMetadata(name)
 	if err == nil {
@@ -23,6 +30,37 @@ func forceWriteMetadata(inode GenericInode, name string) error {
 	return inode.WriteMetadata(name)
 }
 
+// setXattrs will set the extended attributes for name. Other extended
+// attributes are removed, except for host owned extended attributes, which are
+// left alone if they are not in xattrs.
+func setXattrs(name string, xattrs map[string][]byte) error {
+	oldXattrs, err := fsutil.GetXattrs(name)
+	if err != nil {
+		return err
+	}
+	var newXattrs map[string][]byte
+	for hostName := range hostXattrNames {
+		value, ok := oldXattrs[hostName]
+		if !ok {
+			continue
+		}
+		if _, ok := xattrs[hostName]; ok {
+			continue
+		}
+		if newXattrs == nil {
+			newXattrs = make(map[string][]byte, len(xattrs)+1)
+			for name, value := range xattrs {
+				newXattrs[name] = value
+			}
+		}
+		newXattrs[hostName] = value
+	}
+	if newXattrs == nil {
+		newXattrs = xattrs
+	}
+	return fsutil.SetXattrs(name, newXattrs)
+}
+
 func (inode *DirectoryInode) write(name string) error {
 	if err := inode.make(name); err != nil {
 		// If existing directory, don't blow it away, just update metadata.
@@ -47,7 +85,10 @@ func (inode *DirectoryInode) writeMetadata(name string) error {
 	if err := os.Lchown(name, int(inode.Uid), int(inode.Gid)); err != nil {
 		return err
 	}
-	return syscall.Chmod(name, uint32(inode.Mode))
+	if err := syscall.Chmod(name, uint32(inode.Mode)); err != nil {
+		return err
+	}
+	return setXattrs(name, inode.Xattrs)
 }
 
 func (inode *RegularInode) writeMetadata(name string) error {
@@ -57,6 +98,9 @@ func (inode *RegularInode) writeMetadata(name string) error {
 	if err := syscall.Chmod(name, uint32(inode.Mode)); err != nil {
 		return err
 	}
+	if err := setXattrs(name, inode.Xattrs); err != nil {
+		return err
+	}
 	t := time.Unix(inode.MtimeSeconds, int64(inode.MtimeNanoSeconds))
 	return os.Chtimes(name, t, t)
 }
@@ -76,7 +120,10 @@ func (inode *SymlinkInode) make(name string) error {
 }
 
 func (inode *SymlinkInode) writeMetadata(name string) error {
-	return os.Lchown(name, int(inode.Uid), int(inode.Gid))
+	if err := os.Lchown(name, int(inode.Uid), int(inode.Gid)); err != nil {
+		return err
+	}
+	return setXattrs(name, inode.Xattrs)
 }
 
 func (inode *SpecialInode) write(name string) error {
@@ -106,6 +153,9 @@ func (inode *SpecialInode) writeMetadata(name string) error {
 	if err := syscall.Chmod(name, uint32(inode.Mode)); err != nil {
 		return err
 	}
+	if err := setXattrs(name, inode.Xattrs); err != nil {
+		return err
+	}
 	t := time.Unix(inode.MtimeSeconds, int64(inode.MtimeNanoSeconds))
 	return os.Chtimes(name, t, t)
 }
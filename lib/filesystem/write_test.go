@@ -0,0 +1,109 @@
+package filesystem
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/Symantec/Dominator/lib/fsutil"
+)
+
+// netBindCapability is a VFS_CAP_REVISION_2 security.capability value granting
+// CAP_NET_BIND_SERVICE.
+var netBindCapability = []byte{0, 0, 0, 2, 0, 4, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0}
+
+func makeXattrsTestFile(t *testing.T, xattrs map[string][]byte) (
+	string, func()) {
+	dirname, err := ioutil.TempDir("", "write_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(dirname, "file")
+	if err := ioutil.WriteFile(filename, nil, 0644); err != nil {
+		os.RemoveAll(dirname)
+		t.Fatal(err)
+	}
+	if err := fsutil.SetXattrs(filename, xattrs); err != nil {
+		os.RemoveAll(dirname)
+		t.Skipf("cannot set xattrs: %s", err)
+	}
+	return filename, func() { os.RemoveAll(dirname) }
+}
+
+func makeXattrsTestInode(xattrs map[string][]byte) *RegularInode {
+	return &RegularInode{
+		Mode:   syscall.S_IFREG | 0600,
+		Uid:    uint32(os.Getuid()),
+		Gid:    uint32(os.Getgid()),
+		Xattrs: xattrs,
+	}
+}
+
+func TestWriteMetadataXattrs(t *testing.T) {
+	savedHostXattrNames := hostXattrNames
+	defer func() { hostXattrNames = savedHostXattrNames }()
+	hostXattrNames = map[string]struct{}{"user.host": {}}
+	value := []byte("value")
+	otherValue := []byte("other value")
+	var tests = []struct {
+		name     string
+		xattrs   map[string][]byte
+		expected map[string][]byte
+	}{
+		{
+			name:     "none in image",
+			expected: map[string][]byte{"user.host": value},
+		},
+		{
+			name:   "other in image",
+			xattrs: map[string][]byte{"user.other": value},
+			expected: map[string][]byte{
+				"user.host":  value,
+				"user.other": value,
+			},
+		},
+		{
+			name:     "host in image",
+			xattrs:   map[string][]byte{"user.host": otherValue},
+			expected: map[string][]byte{"user.host": otherValue},
+		},
+	}
+	for _, test := range tests {
+		filename, cleanup := makeXattrsTestFile(t, map[string][]byte{
+			"user.host":  value,
+			"user.stray": value,
+		})
+		defer cleanup()
+		if err := makeXattrsTestInode(test.xattrs).WriteMetadata(
+			filename); err != nil {
+			t.Fatal(err)
+		}
+		xattrs, err := fsutil.GetXattrs(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !compareXattrs(xattrs, test.expected, nil) {
+			t.Errorf("%s: xattrs: %v, expected: %v",
+				test.name, xattrs, test.expected)
+		}
+	}
+}
+
+func TestWriteMetadataStripsCapability(t *testing.T) {
+	filename, cleanup := makeXattrsTestFile(t,
+		map[string][]byte{"security.capability": netBindCapability})
+	defer cleanup()
+	if err := makeXattrsTestInode(nil).WriteMetadata(filename); err != nil {
+		t.Fatal(err)
+	}
+	xattrs, err := fsutil.GetXattrs(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := xattrs["security.capability"]; ok {
+		t.Errorf("stray capability not removed: %v", xattrs)
+	}
+}
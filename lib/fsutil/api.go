@@ -83,6 +83,13 @@ func ForceRename(oldpath, newpath string) error {
 	return forceRename(oldpath, newpath)
 }
 
+// GetXattrs returns the extended attributes (including POSIX ACLs and file
+// capabilities) of the file given by pathname, without following symlinks. If
+// there are none or the file-system does not support them, nil is returned.
+func GetXattrs(pathname string) (map[string][]byte, error) {
+	return getXattrs(pathname)
+}
+
 // LoadLines will open a file and read lines from it. Comment lines (i.e. lines
 // beginning with '#') are skipped.
 func LoadLines(filename string) ([]string, error) {
@@ -103,6 +110,12 @@ func ReadDirnames(dirname string, ignoreMissing bool) ([]string, error) {
 	return readDirnames(dirname, ignoreMissing)
 }
 
+// SetXattrs will set the extended attributes of the file given by pathname,
+// without following symlinks. Any other extended attributes are removed.
+func SetXattrs(pathname string, xattrs map[string][]byte) error {
+	return setXattrs(pathname, xattrs)
+}
+
 // WaitFile waits for the file given by pathname to become available to read and
 // yields a io.ReadCloser when available, or an error if the timeout is
 // exceeded or an error (other than file not existing) is encountered. A
@@ -0,0 +1,118 @@
+package fsutil
+
+import (
+	"bytes"
+	"syscall"
+
+	"github.com/Symantec/Dominator/lib/wsyscall"
+)
+
+func getXattrs(pathname string) (map[string][]byte, error) {
+	names, err := listXattrs(pathname)
+	if err != nil {
+		if err == syscall.ENOTSUP {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(names) < 1 {
+		return nil, nil
+	}
+	xattrs := make(map[string][]byte, len(names))
+	for _, name := range names {
+		value, err := getXattr(pathname, name)
+		if err != nil {
+			if err == syscall.ENODATA {
+				continue // Removed since listing.
+			}
+			return nil, err
+		}
+		xattrs[name] = value
+	}
+	if len(xattrs) < 1 {
+		return nil, nil
+	}
+	return xattrs, nil
+}
+
+func listXattrs(pathname string) ([]string, error) {
+	for {
+		size, err := wsyscall.Llistxattr(pathname, nil)
+		if err != nil || size < 1 {
+			return nil, err
+		}
+		buffer := make([]byte, size)
+		size, err = wsyscall.Llistxattr(pathname, buffer)
+		if err != nil {
+			if err == syscall.ERANGE {
+				continue // Grew since the size was read.
+			}
+			return nil, err
+		}
+		var names []string
+		for _, name := range bytes.Split(buffer[:size], []byte{0}) {
+			if len(name) > 0 {
+				names = append(names, string(name))
+			}
+		}
+		return names, nil
+	}
+}
+
+func getXattr(pathname, name string) ([]byte, error) {
+	for {
+		size, err := wsyscall.Lgetxattr(pathname, name, nil)
+		if err != nil {
+			return nil, err
+		}
+		value := make([]byte, size)
+		if size < 1 {
+			return value, nil
+		}
+		size, err = wsyscall.Lgetxattr(pathname, name, value)
+		if err != nil {
+			if err == syscall.ERANGE {
+				continue // Grew since the size was read.
+			}
+			return nil, err
+		}
+		return value[:size], nil
+	}
+}
+
+func setXattrs(pathname string, xattrs map[string][]byte) error {
+	oldXattrs, err := getXattrs(pathname)
+	if err != nil {
+		return err
+	}
+	for name := range oldXattrs {
+		if _, ok := xattrs[name]; ok {
+			continue
+		}
+		err := wsyscall.Lremovexattr(pathname, name)
+		if err != nil && err != syscall.ENODATA {
+			return &wrappedError{"removexattr", pathname, name, err}
+		}
+	}
+	for name, value := range xattrs {
+		if oldValue, ok := oldXattrs[name]; ok &&
+			bytes.Equal(oldValue, value) {
+			continue
+		}
+		if err := wsyscall.Lsetxattr(pathname, name, value, 0); err != nil {
+			return &wrappedError{"setxattr", pathname, name, err}
+		}
+	}
+	return nil
+}
+
+type wrappedError struct {
+	op       string
+	pathname string
+	name     string
+	err      error
+}
+
+func (e *wrappedError) Error() string {
+	return e.op + " " + e.pathname + ": " + e.name + ": " + e.err.Error()
+}
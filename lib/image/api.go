@@ -1,6 +1,7 @@
 package image
 
 import (
+	"crypto"
 	"time"
 
 	"github.com/Symantec/Dominator/lib/filesystem"
@@ -8,6 +9,7 @@ import (
 	"github.com/Symantec/Dominator/lib/hash"
 	"github.com/Symantec/Dominator/lib/log"
 	"github.com/Symantec/Dominator/lib/objectserver"
+	"github.com/Symantec/Dominator/lib/tags"
 	"github.com/Symantec/Dominator/lib/triggers"
 )
 
@@ -17,7 +19,23 @@ type Annotation struct {
 }
 
 type DirectoryMetadata struct {
-	OwnerGroup string
+	OwnerGroup      string
+	RetentionPolicy RetentionPolicy
+}
+
+// Diff describes the changes needed to move from one image to another. Sizes
+// are in bytes and pathnames are sorted.
+type Diff struct {
+	AddedFiles         []FileSize
+	RemovedFiles       []FileSize
+	ChangedFiles       []FileChange
+	PackageChanges     []PackageChange
+	FilterLinesAdded   []string
+	FilterLinesRemoved []string
+	TriggersAdded      []string // Service names.
+	TriggersRemoved    []string // Service names.
+	TriggersChanged    []string // Service names.
+	TransferBytes      uint64   // Object data a sub would need to fetch.
 }
 
 type Directory struct {
@@ -25,6 +43,37 @@ type Directory struct {
 	Metadata DirectoryMetadata
 }
 
+// RetentionPolicy specifies which of the images directly in a directory the
+// imageserver keeps. An image is kept if any of the rules keeps it. If neither
+// KeepNewest nor KeepYoungerThan is set the policy is inactive and all images
+// are kept.
+type RetentionPolicy struct {
+	KeepNewest      uint          // Keep the newest N images.
+	KeepYoungerThan time.Duration // Keep images created more recently.
+	KeepMdbImages   bool          // Keep images referenced by the MDB.
+}
+
+// IsActive returns true if the policy may cause images to be deleted.
+func (policy RetentionPolicy) IsActive() bool {
+	return policy.KeepNewest > 0 || policy.KeepYoungerThan > 0
+}
+
+func (policy RetentionPolicy) String() string {
+	return policy.string()
+}
+
+type FileChange struct {
+	Name        string
+	OldSize     uint64
+	NewSize     uint64
+	DataChanged bool // If false, only the metadata (or type) changed.
+}
+
+type FileSize struct {
+	Name string
+	Size uint64
+}
+
 type Image struct {
 	CreatedBy    string // Username. Set by imageserver. Empty: unauthenticated.
 	Filter       *filter.Filter
@@ -35,6 +84,40 @@ type Image struct {
 	CreatedOn    time.Time
 	ExpiresAt    time.Time
 	Packages     []Package
+	Signatures   []Signature
+	Labels       tags.Tags // May be changed after the image is added.
+}
+
+// Signature is a detached signature over the canonical encoding of the
+// FileSystem, Filter, Triggers and annotations of an image.
+type Signature struct {
+	PublicKey []byte    // DER encoded PKIX public key of the signer.
+	Signature []byte    // Signature over the digest from Image.Digest.
+	SignedBy  string    // Username. Set by imageserver.
+	SignedOn  time.Time // Set by imageserver.
+}
+
+// LoadSigningKey will load a PKCS#8 PEM encoded private key from a file, for
+// signing images.
+func LoadSigningKey(filename string) (crypto.Signer, error) {
+	return loadSigningKey(filename)
+}
+
+// LoadVerifyingKeys will load one or more PEM encoded public keys from a file,
+// for verifying image signatures.
+func LoadVerifyingKeys(filename string) ([]crypto.PublicKey, error) {
+	return loadVerifyingKeys(filename)
+}
+
+// KeyFingerprint returns the SHA-256 checksum of the signer public key.
+func (signature *Signature) KeyFingerprint() string {
+	return signature.keyFingerprint()
+}
+
+// Verify will check that the signature is valid for the image digest, using
+// the public key in the signature. It does not establish trust in the key.
+func (signature *Signature) Verify(digest hash.Hash) error {
+	return signature.verify(digest)
 }
 
 type Package struct {
@@ -43,6 +126,28 @@ type Package struct {
 	Version string
 }
 
+// PackageChange describes a package version change. OldVersion is empty for
+// added packages and NewVersion is empty for removed packages.
+type PackageChange struct {
+	Name       string
+	OldVersion string
+	NewVersion string
+}
+
+// Diff returns the differences between the image and newImage, from the point
+// of view of a sub moving from the image to newImage.
+func (image *Image) Diff(newImage *Image) (*Diff, error) {
+	return image.diff(newImage)
+}
+
+// Digest returns the SHA-512 checksum of a canonical encoding of the
+// FileSystem, Filter, Triggers and annotations of the image. The inode
+// pointers need not be built and the encoding is unaffected by the order of
+// directory entries and inode numbering.
+func (image *Image) Digest() (hash.Hash, error) {
+	return image.digest()
+}
+
 // ForEachObject will call objectFunc for all objects (including those for
 // annotations) for the image. If objectFunc returns a non-nil error, processing
 // stops and the error is returned.
@@ -69,6 +174,12 @@ func (image *Image) ListObjects() []hash.Hash {
 	return image.listObjects()
 }
 
+// Sign will sign the image digest with signer, returning the signature. The
+// signature is not added to the image. ECDSA and RSA keys are supported.
+func (image *Image) Sign(signer crypto.Signer) (*Signature, error) {
+	return image.sign(signer)
+}
+
 func (image *Image) ReplaceStrings(replaceFunc func(string) string) {
 	image.replaceStrings(replaceFunc)
 }
@@ -79,6 +190,12 @@ func (image *Image) Verify() error {
 	return image.verify()
 }
 
+// VerifySignatures will check that the image has at least one valid signature
+// made by one of trustedKeys. If not, an error is returned.
+func (image *Image) VerifySignatures(trustedKeys []crypto.PublicKey) error {
+	return image.verifySignatures(trustedKeys)
+}
+
 func (image *Image) VerifyObjects(checker objectserver.ObjectsChecker) error {
 	return image.verifyObjects(checker)
 }
@@ -0,0 +1,202 @@
+package image
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/Symantec/Dominator/lib/filesystem"
+	"github.com/Symantec/Dominator/lib/triggers"
+)
+
+func (image *Image) diff(newImage *Image) (*Diff, error) {
+	if image.FileSystem == nil || newImage.FileSystem == nil {
+		return nil, errors.New("image has no file-system")
+	}
+	oldInodes, err := getPathInodes(image.FileSystem)
+	if err != nil {
+		return nil, err
+	}
+	newInodes, err := getPathInodes(newImage.FileSystem)
+	if err != nil {
+		return nil, err
+	}
+	diff := &Diff{}
+	for name, newInode := range newInodes {
+		oldInode, ok := oldInodes[name]
+		if !ok {
+			diff.AddedFiles = append(diff.AddedFiles,
+				FileSize{name, getInodeSize(newInode)})
+			continue
+		}
+		sameType, sameMetadata, sameData := filesystem.CompareInodes(oldInode,
+			newInode, nil)
+		if _, ok := newInode.(*filesystem.DirectoryInode); ok && sameType {
+			sameData = true
+		}
+		if sameType && sameMetadata && sameData {
+			continue
+		}
+		diff.ChangedFiles = append(diff.ChangedFiles, FileChange{
+			Name:        name,
+			OldSize:     getInodeSize(oldInode),
+			NewSize:     getInodeSize(newInode),
+			DataChanged: !sameType || !sameData,
+		})
+	}
+	for name, oldInode := range oldInodes {
+		if _, ok := newInodes[name]; !ok {
+			diff.RemovedFiles = append(diff.RemovedFiles,
+				FileSize{name, getInodeSize(oldInode)})
+		}
+	}
+	sort.Sort(fileSizeList(diff.AddedFiles))
+	sort.Sort(fileSizeList(diff.RemovedFiles))
+	sort.Sort(fileChangeList(diff.ChangedFiles))
+	diff.PackageChanges = diffPackages(image.Packages, newImage.Packages)
+	diff.FilterLinesAdded, diff.FilterLinesRemoved = diffStrings(
+		getFilterLines(image), getFilterLines(newImage))
+	diff.TriggersAdded, diff.TriggersRemoved, diff.TriggersChanged, err =
+		diffTriggers(image.Triggers, newImage.Triggers)
+	if err != nil {
+		return nil, err
+	}
+	oldObjects := image.FileSystem.GetObjects()
+	for hashVal, size := range newImage.FileSystem.GetObjects() {
+		if _, ok := oldObjects[hashVal]; !ok {
+			diff.TransferBytes += size
+		}
+	}
+	return diff, nil
+}
+
+func diffPackages(oldPackages, newPackages []Package) []PackageChange {
+	oldVersions := make(map[string]string, len(oldPackages))
+	for _, pkg := range oldPackages {
+		oldVersions[pkg.Name] = pkg.Version
+	}
+	newVersions := make(map[string]string, len(newPackages))
+	for _, pkg := range newPackages {
+		newVersions[pkg.Name] = pkg.Version
+	}
+	var changes []PackageChange
+	for name, newVersion := range newVersions {
+		if oldVersion, ok := oldVersions[name]; !ok {
+			changes = append(changes, PackageChange{name, "", newVersion})
+		} else if oldVersion != newVersion {
+			changes = append(changes,
+				PackageChange{name, oldVersion, newVersion})
+		}
+	}
+	for name, oldVersion := range oldVersions {
+		if _, ok := newVersions[name]; !ok {
+			changes = append(changes, PackageChange{name, oldVersion, ""})
+		}
+	}
+	sort.Sort(packageChangeList(changes))
+	return changes
+}
+
+// diffStrings returns the sorted strings which are only in newStrings (added)
+// and only in oldStrings (removed).
+func diffStrings(oldStrings, newStrings []string) ([]string, []string) {
+	oldSet := make(map[string]struct{}, len(oldStrings))
+	for _, value := range oldStrings {
+		oldSet[value] = struct{}{}
+	}
+	newSet := make(map[string]struct{}, len(newStrings))
+	for _, value := range newStrings {
+		newSet[value] = struct{}{}
+	}
+	var added, removed []string
+	for value := range newSet {
+		if _, ok := oldSet[value]; !ok {
+			added = append(added, value)
+		}
+	}
+	for value := range oldSet {
+		if _, ok := newSet[value]; !ok {
+			removed = append(removed, value)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+func diffTriggers(oldTriggers, newTriggers *triggers.Triggers) (
+	[]string, []string, []string, error) {
+	oldEncoded, err := encodeTriggers(oldTriggers)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	newEncoded, err := encodeTriggers(newTriggers)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var added, removed, changed []string
+	for service, newData := range newEncoded {
+		if oldData, ok := oldEncoded[service]; !ok {
+			added = append(added, service)
+		} else if oldData != newData {
+			changed = append(changed, service)
+		}
+	}
+	for service := range oldEncoded {
+		if _, ok := newEncoded[service]; !ok {
+			removed = append(removed, service)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed, nil
+}
+
+// encodeTriggers returns a table of JSON encoded triggers, keyed by service.
+func encodeTriggers(trig *triggers.Triggers) (map[string]string, error) {
+	encoded := make(map[string]string)
+	if trig == nil {
+		return encoded, nil
+	}
+	for _, trigger := range trig.Triggers {
+		if data, err := json.Marshal(trigger); err != nil {
+			return nil, err
+		} else {
+			encoded[trigger.Service] = string(data)
+		}
+	}
+	return encoded, nil
+}
+
+func getFilterLines(image *Image) []string {
+	if image.Filter == nil {
+		return nil
+	}
+	return image.Filter.FilterLines
+}
+
+func getInodeSize(inode filesystem.GenericInode) uint64 {
+	if inode, ok := inode.(*filesystem.RegularInode); ok {
+		return inode.Size
+	}
+	return 0
+}
+
+// getPathInodes returns a table of inodes keyed by pathname. Unlike
+// FilenameToInodeTable, the result is not cached in the file-system, so it is
+// safe to use on shared images.
+func getPathInodes(fs *filesystem.FileSystem) (
+	map[string]filesystem.GenericInode, error) {
+	inodes := make(map[string]filesystem.GenericInode)
+	err := fs.ForEachFile(
+		func(name string, inodeNumber uint64,
+			inode filesystem.GenericInode) error {
+			inodes[name] = inode
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return inodes, nil
+}
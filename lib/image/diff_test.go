@@ -0,0 +1,140 @@
+package image
+
+import (
+	"path"
+	"reflect"
+	"sort"
+	"syscall"
+	"testing"
+
+	"github.com/Symantec/Dominator/lib/filesystem"
+	"github.com/Symantec/Dominator/lib/filter"
+	"github.com/Symantec/Dominator/lib/hash"
+	"github.com/Symantec/Dominator/lib/triggers"
+)
+
+func makeDiffTestImage(t *testing.T,
+	inodes map[string]filesystem.GenericInode, packages []Package,
+	filterLines []string, services map[string]bool) *Image {
+	fs := &filesystem.FileSystem{InodeTable: make(filesystem.InodeTable)}
+	fs.DirectoryInode.Mode = syscall.S_IFDIR | 0755
+	names := make([]string, 0, len(inodes))
+	for name := range inodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for index, name := range names {
+		parent := &fs.DirectoryInode
+		if dirname := path.Dir(name); dirname != "/" {
+			parent = inodes[dirname].(*filesystem.DirectoryInode)
+		}
+		inodeNumber := uint64(index + 1)
+		parent.EntryList = append(parent.EntryList,
+			&filesystem.DirectoryEntry{
+				Name:        path.Base(name),
+				InodeNumber: inodeNumber,
+			})
+		fs.InodeTable[inodeNumber] = inodes[name]
+	}
+	if err := fs.RebuildInodePointers(); err != nil {
+		t.Fatal(err)
+	}
+	imageFilter, err := filter.New(filterLines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trig := triggers.New()
+	for service, highImpact := range services {
+		trig.Triggers = append(trig.Triggers, &triggers.Trigger{
+			MatchLines: []string{"/etc/.*"},
+			Service:    service,
+			HighImpact: highImpact,
+		})
+	}
+	return &Image{
+		Filter:     imageFilter,
+		FileSystem: fs,
+		Triggers:   trig,
+		Packages:   packages,
+	}
+}
+
+func makeDirectoryInode(perm uint32) *filesystem.DirectoryInode {
+	return &filesystem.DirectoryInode{
+		Mode: filesystem.FileMode(syscall.S_IFDIR | perm),
+	}
+}
+
+func makeRegularInode(size uint64, hashByte byte,
+	uid uint32) *filesystem.RegularInode {
+	return &filesystem.RegularInode{
+		Mode: syscall.S_IFREG | 0644,
+		Uid:  uid,
+		Size: size,
+		Hash: hash.Hash{hashByte},
+	}
+}
+
+func TestDiff(t *testing.T) {
+	oldImage := makeDiffTestImage(t,
+		map[string]filesystem.GenericInode{
+			"/bin":      makeDirectoryInode(0755),
+			"/bin/a":    makeRegularInode(10, 1, 0),
+			"/bin/b":    makeRegularInode(5, 2, 0),
+			"/etc":      makeDirectoryInode(0755),
+			"/etc/conf": makeRegularInode(3, 3, 0),
+			"/etc/meta": makeRegularInode(2, 6, 0),
+		},
+		[]Package{{Name: "p1", Version: "1.0"}, {Name: "p2", Version: "1.0"}},
+		[]string{"/tmp/.*", "/var/log/.*"},
+		map[string]bool{"svcA": false, "svcB": false})
+	newImage := makeDiffTestImage(t,
+		map[string]filesystem.GenericInode{
+			"/bin":      makeDirectoryInode(0700),
+			"/bin/a":    makeRegularInode(10, 1, 0),
+			"/bin/c":    makeRegularInode(7, 4, 0),
+			"/etc":      makeDirectoryInode(0755),
+			"/etc/conf": makeRegularInode(4, 5, 0),
+			"/etc/meta": makeRegularInode(2, 6, 1),
+		},
+		[]Package{{Name: "p1", Version: "1.1"}, {Name: "p3", Version: "2.0"}},
+		[]string{"/tmp/.*", "/var/cache/.*"},
+		map[string]bool{"svcA": true, "svcC": false})
+	expected := &Diff{
+		AddedFiles:   []FileSize{{"/bin/c", 7}},
+		RemovedFiles: []FileSize{{"/bin/b", 5}},
+		ChangedFiles: []FileChange{
+			{Name: "/bin"},
+			{Name: "/etc/conf", OldSize: 3, NewSize: 4, DataChanged: true},
+			{Name: "/etc/meta", OldSize: 2, NewSize: 2},
+		},
+		PackageChanges: []PackageChange{
+			{"p1", "1.0", "1.1"},
+			{"p2", "1.0", ""},
+			{"p3", "", "2.0"},
+		},
+		FilterLinesAdded:   []string{"/var/cache/.*"},
+		FilterLinesRemoved: []string{"/var/log/.*"},
+		TriggersAdded:      []string{"svcC"},
+		TriggersRemoved:    []string{"svcB"},
+		TriggersChanged:    []string{"svcA"},
+		TransferBytes:      11,
+	}
+	diff, err := oldImage.Diff(newImage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("diff: %+v, expected: %+v", diff, expected)
+	}
+	diff, err = oldImage.Diff(oldImage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(diff, &Diff{}) {
+		t.Errorf("diff with self: %+v", diff)
+	}
+	if _, err := oldImage.Diff(&Image{}); err == nil {
+		t.Error("no error for image without a file-system")
+	}
+}
@@ -0,0 +1,262 @@
+package image
+
+import (
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"path"
+	"sort"
+
+	"github.com/Symantec/Dominator/lib/filesystem"
+	domhash "github.com/Symantec/Dominator/lib/hash"
+	"github.com/Symantec/Dominator/lib/triggers"
+)
+
+// Field tags for the canonical encoding. These must never change, otherwise
+// existing signatures will no longer verify.
+const (
+	tagFileSystem = iota + 1
+	tagFilter
+	tagTriggers
+	tagReleaseNotes
+	tagBuildLog
+	tagDirectory
+	tagRegular
+	tagComputed
+	tagSymlink
+	tagSpecial
+	tagHardlink
+	tagEndDirectory
+)
+
+// Field tags for triggers. Only fields with non-zero values are encoded, so
+// that adding a field does not change the encoding of existing triggers. These
+// must never change and new fields must use new tags.
+const (
+	tagTriggerEnd = iota
+	tagTriggerMatchLines
+	tagTriggerService
+	tagTriggerDoReboot
+	tagTriggerHighImpact
+	tagTriggerTimeoutSeconds
+	tagTriggerAfter
+	tagTriggerBefore
+	tagTriggerHealthCheck
+	tagTriggerHealthCheckRetries
+	tagTriggerHealthCheckTimeoutSeconds
+)
+
+type digester struct {
+	hasher      hash.Hash
+	inodeTable  filesystem.InodeTable
+	linkedPaths map[uint64]string // First pathname seen for each inode.
+}
+
+func (image *Image) digest() (domhash.Hash, error) {
+	var result domhash.Hash
+	d := &digester{hasher: sha512.New()}
+	d.writeUint(tagFileSystem)
+	if fs := image.FileSystem; fs == nil {
+		return result, errors.New("image has no file-system")
+	} else {
+		d.inodeTable = fs.InodeTable
+		d.linkedPaths = make(map[uint64]string)
+		d.writeDirectoryInode(&fs.DirectoryInode)
+		if err := d.writeDirectory(&fs.DirectoryInode, "/"); err != nil {
+			return result, err
+		}
+	}
+	d.writeUint(tagFilter)
+	if image.Filter == nil {
+		d.writeUint(0)
+	} else {
+		d.writeUint(1)
+		d.writeStrings(image.Filter.FilterLines)
+	}
+	d.writeUint(tagTriggers)
+	if image.Triggers == nil {
+		d.writeUint(0)
+	} else {
+		d.writeUint(1)
+		d.writeTriggers(image.Triggers.Triggers)
+	}
+	d.writeUint(tagReleaseNotes)
+	d.writeAnnotation(image.ReleaseNotes)
+	d.writeUint(tagBuildLog)
+	d.writeAnnotation(image.BuildLog)
+	copy(result[:], d.hasher.Sum(nil))
+	return result, nil
+}
+
+func (d *digester) writeUint(value uint64) {
+	var buffer [binary.MaxVarintLen64]byte
+	length := binary.PutUvarint(buffer[:], value)
+	d.hasher.Write(buffer[:length])
+}
+
+func (d *digester) writeInt(value int64) {
+	var buffer [binary.MaxVarintLen64]byte
+	length := binary.PutVarint(buffer[:], value)
+	d.hasher.Write(buffer[:length])
+}
+
+func (d *digester) writeBytes(data []byte) {
+	d.writeUint(uint64(len(data)))
+	d.hasher.Write(data)
+}
+
+func (d *digester) writeString(value string) {
+	d.writeBytes([]byte(value))
+}
+
+func (d *digester) writeStrings(values []string) {
+	d.writeUint(uint64(len(values)))
+	for _, value := range values {
+		d.writeString(value)
+	}
+}
+
+func (d *digester) writeAnnotation(annotation *Annotation) {
+	if annotation == nil {
+		d.writeUint(0)
+	} else if annotation.Object != nil {
+		d.writeUint(1)
+		d.hasher.Write(annotation.Object[:])
+	} else {
+		d.writeUint(2)
+		d.writeString(annotation.URL)
+	}
+}
+
+func (d *digester) writeXattrs(xattrs map[string][]byte) {
+	names := make([]string, 0, len(xattrs))
+	for name := range xattrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	d.writeUint(uint64(len(names)))
+	for _, name := range names {
+		d.writeString(name)
+		d.writeBytes(xattrs[name])
+	}
+}
+
+func (d *digester) writeTaggedBool(tag uint64, value bool) {
+	if value {
+		d.writeUint(tag)
+	}
+}
+
+func (d *digester) writeTaggedString(tag uint64, value string) {
+	if value != "" {
+		d.writeUint(tag)
+		d.writeString(value)
+	}
+}
+
+func (d *digester) writeTaggedStrings(tag uint64, values []string) {
+	if len(values) > 0 {
+		d.writeUint(tag)
+		d.writeStrings(values)
+	}
+}
+
+func (d *digester) writeTaggedUint(tag uint64, value uint64) {
+	if value != 0 {
+		d.writeUint(tag)
+		d.writeUint(value)
+	}
+}
+
+// writeTriggers encodes the triggers in order. Each trigger is encoded as its
+// non-zero fields, each preceded by its tag, followed by tagTriggerEnd. A new
+// field in triggers.Trigger needs a new tag; images which do not use the field
+// keep their digest.
+func (d *digester) writeTriggers(triggerList []*triggers.Trigger) {
+	d.writeUint(uint64(len(triggerList)))
+	for _, trigger := range triggerList {
+		d.writeTaggedStrings(tagTriggerMatchLines, trigger.MatchLines)
+		d.writeTaggedString(tagTriggerService, trigger.Service)
+		d.writeTaggedBool(tagTriggerDoReboot, trigger.DoReboot)
+		d.writeTaggedBool(tagTriggerHighImpact, trigger.HighImpact)
+		d.writeTaggedUint(tagTriggerTimeoutSeconds,
+			uint64(trigger.TimeoutSeconds))
+		d.writeTaggedStrings(tagTriggerAfter, trigger.After)
+		d.writeTaggedStrings(tagTriggerBefore, trigger.Before)
+		d.writeTaggedString(tagTriggerHealthCheck, trigger.HealthCheck)
+		d.writeTaggedUint(tagTriggerHealthCheckRetries,
+			uint64(trigger.HealthCheckRetries))
+		d.writeTaggedUint(tagTriggerHealthCheckTimeoutSeconds,
+			uint64(trigger.HealthCheckTimeoutSeconds))
+		d.writeUint(tagTriggerEnd)
+	}
+}
+
+func (d *digester) writeDirectoryInode(inode *filesystem.DirectoryInode) {
+	d.writeUint(uint64(inode.Mode))
+	d.writeUint(uint64(inode.Uid))
+	d.writeUint(uint64(inode.Gid))
+	d.writeXattrs(inode.Xattrs)
+}
+
+func (d *digester) writeDirectory(directory *filesystem.DirectoryInode,
+	name string) error {
+	entries := make([]*filesystem.DirectoryEntry, len(directory.EntryList))
+	copy(entries, directory.EntryList)
+	sort.Sort(directoryEntryList(entries))
+	for _, entry := range entries {
+		pathname := path.Join(name, entry.Name)
+		d.writeString(entry.Name)
+		if linkedPath, ok := d.linkedPaths[entry.InodeNumber]; ok {
+			d.writeUint(tagHardlink)
+			d.writeString(linkedPath)
+			continue
+		}
+		d.linkedPaths[entry.InodeNumber] = pathname
+		switch inode := d.inodeTable[entry.InodeNumber].(type) {
+		case *filesystem.DirectoryInode:
+			d.writeUint(tagDirectory)
+			d.writeDirectoryInode(inode)
+			if err := d.writeDirectory(inode, pathname); err != nil {
+				return err
+			}
+		case *filesystem.RegularInode:
+			d.writeUint(tagRegular)
+			d.writeUint(uint64(inode.Mode))
+			d.writeUint(uint64(inode.Uid))
+			d.writeUint(uint64(inode.Gid))
+			d.writeInt(inode.MtimeSeconds)
+			d.writeInt(int64(inode.MtimeNanoSeconds))
+			d.writeUint(inode.Size)
+			d.hasher.Write(inode.Hash[:])
+			d.writeXattrs(inode.Xattrs)
+		case *filesystem.ComputedRegularInode:
+			d.writeUint(tagComputed)
+			d.writeUint(uint64(inode.Mode))
+			d.writeUint(uint64(inode.Uid))
+			d.writeUint(uint64(inode.Gid))
+			d.writeString(inode.Source)
+		case *filesystem.SymlinkInode:
+			d.writeUint(tagSymlink)
+			d.writeUint(uint64(inode.Uid))
+			d.writeUint(uint64(inode.Gid))
+			d.writeString(inode.Symlink)
+			d.writeXattrs(inode.Xattrs)
+		case *filesystem.SpecialInode:
+			d.writeUint(tagSpecial)
+			d.writeUint(uint64(inode.Mode))
+			d.writeUint(uint64(inode.Uid))
+			d.writeUint(uint64(inode.Gid))
+			d.writeInt(inode.MtimeSeconds)
+			d.writeInt(int64(inode.MtimeNanoSeconds))
+			d.writeUint(inode.Rdev)
+			d.writeXattrs(inode.Xattrs)
+		default:
+			return fmt.Errorf("%s: unsupported inode type: %T", pathname, inode)
+		}
+	}
+	d.writeUint(tagEndDirectory)
+	return nil
+}
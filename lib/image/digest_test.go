@@ -0,0 +1,202 @@
+package image
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"syscall"
+	"testing"
+
+	"github.com/Symantec/Dominator/lib/filesystem"
+	"github.com/Symantec/Dominator/lib/filter"
+	"github.com/Symantec/Dominator/lib/hash"
+	"github.com/Symantec/Dominator/lib/triggers"
+)
+
+// goldenDigest is the digest of the image from makeDigestTestImage. If this
+// changes then existing signatures will no longer verify.
+const goldenDigest = "27ea887cdc87333b112411e47dd23c0f" +
+	"8bfc5c5a789df8bf646ab3fa72b7cd08" +
+	"de82f84a624cea2e01c187c3bb460821" +
+	"98ef9bb4757a66692149d83f4dfe11e8"
+
+func makeDigestTestImage(inodeOffset uint64) *Image {
+	fs := &filesystem.FileSystem{
+		InodeTable: filesystem.InodeTable{
+			inodeOffset + 1: &filesystem.DirectoryInode{
+				Mode: syscall.S_IFDIR | 0755,
+				EntryList: []*filesystem.DirectoryEntry{
+					{Name: "config", InodeNumber: inodeOffset + 3},
+				},
+			},
+			inodeOffset + 2: &filesystem.RegularInode{
+				Mode:         syscall.S_IFREG | 0755,
+				MtimeSeconds: 1500000000,
+				Size:         4,
+				Hash:         hash.Hash{1, 2, 3},
+				Xattrs: map[string][]byte{
+					"security.selinux": []byte("bin_t"),
+				},
+			},
+			inodeOffset + 3: &filesystem.RegularInode{
+				Mode: syscall.S_IFREG | 0644,
+				Uid:  1,
+				Gid:  2,
+			},
+			inodeOffset + 4: &filesystem.SymlinkInode{Symlink: "program"},
+		},
+	}
+	fs.DirectoryInode = filesystem.DirectoryInode{
+		Mode: syscall.S_IFDIR | 0755,
+		EntryList: []*filesystem.DirectoryEntry{
+			{Name: "program", InodeNumber: inodeOffset + 2},
+			{Name: "etc", InodeNumber: inodeOffset + 1},
+			{Name: "link", InodeNumber: inodeOffset + 4},
+			{Name: "hardlink", InodeNumber: inodeOffset + 2},
+		},
+	}
+	imageFilter, _ := filter.New([]string{"/tmp/.*"})
+	trig := triggers.New()
+	trig.Triggers = []*triggers.Trigger{
+		{
+			MatchLines:         []string{"/etc/config"},
+			Service:            "service",
+			HighImpact:         true,
+			After:              []string{"network"},
+			HealthCheck:        "true",
+			HealthCheckRetries: 2,
+		},
+	}
+	return &Image{
+		Filter:       imageFilter,
+		FileSystem:   fs,
+		Triggers:     trig,
+		ReleaseNotes: &Annotation{URL: "http://notes/"},
+	}
+}
+
+func TestDigestGolden(t *testing.T) {
+	digest, err := makeDigestTestImage(0).Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprintf("%x", digest); got != goldenDigest {
+		t.Errorf("digest: %s != %s", got, goldenDigest)
+	}
+	// Inode numbering does not affect the digest.
+	digest, err = makeDigestTestImage(100).Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprintf("%x", digest); got != goldenDigest {
+		t.Errorf("renumbered digest: %s != %s", got, goldenDigest)
+	}
+}
+
+func TestDigestChanges(t *testing.T) {
+	baseDigest, err := makeDigestTestImage(0).Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		name   string
+		change func(img *Image)
+	}{
+		{"filter", func(img *Image) { img.Filter = nil }},
+		{"triggers", func(img *Image) { img.Triggers = nil }},
+		{"trigger service", func(img *Image) {
+			img.Triggers.Triggers[0].Service = "other"
+		}},
+		{"trigger reboot", func(img *Image) {
+			img.Triggers.Triggers[0].DoReboot = true
+		}},
+		{"trigger before", func(img *Image) {
+			img.Triggers.Triggers[0].Before = []string{"network"}
+		}},
+		{"trigger after/before", func(img *Image) {
+			trigger := img.Triggers.Triggers[0]
+			trigger.Before, trigger.After = trigger.After, nil
+		}},
+		{"trigger health check", func(img *Image) {
+			img.Triggers.Triggers[0].HealthCheckTimeoutSeconds = 1
+		}},
+		{"release notes", func(img *Image) { img.ReleaseNotes = nil }},
+		{"mode", func(img *Image) {
+			img.FileSystem.InodeTable[3].(*filesystem.RegularInode).Mode |=
+				0111
+		}},
+		{"xattrs", func(img *Image) {
+			img.FileSystem.InodeTable[2].(*filesystem.RegularInode).Xattrs =
+				nil
+		}},
+		{"symlink", func(img *Image) {
+			img.FileSystem.InodeTable[4].(*filesystem.SymlinkInode).Symlink =
+				"other"
+		}},
+	}
+	for _, test := range tests {
+		img := makeDigestTestImage(0)
+		test.change(img)
+		digest, err := img.Digest()
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if digest == baseDigest {
+			t.Errorf("%s: digest did not change", test.name)
+		}
+	}
+	img := makeDigestTestImage(0)
+	img.CreatedOn = img.CreatedOn.Add(1)
+	img.Labels = map[string]string{"label": "value"}
+	if digest, err := img.Digest(); err != nil {
+		t.Error(err)
+	} else if digest != baseDigest {
+		t.Error("digest changed with unsigned fields")
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers := []crypto.Signer{ecdsaKey, rsaKey}
+	for _, signer := range signers {
+		img := makeDigestTestImage(0)
+		if err := img.VerifySignatures(
+			[]crypto.PublicKey{signer.Public()}); err == nil {
+			t.Errorf("%T: unsigned image verified", signer)
+		}
+		signature, err := img.Sign(signer)
+		if err != nil {
+			t.Errorf("%T: %s", signer, err)
+			continue
+		}
+		img.Signatures = append(img.Signatures, *signature)
+		if err := img.VerifySignatures(
+			[]crypto.PublicKey{signer.Public()}); err != nil {
+			t.Errorf("%T: %s", signer, err)
+		}
+		if err := img.VerifySignatures(
+			[]crypto.PublicKey{otherKey.Public()}); err == nil {
+			t.Errorf("%T: verified with untrusted key", signer)
+		}
+		img.Triggers.Triggers[0].HealthCheck = "false"
+		if err := img.VerifySignatures(
+			[]crypto.PublicKey{signer.Public()}); err == nil {
+			t.Errorf("%T: verified after trigger change", signer)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package image
+
+import (
+	"fmt"
+	"strings"
+)
+
+func (policy RetentionPolicy) string() string {
+	var rules []string
+	if policy.KeepNewest > 0 {
+		rules = append(rules, fmt.Sprintf("KeepNewest=%d", policy.KeepNewest))
+	}
+	if policy.KeepYoungerThan > 0 {
+		rules = append(rules,
+			fmt.Sprintf("KeepYoungerThan=%s", policy.KeepYoungerThan))
+	}
+	if policy.KeepMdbImages {
+		rules = append(rules, "KeepMdbImages")
+	}
+	return strings.Join(rules, ",")
+}
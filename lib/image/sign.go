@@ -0,0 +1,153 @@
+package image
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/Symantec/Dominator/lib/hash"
+)
+
+func (image *Image) sign(signer crypto.Signer) (*Signature, error) {
+	publicKey, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	digest, err := image.digest()
+	if err != nil {
+		return nil, err
+	}
+	switch signer.Public().(type) {
+	case *ecdsa.PublicKey:
+	case *rsa.PublicKey:
+	default:
+		return nil, fmt.Errorf("unsupported public key type: %T",
+			signer.Public())
+	}
+	data, err := signer.Sign(rand.Reader, digest[:], crypto.SHA512)
+	if err != nil {
+		return nil, err
+	}
+	return &Signature{PublicKey: publicKey, Signature: data}, nil
+}
+
+func (image *Image) verifySignatures(trustedKeys []crypto.PublicKey) error {
+	if len(image.Signatures) < 1 {
+		return errors.New("image is not signed")
+	}
+	trustedKeysDER := make([][]byte, 0, len(trustedKeys))
+	for _, key := range trustedKeys {
+		if der, err := x509.MarshalPKIXPublicKey(key); err != nil {
+			return err
+		} else {
+			trustedKeysDER = append(trustedKeysDER, der)
+		}
+	}
+	digest, err := image.digest()
+	if err != nil {
+		return err
+	}
+	for _, signature := range image.Signatures {
+		for _, der := range trustedKeysDER {
+			if !bytes.Equal(signature.PublicKey, der) {
+				continue
+			}
+			if err := signature.verify(digest); err == nil {
+				return nil
+			}
+		}
+	}
+	return errors.New("image is not signed by a trusted key")
+}
+
+func (signature *Signature) keyFingerprint() string {
+	checksum := sha256.Sum256(signature.PublicKey)
+	return fmt.Sprintf("%x", checksum)
+}
+
+func (signature *Signature) verify(digest hash.Hash) error {
+	publicKey, err := x509.ParsePKIXPublicKey(signature.PublicKey)
+	if err != nil {
+		return err
+	}
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		var ecdsaSignature struct{ R, S *big.Int }
+		rest, err := asn1.Unmarshal(signature.Signature, &ecdsaSignature)
+		if err != nil {
+			return err
+		}
+		if len(rest) > 0 {
+			return errors.New("trailing data after ECDSA signature")
+		}
+		if ecdsa.Verify(key, digest[:], ecdsaSignature.R, ecdsaSignature.S) {
+			return nil
+		}
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA512, digest[:],
+			signature.Signature)
+	default:
+		return fmt.Errorf("unsupported public key type: %T", publicKey)
+	}
+	return errors.New("signature verification failed")
+}
+
+func loadSigningKey(filename string) (crypto.Signer, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New(filename + ": no PEM data found")
+	}
+	if block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("%s: unsupported PEM type: %s",
+			filename, block.Type)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	if signer, ok := key.(crypto.Signer); !ok {
+		return nil, fmt.Errorf("%s: key cannot sign", filename)
+	} else {
+		return signer, nil
+	}
+}
+
+func loadVerifyingKeys(filename string) ([]crypto.PublicKey, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var keys []crypto.PublicKey
+	for {
+		var block *pem.Block
+		if block, data = pem.Decode(data); block == nil {
+			break
+		}
+		if block.Type != "PUBLIC KEY" {
+			continue
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", filename, err)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) < 1 {
+		return nil, errors.New(filename + ": no public keys found")
+	}
+	return keys, nil
+}
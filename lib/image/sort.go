@@ -3,9 +3,24 @@ package image
 import (
 	"sort"
 
+	"github.com/Symantec/Dominator/lib/filesystem"
 	"github.com/Symantec/Dominator/lib/verstr"
 )
 
+type directoryEntryList []*filesystem.DirectoryEntry
+
+func (list directoryEntryList) Len() int {
+	return len(list)
+}
+
+func (list directoryEntryList) Less(i, j int) bool {
+	return list[i].Name < list[j].Name
+}
+
+func (list directoryEntryList) Swap(i, j int) {
+	list[i], list[j] = list[j], list[i]
+}
+
 type directoryList []Directory
 
 func (list directoryList) Len() int {
@@ -20,6 +35,48 @@ func (list directoryList) Swap(i, j int) {
 	list[i], list[j] = list[j], list[i]
 }
 
+type fileChangeList []FileChange
+
+func (list fileChangeList) Len() int {
+	return len(list)
+}
+
+func (list fileChangeList) Less(i, j int) bool {
+	return list[i].Name < list[j].Name
+}
+
+func (list fileChangeList) Swap(i, j int) {
+	list[i], list[j] = list[j], list[i]
+}
+
+type fileSizeList []FileSize
+
+func (list fileSizeList) Len() int {
+	return len(list)
+}
+
+func (list fileSizeList) Less(i, j int) bool {
+	return list[i].Name < list[j].Name
+}
+
+func (list fileSizeList) Swap(i, j int) {
+	list[i], list[j] = list[j], list[i]
+}
+
+type packageChangeList []PackageChange
+
+func (list packageChangeList) Len() int {
+	return len(list)
+}
+
+func (list packageChangeList) Less(i, j int) bool {
+	return list[i].Name < list[j].Name
+}
+
+func (list packageChangeList) Swap(i, j int) {
+	list[i], list[j] = list[j], list[i]
+}
+
 func sortDirectories(directories []Directory) {
 	sort.Sort(directoryList(directories))
 }
@@ -26,10 +26,8 @@ func serveBlocks(conn Conn, decoder Decoder, encoder Encoder,
 		}
 		var localHash, remoteHash hash.Hash
 		copy(localHash[:], hasher.Sum(nil))
-		if nRead, err := conn.Read(remoteHash[:]); err != nil {
+		if _, err := io.ReadFull(conn, remoteHash[:]); err != nil {
 			return encoder.Encode(proto.Block{Error: err.Error()})
-		} else if nRead != len(remoteHash) {
-			return encoder.Encode(proto.Block{Error: "short read"})
 		}
 		if remoteHash != localHash {
 			if _, err := reader.Seek(-blockSize, io.SeekCurrent); err != nil {
@@ -9,17 +9,34 @@ type MergeableTriggers struct {
 }
 
 type mergeableTrigger struct {
-	matchLines map[string]struct{}
-	doReboot   bool
-	highImpact bool
+	matchLines                map[string]struct{}
+	doReboot                  bool
+	highImpact                bool
+	timeoutSeconds            uint
+	healthCheck               string
+	healthCheckRetries        uint
+	healthCheckTimeoutSeconds uint
+	after                     map[string]struct{}
+	before                    map[string]struct{}
 }
 
 type Trigger struct {
-	MatchLines   []string
-	matchRegexes []*regexp.Regexp
-	Service      string
-	DoReboot     bool `json:",omitempty"`
-	HighImpact   bool `json:",omitempty"`
+	MatchLines     []string
+	matchRegexes   []*regexp.Regexp
+	Service        string
+	DoReboot       bool `json:",omitempty"`
+	HighImpact     bool `json:",omitempty"`
+	TimeoutSeconds uint `json:",omitempty"` // Zero: use the sub default.
+	// The service is started after (and stopped before) the services listed
+	// in After and is started before (and stopped after) the services listed
+	// in Before.
+	After  []string `json:",omitempty"`
+	Before []string `json:",omitempty"`
+	// If specified, this shell command is run after the service is started.
+	// A non-zero exit status (after the retries) is a trigger failure.
+	HealthCheck               string `json:",omitempty"`
+	HealthCheckRetries        uint   `json:",omitempty"`
+	HealthCheckTimeoutSeconds uint   `json:",omitempty"` // Per attempt.
 }
 
 func (trigger *Trigger) ReplaceStrings(replaceFunc func(string) string) {
@@ -33,10 +50,33 @@ type Triggers struct {
 	unmatchedTriggers map[*Trigger]struct{}
 }
 
+// OrderForStart returns the triggers in the order in which the services should
+// be started. Dependency cycles are ignored.
+func OrderForStart(triggerList []*Trigger) []*Trigger {
+	return orderForStart(triggerList)
+}
+
+// OrderForStop returns the triggers in the order in which the services should
+// be stopped. Dependency cycles are ignored.
+func OrderForStop(triggerList []*Trigger) []*Trigger {
+	return orderForStop(triggerList)
+}
+
+// ReverseOrder returns a copy of the triggers in reverse order. Use this rather
+// than OrderForStop to get the stop order of a list which is already in start
+// order (such as from GetMatchedTriggers), since ordering a subset of the
+// triggers loses the dependencies linked by the triggers which were left out.
+func ReverseOrder(triggerList []*Trigger) []*Trigger {
+	return reverseOrder(triggerList)
+}
+
+// Decode will decode the triggers and check that there are no dependency
+// cycles.
 func Decode(jsonData []byte) (*Triggers, error) {
 	return decode(jsonData)
 }
 
+// Load will load the triggers and check that there are no dependency cycles.
 func Load(filename string) (*Triggers, error) {
 	return load(filename)
 }
@@ -45,6 +85,11 @@ func New() *Triggers {
 	return newTriggers()
 }
 
+// CheckDependencies returns an error if there is a dependency cycle.
+func (triggers *Triggers) CheckDependencies() error {
+	return triggers.checkDependencies()
+}
+
 func (triggers *Triggers) Len() int {
 	return len(triggers.Triggers)
 }
@@ -74,6 +119,8 @@ func (triggers *Triggers) Match(line string) {
 	triggers.match(line)
 }
 
+// GetMatchedTriggers returns the matched triggers in the order in which the
+// services should be started.
 func (triggers *Triggers) GetMatchedTriggers() []*Trigger {
 	return triggers.getMatchedTriggers()
 }
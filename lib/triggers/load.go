@@ -17,6 +17,9 @@ func load(filename string) (*Triggers, error) {
 	if err := decoder.Decode(&trig.Triggers); err != nil {
 		return nil, errors.New("error decoding triggers " + err.Error())
 	}
+	if err := trig.checkDependencies(); err != nil {
+		return nil, err
+	}
 	return &trig, nil
 }
 
@@ -25,5 +28,8 @@ func decode(jsonData []byte) (*Triggers, error) {
 	if err := json.Unmarshal(jsonData, &trig.Triggers); err != nil {
 		return nil, errors.New("error decoding triggers " + err.Error())
 	}
+	if err := trig.checkDependencies(); err != nil {
+		return nil, err
+	}
 	return &trig, nil
 }
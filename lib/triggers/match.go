@@ -23,8 +23,12 @@ func (triggers *Triggers) match(line string) {
 
 func (triggers *Triggers) getMatchedTriggers() []*Trigger {
 	mTriggers := make([]*Trigger, 0, len(triggers.matchedTriggers))
-	for trigger := range triggers.matchedTriggers {
-		mTriggers = append(mTriggers, trigger)
+	// Unmatched triggers are included in the ordering, since they may link
+	// the dependencies of matched triggers.
+	for _, trigger := range orderForStart(triggers.Triggers) {
+		if _, ok := triggers.matchedTriggers[trigger]; ok {
+			mTriggers = append(mTriggers, trigger)
+		}
 	}
 	triggers.matchedTriggers = nil
 	triggers.unmatchedTriggers = nil
@@ -21,11 +21,24 @@ func (mt *MergeableTriggers) exportTriggers() *Triggers {
 			matchLines = append(matchLines, matchLine)
 		}
 		sort.Strings(matchLines)
+		var after, before []string
+		if len(trigger.after) > 0 {
+			after = sortedKeys(trigger.after)
+		}
+		if len(trigger.before) > 0 {
+			before = sortedKeys(trigger.before)
+		}
 		triggerList = append(triggerList, &Trigger{
-			MatchLines: matchLines,
-			Service:    service,
-			DoReboot:   trigger.doReboot,
-			HighImpact: trigger.highImpact,
+			MatchLines:                matchLines,
+			Service:                   service,
+			DoReboot:                  trigger.doReboot,
+			HighImpact:                trigger.highImpact,
+			TimeoutSeconds:            trigger.timeoutSeconds,
+			After:                     after,
+			Before:                    before,
+			HealthCheck:               trigger.healthCheck,
+			HealthCheckRetries:        trigger.healthCheckRetries,
+			HealthCheckTimeoutSeconds: trigger.healthCheckTimeoutSeconds,
 		})
 	}
 	triggers := New()
@@ -50,11 +63,32 @@ func (mt *MergeableTriggers) merge(triggers *Triggers) {
 		for _, matchLine := range trigger.MatchLines {
 			trig.matchLines[matchLine] = struct{}{}
 		}
+		for _, service := range trigger.After {
+			if trig.after == nil {
+				trig.after = make(map[string]struct{})
+			}
+			trig.after[service] = struct{}{}
+		}
+		for _, service := range trigger.Before {
+			if trig.before == nil {
+				trig.before = make(map[string]struct{})
+			}
+			trig.before[service] = struct{}{}
+		}
 		if trigger.DoReboot {
 			trig.doReboot = true
 		}
 		if trigger.HighImpact {
 			trig.highImpact = true
 		}
+		if trigger.TimeoutSeconds > trig.timeoutSeconds {
+			trig.timeoutSeconds = trigger.TimeoutSeconds
+		}
+		if trigger.HealthCheck != "" {
+			// The last health check wins, with its own retry budget.
+			trig.healthCheck = trigger.HealthCheck
+			trig.healthCheckRetries = trigger.HealthCheckRetries
+			trig.healthCheckTimeoutSeconds = trigger.HealthCheckTimeoutSeconds
+		}
 	}
 }
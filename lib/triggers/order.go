@@ -0,0 +1,108 @@
+package triggers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	stateVisiting = iota + 1
+	stateVisited
+)
+
+// getStartOrder returns the triggers in the order in which their services
+// should be started: each service after the services it depends on. Services
+// without dependencies between them are ordered by name. If there is a
+// dependency cycle an error is returned, along with an order which ignores the
+// dependencies which close cycles.
+func getStartOrder(triggerList []*Trigger) ([]*Trigger, error) {
+	byService := make(map[string][]*Trigger, len(triggerList))
+	dependencies := make(map[string]map[string]struct{})
+	addDependency := func(service, dependency string) {
+		if dependencies[service] == nil {
+			dependencies[service] = make(map[string]struct{})
+		}
+		dependencies[service][dependency] = struct{}{}
+	}
+	for _, trigger := range triggerList {
+		byService[trigger.Service] = append(byService[trigger.Service],
+			trigger)
+		for _, service := range trigger.After {
+			addDependency(trigger.Service, service)
+		}
+		for _, service := range trigger.Before {
+			addDependency(service, trigger.Service)
+		}
+	}
+	ordered := make([]*Trigger, 0, len(triggerList))
+	states := make(map[string]int, len(dependencies))
+	var firstErr error
+	var visit func(service string, chain []string)
+	visit = func(service string, chain []string) {
+		switch states[service] {
+		case stateVisiting:
+			if firstErr == nil {
+				for index, name := range chain {
+					if name == service {
+						chain = chain[index:]
+						break
+					}
+				}
+				firstErr = fmt.Errorf("trigger dependency cycle: %s",
+					strings.Join(append(chain, service), " -> "))
+			}
+			return
+		case stateVisited:
+			return
+		}
+		states[service] = stateVisiting
+		chain = append(chain, service)
+		for _, dependency := range sortedKeys(dependencies[service]) {
+			visit(dependency, chain)
+		}
+		states[service] = stateVisited
+		ordered = append(ordered, byService[service]...)
+	}
+	serviceNames := make([]string, 0, len(byService))
+	for service := range byService {
+		serviceNames = append(serviceNames, service)
+	}
+	sort.Strings(serviceNames)
+	for _, service := range serviceNames {
+		visit(service, nil)
+	}
+	return ordered, firstErr
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (triggers *Triggers) checkDependencies() error {
+	_, err := getStartOrder(triggers.Triggers)
+	return err
+}
+
+func orderForStart(triggerList []*Trigger) []*Trigger {
+	ordered, _ := getStartOrder(triggerList)
+	return ordered
+}
+
+func orderForStop(triggerList []*Trigger) []*Trigger {
+	ordered, _ := getStartOrder(triggerList)
+	return reverseOrder(ordered)
+}
+
+func reverseOrder(triggerList []*Trigger) []*Trigger {
+	reversed := make([]*Trigger, 0, len(triggerList))
+	for index := len(triggerList) - 1; index >= 0; index-- {
+		reversed = append(reversed, triggerList[index])
+	}
+	return reversed
+}
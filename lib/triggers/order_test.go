@@ -0,0 +1,162 @@
+package triggers
+
+import (
+	"strings"
+	"testing"
+)
+
+func getServices(triggerList []*Trigger) string {
+	services := make([]string, 0, len(triggerList))
+	for _, trigger := range triggerList {
+		services = append(services, trigger.Service)
+	}
+	return strings.Join(services, ",")
+}
+
+func TestStartOrder(t *testing.T) {
+	var tests = []struct {
+		name      string
+		triggers  []*Trigger
+		wantStart string
+		wantStop  string
+		wantCycle bool
+	}{
+		{
+			name: "by name",
+			triggers: []*Trigger{
+				{Service: "c"}, {Service: "a"}, {Service: "b"},
+			},
+			wantStart: "a,b,c",
+			wantStop:  "c,b,a",
+		},
+		{
+			name: "after",
+			triggers: []*Trigger{
+				{Service: "a", After: []string{"c"}},
+				{Service: "b"},
+				{Service: "c", After: []string{"b"}},
+			},
+			wantStart: "b,c,a",
+			wantStop:  "a,c,b",
+		},
+		{
+			name: "before",
+			triggers: []*Trigger{
+				{Service: "a"},
+				{Service: "b", Before: []string{"a"}},
+			},
+			wantStart: "b,a",
+			wantStop:  "a,b",
+		},
+		{
+			name: "unknown dependency",
+			triggers: []*Trigger{
+				{Service: "a", After: []string{"x"}},
+				{Service: "b", Before: []string{"y"}},
+			},
+			wantStart: "a,b",
+			wantStop:  "b,a",
+		},
+		{
+			name: "cycle",
+			triggers: []*Trigger{
+				{Service: "a", After: []string{"b"}},
+				{Service: "b", After: []string{"a"}},
+				{Service: "c", Before: []string{"a"}},
+			},
+			wantStart: "b,c,a",
+			wantStop:  "a,c,b",
+			wantCycle: true,
+		},
+	}
+	for _, test := range tests {
+		ordered, err := getStartOrder(test.triggers)
+		if got := getServices(ordered); got != test.wantStart {
+			t.Errorf("%s: start order: %s != %s",
+				test.name, got, test.wantStart)
+		}
+		if test.wantCycle && err == nil {
+			t.Errorf("%s: cycle not detected", test.name)
+		} else if !test.wantCycle && err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+		}
+		if got := getServices(orderForStop(test.triggers)); got !=
+			test.wantStop {
+			t.Errorf("%s: stop order: %s != %s", test.name, got, test.wantStop)
+		}
+		if got := getServices(reverseOrder(ordered)); got != test.wantStop {
+			t.Errorf("%s: reversed order: %s != %s",
+				test.name, got, test.wantStop)
+		}
+	}
+}
+
+func TestCycleError(t *testing.T) {
+	_, err := getStartOrder([]*Trigger{
+		{Service: "a", After: []string{"b"}},
+		{Service: "b", After: []string{"c"}},
+		{Service: "c", After: []string{"b"}},
+	})
+	if err == nil {
+		t.Fatal("cycle not detected")
+	}
+	if want := "trigger dependency cycle: b -> c -> b"; err.Error() != want {
+		t.Errorf("error: %s != %s", err, want)
+	}
+}
+
+func TestMatchedTriggersKeepLinkedDependencies(t *testing.T) {
+	// a is started after b, which is started after c. Only a and c match, so
+	// the dependency of a on c is only linked through b.
+	triggers := New()
+	triggers.Triggers = []*Trigger{
+		{MatchLines: []string{"/etc/a"}, Service: "a", After: []string{"b"}},
+		{MatchLines: []string{"/etc/b"}, Service: "b", After: []string{"c"}},
+		{MatchLines: []string{"/etc/c"}, Service: "c"},
+	}
+	triggers.Match("/etc/c")
+	triggers.Match("/etc/a")
+	matched := triggers.GetMatchedTriggers()
+	if got := getServices(matched); got != "c,a" {
+		t.Errorf("start order: %s != c,a", got)
+	}
+	if got := getServices(ReverseOrder(matched)); got != "a,c" {
+		t.Errorf("stop order: %s != a,c", got)
+	}
+	if got := getServices(matched); got != "c,a" {
+		t.Errorf("start order modified: %s != c,a", got)
+	}
+}
+
+func TestMergeDependencies(t *testing.T) {
+	var mt MergeableTriggers
+	first := New()
+	first.Triggers = []*Trigger{
+		{MatchLines: []string{"/etc/a"}, Service: "a", After: []string{"b"}},
+	}
+	second := New()
+	second.Triggers = []*Trigger{
+		{MatchLines: []string{"/etc/a.d"}, Service: "a",
+			After: []string{"c", "b"}, Before: []string{"d"}},
+		{MatchLines: []string{"/etc/c"}, Service: "c"},
+	}
+	mt.Merge(first)
+	mt.Merge(second)
+	merged := mt.ExportTriggers()
+	if got := getServices(merged.Triggers); got != "a,c" {
+		t.Fatalf("merged services: %s != a,c", got)
+	}
+	trigger := merged.Triggers[0]
+	if got := strings.Join(trigger.After, ","); got != "b,c" {
+		t.Errorf("after: %s != b,c", got)
+	}
+	if got := strings.Join(trigger.Before, ","); got != "d" {
+		t.Errorf("before: %s != d", got)
+	}
+	if got := strings.Join(trigger.MatchLines, ","); got != "/etc/a,/etc/a.d" {
+		t.Errorf("match lines: %s", got)
+	}
+	if got := getServices(OrderForStart(merged.Triggers)); got != "c,a" {
+		t.Errorf("start order: %s != c,a", got)
+	}
+}
@@ -5,6 +5,13 @@ func (trigger *Trigger) replaceStrings(replaceFunc func(string) string) {
 		trigger.MatchLines[index] = replaceFunc(str)
 	}
 	trigger.Service = replaceFunc(trigger.Service)
+	for index, service := range trigger.After {
+		trigger.After[index] = replaceFunc(service)
+	}
+	for index, service := range trigger.Before {
+		trigger.Before[index] = replaceFunc(service)
+	}
+	trigger.HealthCheck = replaceFunc(trigger.HealthCheck)
 }
 
 func (triggers *Triggers) replaceStrings(replaceFunc func(string) string) {
@@ -58,6 +58,22 @@ func Ioctl(fd int, request, argp uintptr) error {
 	return ioctl(fd, request, argp)
 }
 
+func Lgetxattr(path string, attr string, dest []byte) (int, error) {
+	return lgetxattr(path, attr, dest)
+}
+
+func Llistxattr(path string, dest []byte) (int, error) {
+	return llistxattr(path, dest)
+}
+
+func Lremovexattr(path string, attr string) error {
+	return lremovexattr(path, attr)
+}
+
+func Lsetxattr(path string, attr string, data []byte, flags int) error {
+	return lsetxattr(path, attr, data, flags)
+}
+
 func Lstat(path string, statbuf *Stat_t) error {
 	var rawStatbuf syscall.Stat_t
 	if err := syscall.Lstat(path, &rawStatbuf); err != nil {
@@ -22,6 +22,22 @@ func fallocate(fd int, mode uint32, off int64, len int64) error {
 	return syscall.ENOTSUP
 }
 
+func lgetxattr(path string, attr string, dest []byte) (int, error) {
+	return 0, syscall.ENOTSUP
+}
+
+func llistxattr(path string, dest []byte) (int, error) {
+	return 0, syscall.ENOTSUP
+}
+
+func lremovexattr(path string, attr string) error {
+	return syscall.ENOTSUP
+}
+
+func lsetxattr(path string, attr string, data []byte, flags int) error {
+	return syscall.ENOTSUP
+}
+
 func mount(source string, target string, fstype string, flags uintptr,
 	data string) error {
 	return syscall.ENOTSUP
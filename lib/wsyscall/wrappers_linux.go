@@ -6,6 +6,7 @@ import (
 	"runtime"
 	"strconv"
 	"syscall"
+	"unsafe"
 )
 
 const sys_SETNS = 308 // 64 bit only.
@@ -30,6 +31,88 @@ func fallocate(fd int, mode uint32, off int64, len int64) error {
 	return syscall.Fallocate(fd, mode, off, len)
 }
 
+func lgetxattr(path string, attr string, dest []byte) (int, error) {
+	pathPointer, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	attrPointer, err := syscall.BytePtrFromString(attr)
+	if err != nil {
+		return 0, err
+	}
+	var destPointer unsafe.Pointer
+	if len(dest) > 0 {
+		destPointer = unsafe.Pointer(&dest[0])
+	}
+	size, _, errno := syscall.Syscall6(syscall.SYS_LGETXATTR,
+		uintptr(unsafe.Pointer(pathPointer)),
+		uintptr(unsafe.Pointer(attrPointer)), uintptr(destPointer),
+		uintptr(len(dest)), 0, 0)
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(size), nil
+}
+
+func llistxattr(path string, dest []byte) (int, error) {
+	pathPointer, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var destPointer unsafe.Pointer
+	if len(dest) > 0 {
+		destPointer = unsafe.Pointer(&dest[0])
+	}
+	size, _, errno := syscall.Syscall(syscall.SYS_LLISTXATTR,
+		uintptr(unsafe.Pointer(pathPointer)), uintptr(destPointer),
+		uintptr(len(dest)))
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(size), nil
+}
+
+func lremovexattr(path string, attr string) error {
+	pathPointer, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return err
+	}
+	attrPointer, err := syscall.BytePtrFromString(attr)
+	if err != nil {
+		return err
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_LREMOVEXATTR,
+		uintptr(unsafe.Pointer(pathPointer)),
+		uintptr(unsafe.Pointer(attrPointer)), 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func lsetxattr(path string, attr string, data []byte, flags int) error {
+	pathPointer, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return err
+	}
+	attrPointer, err := syscall.BytePtrFromString(attr)
+	if err != nil {
+		return err
+	}
+	var dataPointer unsafe.Pointer
+	if len(data) > 0 {
+		dataPointer = unsafe.Pointer(&data[0])
+	}
+	_, _, errno := syscall.Syscall6(syscall.SYS_LSETXATTR,
+		uintptr(unsafe.Pointer(pathPointer)),
+		uintptr(unsafe.Pointer(attrPointer)), uintptr(dataPointer),
+		uintptr(len(data)), uintptr(flags), 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
 func mount(source string, target string, fstype string, flags uintptr,
 	data string) error {
 	var linuxFlags uintptr
@@ -0,0 +1,46 @@
+package rpcd
+
+import (
+	"io"
+
+	"github.com/Symantec/Dominator/lib/rsync"
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/objectserver"
+)
+
+func (t *srpcType) GetObjectDelta(conn *srpc.Conn, decoder srpc.Decoder,
+	encoder srpc.Encoder) error {
+	defer conn.Flush()
+	var request objectserver.GetObjectDeltaRequest
+	if err := decoder.Decode(&request); err != nil {
+		return err
+	}
+	exclusive.RLock()
+	defer exclusive.RUnlock()
+	t.getSemaphore <- true
+	defer releaseSemaphore(t.getSemaphore)
+	size, reader, err := t.objectServer.GetObject(request.Hash)
+	if err != nil {
+		return encoder.Encode(
+			objectserver.GetObjectDeltaResponse{Error: err.Error()})
+	}
+	defer reader.Close()
+	readSeeker, ok := reader.(io.ReadSeeker)
+	if !ok {
+		return encoder.Encode(objectserver.GetObjectDeltaResponse{
+			Error: "object is not seekable"})
+	}
+	err = encoder.Encode(objectserver.GetObjectDeltaResponse{Size: size})
+	if err != nil {
+		return err
+	}
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+	if err := rsync.ServeBlocks(conn, decoder, encoder, readSeeker,
+		size); err != nil {
+		return err
+	}
+	t.logger.Debugf(0, "GetObjectDelta(%x) served\n", request.Hash)
+	return nil
+}
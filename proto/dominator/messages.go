@@ -1,9 +1,47 @@
 package dominator
 
 import (
+	"time"
+
+	"github.com/Symantec/Dominator/lib/hash"
+	"github.com/Symantec/Dominator/lib/triggers"
 	"github.com/Symantec/Dominator/proto/sub"
 )
 
+type AbortRolloutRequest struct{}
+
+type AbortRolloutResponse struct{}
+
+// AuditRecord records an update sent to a sub by the dominator.
+// AuditRecord describes an update. The record is written when the update is
+// sent and the result is appended as a separate record (with the same Hostname
+// and StartTime) when it is known. These are merged when reading the log.
+type AuditRecord struct {
+	Hostname        string
+	ImageName       string
+	StartTime       time.Time
+	Duration        time.Duration
+	Update          UpdateSummary
+	TriggersFired   []string // Services of the matched triggers.
+	Completed       bool     // If false, the result is not (yet) known.
+	Result          string   // Empty if the update succeeded.
+	TriggerFailures bool
+	ImageChangedBy  string // Empty if the image was selected by the MDB.
+	ConfigChangedBy string
+}
+
+type ClearHostImageOverrideRequest struct {
+	Hostname string
+}
+
+type ClearHostImageOverrideResponse struct{}
+
+type ClearImageQuarantineRequest struct {
+	ImageName string
+}
+
+type ClearImageQuarantineResponse struct{}
+
 type ClearSafetyShutoffRequest struct {
 	Hostname string
 }
@@ -20,24 +58,199 @@ type DisableUpdatesRequest struct {
 
 type DisableUpdatesResponse struct{}
 
+// DriftReport describes how the file-system of a sub differs from its required
+// image. Paths excluded by the image filter are ignored. Added and missing
+// directories are reported without their contents.
+type DriftReport struct {
+	ImageName       string
+	ComputeTime     time.Time
+	AddedPaths      []string // Present on the sub but not in the image.
+	MissingPaths    []string // Present in the image but not on the sub.
+	ModifiedPaths   []string // Type or content differs.
+	MetadataChanges []MetadataChange
+}
+
 type EnableUpdatesRequest struct {
 	Reason string
 }
 
 type EnableUpdatesResponse struct{}
 
+type GetAuditLogRequest struct {
+	Hostname  string    // If empty, records for all subs are returned.
+	StartTime time.Time // If zero, there is no lower bound.
+	EndTime   time.Time // If zero, there is no upper bound.
+}
+
+type GetAuditLogResponse struct {
+	Records []AuditRecord
+}
+
 type GetDefaultImageRequest struct{}
 
 type GetDefaultImageResponse struct {
 	ImageName string
 }
 
+type GetDriftRequest struct {
+	Hostname string
+}
+
+type GetDriftResponse struct {
+	Drift DriftReport
+}
+
+type GetPlannedUpdateRequest struct {
+	Hostname string
+}
+
+type GetPlannedUpdateResponse struct {
+	ImageName       string
+	ObjectsToFetch  []hash.Hash // Fetched by the sub from the objectserver.
+	ObjectsToPush   []hash.Hash // Computed files pushed by the dominator.
+	UpdateRequest   sub.UpdateRequest
+	MatchedTriggers []*triggers.Trigger
+	UnsafeUpdate    bool   // If true, the safety shutoff would be triggered.
+	HoldReason      string // If set, the update would not be sent now.
+}
+
+type GetRolloutStatusRequest struct{}
+
+type GetRolloutStatusResponse struct {
+	Rollout *RolloutStatus // nil if no rollout was started.
+}
+
+// The GetSubUpdates() RPC is fully streamed.
+// The client sends a single GetSubUpdatesRequest message.
+// The server sends a stream of SubUpdate messages. The first message contains
+// all the matching subs.
+
+type GetSubUpdatesRequest struct {
+	Hostnames []string // If empty, all subs are matched.
+	TagKey    string   // If non-empty, only subs with a matching tag.
+	TagValue  string
+}
+
 type GetSubsConfigurationRequest struct{}
 
 type GetSubsConfigurationResponse sub.Configuration
 
+type GetUpdateConcurrencyLimitsRequest struct{}
+
+type GetUpdateConcurrencyLimitsResponse UpdateConcurrencyLimits
+
+// HostImageOverride pins the image for a sub and/or disables updates for it
+// until it expires. It takes precedence over the MDB.
+type HostImageOverride struct {
+	Hostname       string
+	ImageName      string // If empty, the image is not overridden.
+	DisableUpdates bool
+	Expires        time.Time
+	Reason         string
+	SetBy          string // Filled in by the dominator.
+	SetTime        time.Time
+}
+
+type ListHostImageOverridesRequest struct{}
+
+type ListHostImageOverridesResponse struct {
+	Overrides []HostImageOverride
+}
+
+// MetadataChange describes a path whose content matches the image but whose
+// metadata differs.
+type MetadataChange struct {
+	Pathname      string
+	ModeChanged   bool
+	UidChanged    bool
+	GidChanged    bool
+	MtimeChanged  bool
+	XattrsChanged bool // Extended attributes, including ACLs.
+}
+
+type PauseRolloutRequest struct {
+	Reason string
+}
+
+type PauseRolloutResponse struct{}
+
+type ResumeRolloutRequest struct{}
+
+type ResumeRolloutResponse struct{}
+
+type RolloutStatus struct {
+	ImageName       string
+	WavePercentages []uint
+	MaxFailures     uint
+	CurrentWave     uint // Index into WavePercentages.
+	State           string
+	PauseReason     string
+	StartedBy       string
+	StartTime       time.Time
+	NumSubs         uint // Subs which require the image.
+	NumAdmitted     uint // Subs which may be updated.
+	NumSynced       uint // Admitted subs which were successfully updated.
+	NumFailed       uint // Admitted subs which failed to update.
+}
+
 type SetDefaultImageRequest struct {
 	ImageName string
 }
 
 type SetDefaultImageResponse struct{}
+
+type SetHostImageOverrideRequest HostImageOverride
+
+type SetHostImageOverrideResponse struct{}
+
+type SetUpdateConcurrencyLimitsRequest UpdateConcurrencyLimits
+
+type SetUpdateConcurrencyLimitsResponse struct{}
+
+type StartRolloutRequest struct {
+	ImageName       string
+	WavePercentages []uint // Must be increasing and end with 100.
+	MaxFailures     uint   // Pause if more admitted subs than this fail.
+}
+
+type StartRolloutResponse struct{}
+
+// SubInfo is the status of a sub as seen by the dominator.
+type SubInfo struct {
+	Hostname                     string
+	Status                       string
+	RequiredImage                string
+	PlannedImage                 string `json:",omitempty"`
+	LastUpdateError              string `json:",omitempty"`
+	LastSuccessfulImageName      string `json:",omitempty"`
+	LastUpdateHadTriggerFailures bool   `json:",omitempty"`
+}
+
+type SubUpdate struct {
+	ChangedSubs []SubInfo `json:",omitempty"`
+	DeletedSubs []string  `json:",omitempty"` // Hostname
+}
+
+// UpdateSummary is a digest of an update request sent to a sub.
+type UpdateSummary struct {
+	NumFilesToCopyToCache uint
+	NumDirectoriesToMake  uint
+	NumInodesToMake       uint
+	NumHardlinksToMake    uint
+	NumPathsToDelete      uint
+	NumInodesToChange     uint
+	DirectoriesToMake     []string
+	InodesToMake          []string
+	HardlinksToMake       []string
+	PathsToDelete         []string
+	InodesToChange        []string
+}
+
+// UpdateConcurrencyLimits limits the number of subs in a group which may
+// concurrently perform updates which run triggers. Subs are grouped by the
+// value of the MDB tag given by TagKey. A limit of zero means no limit.
+type UpdateConcurrencyLimits struct {
+	TagKey                       string
+	MaxRebootsPerGroup           uint
+	MaxTriggeringUpdatesPerGroup uint
+}
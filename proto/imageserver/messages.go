@@ -5,6 +5,7 @@ import (
 
 	"github.com/Symantec/Dominator/lib/hash"
 	"github.com/Symantec/Dominator/lib/image"
+	"github.com/Symantec/Dominator/lib/tags"
 )
 
 type AddImageRequest struct {
@@ -14,6 +15,34 @@ type AddImageRequest struct {
 
 type AddImageResponse struct{}
 
+// The signature must be valid for the image digest. The imageserver sets the
+// SignedBy and SignedOn fields.
+type AddImageSignatureRequest struct {
+	ImageName string
+	Signature image.Signature
+}
+
+type AddImageSignatureResponse struct{}
+
+// If DirectoryName is empty, all directories are processed.
+type ApplyRetentionPoliciesRequest struct {
+	DirectoryName string
+	DryRun        bool // If true, list the images which would be deleted.
+}
+
+type ApplyRetentionPoliciesResponse struct {
+	ImageNames []string // Images which were (or would be) deleted.
+}
+
+// Labels are set before labels are deleted.
+type ChangeImageLabelsRequest struct {
+	ImageName      string
+	LabelsToSet    tags.Tags
+	LabelsToDelete []string
+}
+
+type ChangeImageLabelsResponse struct{}
+
 type ChangeOwnerRequest struct {
 	DirectoryName string
 	OwnerGroup    string
@@ -50,6 +79,30 @@ type DeleteUnreferencedObjectsRequest struct {
 
 type DeleteUnreferencedObjectsResponse struct{}
 
+type DiffImagesRequest struct {
+	LeftImageName  string
+	RightImageName string
+}
+
+type DiffImagesResponse struct {
+	Diff *image.Diff
+}
+
+// Images must match all the specified criteria. DirectoryName includes
+// sub-directories and PackageVersion is ignored if PackageName is empty.
+type FindImagesRequest struct {
+	DirectoryName  string
+	Labels         tags.Tags
+	PackageName    string
+	PackageVersion string
+	CreatedAfter   time.Time
+	CreatedBefore  time.Time
+}
+
+type FindImagesResponse struct {
+	ImageNames []string // Sorted by creation time (oldest first).
+}
+
 type FindLatestImageRequest struct {
 	DirectoryName        string
 	IgnoreExpiringImages bool
@@ -74,6 +127,8 @@ const (
 	OperationAddImage = iota
 	OperationDeleteImage
 	OperationMakeDirectory
+	OperationAddImageSignatures
+	OperationSetImageLabels
 )
 
 // The GetImageUpdates() RPC is fully streamed.
@@ -81,9 +136,11 @@ const (
 // The server sends a stream of ImageUpdate messages.
 
 type ImageUpdate struct {
-	Name      string // "" signifies initial list is sent, changes to follow.
-	Directory *image.Directory
-	Operation uint
+	Name       string // "" signifies initial list is sent, changes to follow.
+	Directory  *image.Directory
+	Operation  uint
+	Signatures []image.Signature // For OperationAddImageSignatures.
+	Labels     tags.Tags         // For OperationSetImageLabels.
 }
 
 // The ListDirectories() RPC is fully streamed.
@@ -111,3 +168,10 @@ type MakeDirectoryRequest struct {
 }
 
 type MakeDirectoryResponse struct{}
+
+type SetRetentionPolicyRequest struct {
+	DirectoryName string
+	Policy        image.RetentionPolicy
+}
+
+type SetRetentionPolicyResponse struct{}
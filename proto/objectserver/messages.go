@@ -28,6 +28,17 @@ type CheckObjectsResponse struct {
 	ObjectSizes []uint64 // size == 0: object not found.
 }
 
+// The GetObjectDelta() RPC is followed by the proto/rsync.GetBlocks message,
+// with the object as the source of the blocks.
+type GetObjectDeltaRequest struct {
+	Hash hash.Hash
+}
+
+type GetObjectDeltaResponse struct {
+	Error string
+	Size  uint64
+}
+
 // This is used in the special GetObjects streaming HTTP/RPC protocol.
 type GetObjectsRequest struct {
 	Exclusive bool // For initial performance benchmarking only.
@@ -18,12 +18,39 @@ type Configuration struct {
 	NetworkSpeedPercent uint
 	ScanSpeedPercent    uint
 	ScanExclusionList   []string
+	// The object cache fields are ignored by SetConfiguration().
+	ObjectCacheMaxBytes     uint64            `json:",omitempty"`
+	ObjectCacheMinFreeBytes uint64            `json:",omitempty"`
+	ObjectCacheUsage        *ObjectCacheUsage `json:",omitempty"`
+}
+
+type ObjectCacheUsage struct {
+	NumObjects   uint64
+	NumBytes     uint64
+	NumEvicted   uint64 // Since subd started.
+	LastEviction time.Time
 }
 
 type FetchRequest struct {
 	ServerAddress string
 	Wait          bool
 	Hashes        []hash.Hash
+	Peers         []FetchPeer  // Tried before ServerAddress.
+	Deltas        []FetchDelta // Fetched as block deltas from ServerAddress.
+}
+
+// FetchDelta names an existing file on the sub which is similar to an object
+// to fetch, so that only the blocks which differ need be fetched.
+type FetchDelta struct {
+	Hash         hash.Hash
+	BasePathname string
+}
+
+// FetchPeer is a sub which has some of the objects to fetch in its object
+// cache.
+type FetchPeer struct {
+	Address string
+	Hashes  []hash.Hash
 }
 
 type FetchResponse struct{}
@@ -43,6 +70,18 @@ type GetFileResponse struct {
 	Size  uint64
 } // File data are streamed afterwards.
 
+// The GetObjects() RPC uses the same streaming protocol as the
+// ObjectServer.GetObjects() RPC, serving objects from the object cache of the
+// sub. It is used by peer subs to fetch objects.
+
+// InterruptedUpdate describes an update which did not complete, for example
+// because the machine crashed, and which was not resumed.
+type InterruptedUpdate struct {
+	ImageName       string
+	StartTime       time.Time
+	CompletedPhases []string
+}
+
 type PollRequest struct {
 	HaveGeneration uint64
 	ShortPollOnly  bool // If true, do not send FileSystem or ObjectCache.
@@ -56,7 +95,10 @@ type PollResponse struct {
 	LastFetchError               string
 	LastUpdateError              string
 	LastUpdateHadTriggerFailures bool
+	LastTriggerFailures          []TriggerFailure `json:",omitempty"`
 	LastSuccessfulImageName      string
+	InterruptedUpdate            *InterruptedUpdate `json:",omitempty"`
+	PathLocks                    []PathLock         `json:",omitempty"`
 	FreeSpace                    *uint64
 	StartTime                    time.Time
 	PollTime                     time.Time
@@ -68,6 +110,34 @@ type PollResponse struct {
 	ObjectCache                  objectcache.ObjectCache // Streamed separately.
 } // FileSystem is encoded afterwards, followed by ObjectCache.
 
+type TriggerFailure struct {
+	Service string
+	Action  string // "start", "stop" or "health check".
+	Output  string // The end of the output of the failed command.
+}
+
+type LockPathsRequest struct {
+	Locks          []PathLock // Replace any locks with the same Pattern.
+	UnlockPatterns []string
+}
+
+type LockPathsResponse struct{}
+
+// A PathLock prevents updates to the paths which match Pattern (a regular
+// expression, as for filters) until it expires.
+type PathLock struct {
+	Pattern   string
+	Reason    string
+	ExpiresAt time.Time
+	Username  string `json:",omitempty"` // Who set the lock.
+}
+
+type RollbackRequest struct{}
+
+type RollbackResponse struct {
+	ImageName string // The image before the update which was rolled back.
+}
+
 type SetConfigurationRequest Configuration
 
 type SetConfigurationResponse struct{}
@@ -104,6 +174,35 @@ type UpdateRequest struct {
 
 type UpdateResponse struct{}
 
+// VerifyRequest asks the sub to get the image from the imageserver and compare
+// its file-system with the image, without involving the dominator.
+type VerifyRequest struct {
+	ImageName          string
+	ImageServerAddress string
+}
+
+// VerifyResponse describes how the file-system of the sub (as of the last
+// scan) differs from the image. Paths excluded by the image filter are ignored.
+// Added and missing directories are reported without their contents.
+type VerifyResponse struct {
+	ScanCount       uint64   // Of the scan which was compared.
+	AddedPaths      []string // Present on the sub but not in the image.
+	MissingPaths    []string // Present in the image but not on the sub.
+	ModifiedPaths   []string // Type or content differs.
+	MetadataChanges []MetadataChange
+}
+
+// MetadataChange describes a path whose content matches the image but whose
+// metadata differs.
+type MetadataChange struct {
+	Pathname      string
+	ModeChanged   bool
+	UidChanged    bool
+	GidChanged    bool
+	MtimeChanged  bool
+	XattrsChanged bool // Extended attributes, including ACLs.
+}
+
 type CleanupRequest struct {
 	Hashes []hash.Hash
 }
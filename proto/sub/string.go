@@ -2,6 +2,9 @@ package sub
 
 import (
 	"fmt"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/format"
 )
 
 func (configuration Configuration) String() string {
@@ -14,6 +17,22 @@ func (configuration Configuration) String() string {
 			retval += "\n  " + exclusion
 		}
 	}
+	if configuration.ObjectCacheMaxBytes > 0 {
+		retval += "\nObjectCacheMaxBytes: " +
+			format.FormatBytes(configuration.ObjectCacheMaxBytes)
+	}
+	if configuration.ObjectCacheMinFreeBytes > 0 {
+		retval += "\nObjectCacheMinFreeBytes: " +
+			format.FormatBytes(configuration.ObjectCacheMinFreeBytes)
+	}
+	if usage := configuration.ObjectCacheUsage; usage != nil {
+		retval += fmt.Sprintf("\nObjectCacheUsage: %d objects, %s",
+			usage.NumObjects, format.FormatBytes(usage.NumBytes))
+		if usage.NumEvicted > 0 {
+			retval += fmt.Sprintf(", %d evicted (last at: %s)",
+				usage.NumEvicted, usage.LastEviction.Format(time.RFC3339))
+		}
+	}
 	return retval
 }
 
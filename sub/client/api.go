@@ -16,6 +16,11 @@ func Cleanup(client *srpc.Client, hashes []hash.Hash) error {
 	return cleanup(client, hashes)
 }
 
+func CallFetch(client *srpc.Client, request sub.FetchRequest,
+	reply *sub.FetchResponse) error {
+	return callFetch(client, request, reply)
+}
+
 func Fetch(client *srpc.Client, serverAddress string,
 	hashes []hash.Hash) error {
 	return fetch(client, serverAddress, hashes)
@@ -25,11 +30,27 @@ func GetConfiguration(client *srpc.Client) (sub.Configuration, error) {
 	return getConfiguration(client)
 }
 
+func CallLockPaths(client *srpc.Client, request sub.LockPathsRequest,
+	reply *sub.LockPathsResponse) error {
+	return callLockPaths(client, request, reply)
+}
+
+func GetObjects(client *srpc.Client, hashes []hash.Hash,
+	readerFunc func(hashVal hash.Hash, reader io.Reader,
+		size uint64) error) error {
+	return getObjects(client, hashes, readerFunc)
+}
+
 func CallPoll(client *srpc.Client, request sub.PollRequest,
 	reply *sub.PollResponse) error {
 	return callPoll(client, request, reply)
 }
 
+func CallRollback(client *srpc.Client, request sub.RollbackRequest,
+	reply *sub.RollbackResponse) error {
+	return callRollback(client, request, reply)
+}
+
 func SetConfiguration(client *srpc.Client, config sub.Configuration) error {
 	return setConfiguration(client, config)
 }
@@ -39,6 +60,11 @@ func CallUpdate(client *srpc.Client, request sub.UpdateRequest,
 	return callUpdate(client, request, reply)
 }
 
+func CallVerify(client *srpc.Client, request sub.VerifyRequest,
+	reply *sub.VerifyResponse) error {
+	return callVerify(client, request, reply)
+}
+
 func GetFiles(client *srpc.Client, filenames []string,
 	readerFunc func(reader io.Reader, size uint64) error) error {
 	return getFiles(client, filenames, readerFunc)
@@ -12,3 +12,8 @@ func fetch(client *srpc.Client, serverAddress string,
 	var reply sub.FetchResponse
 	return client.RequestReply("Subd.Fetch", request, &reply)
 }
+
+func callFetch(client *srpc.Client, request sub.FetchRequest,
+	reply *sub.FetchResponse) error {
+	return client.RequestReply("Subd.Fetch", request, reply)
+}
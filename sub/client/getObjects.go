@@ -0,0 +1,46 @@
+package client
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+
+	"github.com/Symantec/Dominator/lib/hash"
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/objectserver"
+)
+
+func getObjects(client *srpc.Client, hashes []hash.Hash,
+	readerFunc func(hashVal hash.Hash, reader io.Reader,
+		size uint64) error) error {
+	conn, err := client.Call("Subd.GetObjects")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	request := objectserver.GetObjectsRequest{Hashes: hashes}
+	if err := gob.NewEncoder(conn).Encode(request); err != nil {
+		return err
+	}
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+	var reply objectserver.GetObjectsResponse
+	if err := gob.NewDecoder(conn).Decode(&reply); err != nil {
+		return err
+	}
+	if reply.ResponseString != "" {
+		return errors.New(reply.ResponseString)
+	}
+	if len(reply.ObjectSizes) != len(hashes) {
+		return errors.New("object sizes do not match hashes")
+	}
+	for index, hashVal := range hashes {
+		size := reply.ObjectSizes[index]
+		if err := readerFunc(hashVal,
+			&io.LimitedReader{R: conn, N: int64(size)}, size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package client
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/sub"
+)
+
+func callLockPaths(client *srpc.Client, request sub.LockPathsRequest,
+	reply *sub.LockPathsResponse) error {
+	return client.RequestReply("Subd.LockPaths", request, reply)
+}
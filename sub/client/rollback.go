@@ -0,0 +1,11 @@
+package client
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/sub"
+)
+
+func callRollback(client *srpc.Client, request sub.RollbackRequest,
+	reply *sub.RollbackResponse) error {
+	return client.RequestReply("Subd.Rollback", request, reply)
+}
@@ -0,0 +1,11 @@
+package client
+
+import (
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/sub"
+)
+
+func callVerify(client *srpc.Client, request sub.VerifyRequest,
+	reply *sub.VerifyResponse) error {
+	return client.RequestReply("Subd.Verify", request, reply)
+}
@@ -9,6 +9,28 @@ import (
 	"github.com/Symantec/Dominator/proto/sub"
 )
 
+// RollbackOptions control the preservation of the state which an update
+// changes, so that the update may be rolled back with RollbackUpdate.
+type RollbackOptions struct {
+	Directory         string // Must be on the same file-system as the root.
+	PreviousImageName string
+	MaxUpdates        uint // The number of updates which may be rolled back.
+	MinFreePercent    uint // Rollback data are deleted to keep this free.
+	// If TriggersFilename is set, PreviousTriggers (the content of the file
+	// before the update, nil if there was none) is restored there when the
+	// update is rolled back.
+	TriggersFilename string
+	PreviousTriggers []byte
+}
+
+// UpdateOptions control optional behaviour of UpdateWithOptions.
+type UpdateOptions struct {
+	// If set, the update is journaled here and may be resumed with
+	// ResumeUpdate if it is interrupted.
+	JournalFilename string
+	Rollback        RollbackOptions
+}
+
 type TriggersRunner func(triggers []*triggers.Trigger, action string,
 	logger log.Logger) bool
 
@@ -19,6 +41,9 @@ type uType struct {
 	runTriggers        TriggersRunner
 	disableTriggers    bool
 	logger             log.Logger
+	rollback           *rollbackRecorder
+	journal            *journalType
+	resuming           bool
 	lastError          error
 	hadTriggerFailures bool
 	fsChangeDuration   time.Duration
@@ -42,3 +67,37 @@ func Update(request sub.UpdateRequest, rootDirectoryName string,
 	err := updateObj.update(request, oldTriggers)
 	return updateObj.hadTriggerFailures, updateObj.fsChangeDuration, err
 }
+
+// GetInterruptedUpdate returns information about the update recorded in the
+// journal, which was interrupted before it completed.
+func GetInterruptedUpdate(journalFilename string) (
+	*sub.InterruptedUpdate, error) {
+	return readInterruptedUpdate(journalFilename)
+}
+
+// ResumeUpdate will complete the update recorded in the journal, skipping the
+// phases which completed before it was interrupted. The image name of the
+// update is returned.
+func ResumeUpdate(journalFilename string, rootDirectoryName string,
+	objectsDir string, skipFilter *filter.Filter,
+	triggersRunner TriggersRunner, logger log.Logger) (
+	string, bool, error) {
+	return resumeUpdate(journalFilename, rootDirectoryName, objectsDir,
+		skipFilter, triggersRunner, logger)
+}
+
+func RollbackUpdate(rootDirectoryName string, rollbackDir string,
+	triggersRunner TriggersRunner, logger log.Logger) (
+	string, bool, error) {
+	return rollbackUpdate(rootDirectoryName, rollbackDir, triggersRunner,
+		logger)
+}
+
+func UpdateWithOptions(request sub.UpdateRequest, rootDirectoryName string,
+	objectsDir string, oldTriggers *triggers.Triggers,
+	skipFilter *filter.Filter, triggersRunner TriggersRunner,
+	options UpdateOptions, logger log.Logger) (
+	bool, time.Duration, error) {
+	return updateWithOptions(request, rootDirectoryName, objectsDir,
+		oldTriggers, skipFilter, triggersRunner, options, logger)
+}
@@ -0,0 +1,202 @@
+package lib
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/filter"
+	"github.com/Symantec/Dominator/lib/log"
+	"github.com/Symantec/Dominator/lib/triggers"
+	"github.com/Symantec/Dominator/proto/sub"
+)
+
+const (
+	phaseCopyToCache      = "copy to cache"
+	phaseMakeObjectCopies = "make object copies"
+	phaseStopTriggers     = "stop triggers"
+	phaseMakeDirectories  = "make directories"
+	phaseMakeInodes       = "make inodes"
+	phaseMakeHardlinks    = "make hardlinks"
+	phaseDoDeletes        = "delete"
+	phaseChangeInodes     = "change inodes"
+	phaseStartTriggers    = "start triggers"
+)
+
+// journalHeader is written at the start of the journal, before any changes
+// are made. It is followed by the names of the phases as they complete.
+type journalHeader struct {
+	Request     sub.UpdateRequest
+	OldTriggers []*triggers.Trigger
+	StartTime   time.Time
+}
+
+type journalType struct {
+	filename  string
+	file      *os.File
+	encoder   *gob.Encoder
+	completed map[string]struct{}
+	logger    log.Logger
+}
+
+// createJournal will write the header and the completed phases to a new
+// journal and sync it to stable storage.
+func createJournal(filename string, header journalHeader,
+	completedPhases []string, logger log.Logger) (*journalType, error) {
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC,
+		0600)
+	if err != nil {
+		return nil, err
+	}
+	journal := &journalType{
+		filename:  filename,
+		file:      file,
+		encoder:   gob.NewEncoder(file),
+		completed: make(map[string]struct{}, len(completedPhases)),
+		logger:    logger,
+	}
+	if err := journal.encoder.Encode(header); err != nil {
+		file.Close()
+		return nil, err
+	}
+	for _, phase := range completedPhases {
+		journal.completed[phase] = struct{}{}
+		if err := journal.encoder.Encode(phase); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return journal, nil
+}
+
+// readJournal will read the header and the completed phases. A partially
+// written phase record at the end of the journal is ignored.
+func readJournal(filename string) (*journalHeader, []string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	decoder := gob.NewDecoder(file)
+	var header journalHeader
+	if err := decoder.Decode(&header); err != nil {
+		return nil, nil, errors.New("error reading journal header: " +
+			err.Error())
+	}
+	var completedPhases []string
+	for {
+		var phase string
+		if err := decoder.Decode(&phase); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return &header, completedPhases, nil
+			}
+			return nil, nil, err
+		}
+		completedPhases = append(completedPhases, phase)
+	}
+}
+
+// pending returns true if the phase has not yet completed. Without a journal
+// every phase is pending.
+func (journal *journalType) pending(phase string) bool {
+	if journal == nil {
+		return true
+	}
+	_, ok := journal.completed[phase]
+	return !ok
+}
+
+// complete will record that the phase has completed. Errors are logged, since
+// the worst consequence is that the phase is repeated after a crash.
+func (journal *journalType) complete(phase string) {
+	if journal == nil || !journal.pending(phase) {
+		return
+	}
+	journal.completed[phase] = struct{}{}
+	if err := journal.encoder.Encode(phase); err != nil {
+		journal.logger.Printf("Error writing journal: %s\n", err)
+		return
+	}
+	if err := journal.file.Sync(); err != nil {
+		journal.logger.Printf("Error syncing journal: %s\n", err)
+	}
+}
+
+// remove will close and delete the journal, marking the update as finished.
+func (journal *journalType) remove() {
+	if journal == nil {
+		return
+	}
+	journal.file.Close()
+	if err := os.Remove(journal.filename); err != nil {
+		journal.logger.Println(err)
+	}
+}
+
+func readInterruptedUpdate(filename string) (*sub.InterruptedUpdate, error) {
+	header, completedPhases, err := readJournal(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &sub.InterruptedUpdate{
+		ImageName:       header.Request.ImageName,
+		StartTime:       header.StartTime,
+		CompletedPhases: completedPhases,
+	}, nil
+}
+
+func resumeUpdate(journalFilename string, rootDirectoryName string,
+	objectsDir string, skipFilter *filter.Filter,
+	triggersRunner TriggersRunner, logger log.Logger) (
+	string, bool, error) {
+	header, completedPhases, err := readJournal(journalFilename)
+	if err != nil {
+		return "", false, err
+	}
+	if skipFilter == nil {
+		skipFilter = new(filter.Filter)
+	}
+	// The journal is re-written so that a crash during the resumed update can
+	// itself be resumed.
+	journal, err := createJournal(journalFilename, *header, completedPhases,
+		logger)
+	if err != nil {
+		return "", false, err
+	}
+	logger.Printf("Resuming update to image: \"%s\" started at: %s\n",
+		header.Request.ImageName, header.StartTime)
+	updateObj := &uType{
+		rootDirectoryName: rootDirectoryName,
+		objectsDir:        objectsDir,
+		skipFilter:        skipFilter,
+		runTriggers:       triggersRunner,
+		logger:            logger,
+		journal:           journal,
+		resuming:          true,
+	}
+	oldTriggers := triggers.New()
+	oldTriggers.Triggers = header.OldTriggers
+	err = updateObj.update(header.Request, oldTriggers)
+	journal.remove()
+	return header.Request.ImageName, updateObj.hadTriggerFailures, err
+}
+
+func (t *uType) createJournal(filename string, request sub.UpdateRequest,
+	oldTriggers *triggers.Triggers) {
+	header := journalHeader{Request: request, StartTime: time.Now()}
+	if oldTriggers != nil {
+		header.OldTriggers = oldTriggers.Triggers
+	}
+	journal, err := createJournal(filename, header, nil, t.logger)
+	if err != nil {
+		t.logger.Printf("Error creating journal: %s\n", err)
+		return
+	}
+	t.journal = journal
+}
@@ -0,0 +1,162 @@
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/Symantec/Dominator/lib/filesystem"
+	"github.com/Symantec/Dominator/lib/log"
+	"github.com/Symantec/Dominator/lib/log/testlogger"
+	"github.com/Symantec/Dominator/lib/triggers"
+	"github.com/Symantec/Dominator/proto/sub"
+)
+
+func makeJournalHeader() journalHeader {
+	return journalHeader{
+		Request: sub.UpdateRequest{
+			ImageName: "image",
+			DirectoriesToMake: []sub.Inode{{
+				Name: "/newdir",
+				GenericInode: &filesystem.DirectoryInode{
+					Mode: syscall.S_IFDIR | 0755,
+				},
+			}},
+			PathsToDelete: []string{"/deleted"},
+			Triggers: &triggers.Triggers{
+				Triggers: []*triggers.Trigger{
+					{MatchLines: []string{"/deleted"}, Service: "new"},
+				},
+			},
+		},
+		OldTriggers: []*triggers.Trigger{
+			{MatchLines: []string{"/deleted"}, Service: "old"},
+		},
+	}
+}
+
+func TestResumeUpdate(t *testing.T) {
+	var tests = []struct {
+		name            string
+		completedPhases []string
+		expectedRuns    []string
+		made            bool
+		deleted         bool
+	}{
+		{
+			name: "stopped triggers",
+			completedPhases: []string{phaseCopyToCache, phaseMakeObjectCopies,
+				phaseStopTriggers, phaseMakeDirectories},
+			expectedRuns: []string{"start new"},
+			deleted:      true,
+		},
+		{
+			name: "changed inodes",
+			completedPhases: []string{phaseCopyToCache, phaseMakeObjectCopies,
+				phaseStopTriggers, phaseMakeDirectories, phaseMakeInodes,
+				phaseMakeHardlinks, phaseDoDeletes, phaseChangeInodes},
+			expectedRuns: []string{"start new"},
+		},
+		{
+			name:         "none completed",
+			expectedRuns: []string{"stop old", "start new"},
+			made:         true,
+			deleted:      true,
+		},
+	}
+	for _, test := range tests {
+		logger := testlogger.New(t)
+		topDir, err := ioutil.TempDir("", "journal_test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(topDir)
+		rootDir := path.Join(topDir, "root")
+		journalFilename := path.Join(topDir, "journal")
+		if err := os.Mkdir(rootDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(path.Join(rootDir, "deleted"), nil, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		journal, err := createJournal(journalFilename, makeJournalHeader(),
+			test.completedPhases, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		journal.file.Close()
+		var runs []string
+		runner := func(triggerList []*triggers.Trigger, action string,
+			logger log.Logger) bool {
+			for _, trigger := range triggerList {
+				runs = append(runs, action+" "+trigger.Service)
+			}
+			return false
+		}
+		imageName, _, err := resumeUpdate(journalFilename, rootDir,
+			path.Join(topDir, "objects"), nil, runner, logger)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if imageName != "image" {
+			t.Errorf("%s: image: %s", test.name, imageName)
+		}
+		if !reflect.DeepEqual(runs, test.expectedRuns) {
+			t.Errorf("%s: triggers run: %v, expected: %v",
+				test.name, runs, test.expectedRuns)
+		}
+		_, err = os.Stat(path.Join(rootDir, "newdir"))
+		if made := err == nil; made != test.made {
+			t.Errorf("%s: directory made: %v, expected: %v",
+				test.name, made, test.made)
+		}
+		_, err = os.Stat(path.Join(rootDir, "deleted"))
+		if deleted := os.IsNotExist(err); deleted != test.deleted {
+			t.Errorf("%s: deleted: %v, expected: %v",
+				test.name, deleted, test.deleted)
+		}
+		if _, err := os.Stat(journalFilename); !os.IsNotExist(err) {
+			t.Errorf("%s: journal not removed: %v", test.name, err)
+		}
+	}
+}
+
+func TestReadJournalIgnoresTornRecord(t *testing.T) {
+	topDir, err := ioutil.TempDir("", "journal_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(topDir)
+	journalFilename := path.Join(topDir, "journal")
+	journal, err := createJournal(journalFilename, makeJournalHeader(),
+		[]string{phaseCopyToCache}, testlogger.New(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi, err := journal.file.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal.complete(phaseMakeObjectCopies)
+	journal.file.Close()
+	// Keep only part of the last record, as if the write was interrupted.
+	if err := os.Truncate(journalFilename, fi.Size()+3); err != nil {
+		t.Fatal(err)
+	}
+	header, completedPhases, err := readJournal(journalFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Request.ImageName != "image" {
+		t.Errorf("image: %s", header.Request.ImageName)
+	}
+	expected := []string{phaseCopyToCache}
+	if !reflect.DeepEqual(completedPhases, expected) {
+		t.Errorf("completed phases: %v, expected: %v",
+			completedPhases, expected)
+	}
+}
@@ -0,0 +1,370 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/fsutil"
+	"github.com/Symantec/Dominator/lib/json"
+	"github.com/Symantec/Dominator/lib/log"
+	"github.com/Symantec/Dominator/lib/triggers"
+	"github.com/Symantec/Dominator/lib/wsyscall"
+)
+
+const (
+	rollbackDirPerms     = syscall.S_IRWXU
+	rollbackLayout       = "2006-01-02:15:04:05.000000"
+	rollbackManifestFile = "manifest.json"
+	rollbackTriggersFile = "triggers"
+)
+
+// rollbackEntry records the state of a path before it was changed by an
+// update. Exactly one of the fields after Pathname is set.
+type rollbackEntry struct {
+	Pathname  string
+	Created   bool           `json:",omitempty"` // Did not exist before.
+	SavedName string         `json:",omitempty"` // In the update directory.
+	Metadata  *inodeMetadata `json:",omitempty"`
+}
+
+type inodeMetadata struct {
+	Mode             uint32
+	Uid              uint32
+	Gid              uint32
+	MtimeSeconds     int64
+	MtimeNanoSeconds int64
+	Xattrs           map[string][]byte `json:",omitempty"`
+}
+
+type rollbackManifest struct {
+	ImageName string // The image before the update.
+	Time      time.Time
+	Triggers  []*triggers.Trigger // Matched by the update.
+	Entries   []rollbackEntry     // In the order the changes were made.
+	// The triggers file of subd. If SavedTriggers is false there was no file
+	// before the update.
+	TriggersFilename string `json:",omitempty"`
+	SavedTriggers    bool   `json:",omitempty"`
+}
+
+// rollbackRecorder preserves the paths an update changes in a directory in the
+// rollback area. Replaced and deleted files are hardlinked or moved there, so
+// that preserving them is cheap.
+type rollbackRecorder struct {
+	dirname  string
+	manifest rollbackManifest
+	recorded map[string]struct{}
+	logger   log.Logger
+}
+
+func newRollbackRecorder(options RollbackOptions,
+	logger log.Logger) (*rollbackRecorder, error) {
+	pruneRollbackArea(options.Directory, options.MaxUpdates-1,
+		options.MinFreePercent, logger)
+	timeNow := time.Now()
+	dirname := path.Join(options.Directory, timeNow.Format(rollbackLayout))
+	if err := os.MkdirAll(dirname, rollbackDirPerms); err != nil {
+		return nil, err
+	}
+	recorder := &rollbackRecorder{
+		dirname: dirname,
+		manifest: rollbackManifest{
+			ImageName:        options.PreviousImageName,
+			Time:             timeNow,
+			TriggersFilename: options.TriggersFilename,
+		},
+		recorded: make(map[string]struct{}),
+		logger:   logger,
+	}
+	if options.TriggersFilename != "" && options.PreviousTriggers != nil {
+		err := ioutil.WriteFile(path.Join(dirname, rollbackTriggersFile),
+			options.PreviousTriggers, 0600)
+		if err != nil {
+			os.RemoveAll(dirname)
+			return nil, err
+		}
+		recorder.manifest.SavedTriggers = true
+	}
+	return recorder, nil
+}
+
+// preserve will save the path before it is replaced or deleted. If move is
+// true the path is moved into the rollback area, else it is hardlinked.
+// Directories which are not moved have only their metadata saved.
+func (r *rollbackRecorder) preserve(rootDirectoryName, pathname string,
+	move bool) error {
+	if _, ok := r.recorded[pathname]; ok {
+		return nil
+	}
+	r.recorded[pathname] = struct{}{}
+	fullPathname := path.Join(rootDirectoryName, pathname)
+	var stat wsyscall.Stat_t
+	if err := wsyscall.Lstat(fullPathname, &stat); err != nil {
+		if os.IsNotExist(err) {
+			r.addEntry(rollbackEntry{Pathname: pathname, Created: true})
+			return nil
+		}
+		return err
+	}
+	if !move && stat.Mode&syscall.S_IFMT == syscall.S_IFDIR {
+		metadata, err := getMetadata(fullPathname, &stat)
+		if err != nil {
+			return err
+		}
+		r.addEntry(rollbackEntry{Pathname: pathname, Metadata: metadata})
+		return nil
+	}
+	savedName := fmt.Sprintf("%d", len(r.manifest.Entries))
+	savedPathname := path.Join(r.dirname, savedName)
+	var err error
+	if move {
+		err = os.Rename(fullPathname, savedPathname)
+	} else {
+		err = os.Link(fullPathname, savedPathname)
+	}
+	if err != nil {
+		return err
+	}
+	r.addEntry(rollbackEntry{Pathname: pathname, SavedName: savedName})
+	return nil
+}
+
+// preserveMetadata will save the metadata of the path before it is changed.
+func (r *rollbackRecorder) preserveMetadata(rootDirectoryName,
+	pathname string) error {
+	if _, ok := r.recorded[pathname]; ok {
+		return nil
+	}
+	r.recorded[pathname] = struct{}{}
+	fullPathname := path.Join(rootDirectoryName, pathname)
+	var stat wsyscall.Stat_t
+	if err := wsyscall.Lstat(fullPathname, &stat); err != nil {
+		if os.IsNotExist(err) {
+			r.addEntry(rollbackEntry{Pathname: pathname, Created: true})
+			return nil
+		}
+		return err
+	}
+	metadata, err := getMetadata(fullPathname, &stat)
+	if err != nil {
+		return err
+	}
+	r.addEntry(rollbackEntry{Pathname: pathname, Metadata: metadata})
+	return nil
+}
+
+func (r *rollbackRecorder) addEntry(entry rollbackEntry) {
+	r.manifest.Entries = append(r.manifest.Entries, entry)
+}
+
+// finish will write the manifest. The triggers matched by the update are
+// saved, so that they may be run again when the update is rolled back.
+func (r *rollbackRecorder) finish(matchedOldTriggers,
+	matchedNewTriggers []*triggers.Trigger) error {
+	services := make(map[string]struct{})
+	for _, matchedTriggers := range [][]*triggers.Trigger{
+		matchedOldTriggers, matchedNewTriggers} {
+		for _, trigger := range matchedTriggers {
+			if _, ok := services[trigger.Service]; !ok {
+				services[trigger.Service] = struct{}{}
+				r.manifest.Triggers = append(r.manifest.Triggers, trigger)
+			}
+		}
+	}
+	return json.WriteToFile(path.Join(r.dirname, rollbackManifestFile),
+		0600, "    ", r.manifest)
+}
+
+func (t *uType) preserve(pathname string, move bool) {
+	if t.rollback == nil {
+		return
+	}
+	err := t.rollback.preserve(t.rootDirectoryName, pathname, move)
+	if err != nil {
+		t.logger.Printf("Error preserving: %s: %s\n", pathname, err)
+	}
+}
+
+func (t *uType) preserveMetadata(pathname string) {
+	if t.rollback == nil {
+		return
+	}
+	err := t.rollback.preserveMetadata(t.rootDirectoryName, pathname)
+	if err != nil {
+		t.logger.Printf("Error preserving: %s: %s\n", pathname, err)
+	}
+}
+
+func getMetadata(fullPathname string, stat *wsyscall.Stat_t) (
+	*inodeMetadata, error) {
+	xattrs, err := fsutil.GetXattrs(fullPathname)
+	if err != nil {
+		return nil, err
+	}
+	return &inodeMetadata{
+		Mode:             stat.Mode,
+		Uid:              stat.Uid,
+		Gid:              stat.Gid,
+		MtimeSeconds:     int64(stat.Mtim.Sec),
+		MtimeNanoSeconds: int64(stat.Mtim.Nsec),
+		Xattrs:           xattrs,
+	}, nil
+}
+
+func (metadata *inodeMetadata) restore(fullPathname string) error {
+	if err := os.Lchown(fullPathname, int(metadata.Uid),
+		int(metadata.Gid)); err != nil {
+		return err
+	}
+	if metadata.Mode&syscall.S_IFMT != syscall.S_IFLNK {
+		err := syscall.Chmod(fullPathname, metadata.Mode&07777)
+		if err != nil {
+			return err
+		}
+	}
+	if err := fsutil.SetXattrs(fullPathname, metadata.Xattrs); err != nil {
+		return err
+	}
+	switch metadata.Mode & syscall.S_IFMT {
+	case syscall.S_IFLNK, syscall.S_IFDIR:
+		return nil
+	}
+	mtime := time.Unix(metadata.MtimeSeconds, metadata.MtimeNanoSeconds)
+	return os.Chtimes(fullPathname, mtime, mtime)
+}
+
+// listRollbackUpdates returns the names of the update directories in the
+// rollback area, oldest first.
+func listRollbackUpdates(rollbackDir string) ([]string, error) {
+	names, err := fsutil.ReadDirnames(rollbackDir, true)
+	if err != nil {
+		return nil, err
+	}
+	updates := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, err := time.Parse(rollbackLayout, name); err == nil {
+			updates = append(updates, name)
+		}
+	}
+	sort.Strings(updates)
+	return updates, nil
+}
+
+// pruneRollbackArea will delete the oldest updates in the rollback area until
+// at most maxUpdates remain and the free space is at least minFreePercent.
+func pruneRollbackArea(rollbackDir string, maxUpdates, minFreePercent uint,
+	logger log.Logger) {
+	updates, err := listRollbackUpdates(rollbackDir)
+	if err != nil {
+		logger.Println(err)
+		return
+	}
+	for len(updates) > 0 {
+		if uint(len(updates)) <= maxUpdates &&
+			!lowOnSpace(rollbackDir, minFreePercent) {
+			return
+		}
+		pathname := path.Join(rollbackDir, updates[0])
+		if err := os.RemoveAll(pathname); err != nil {
+			logger.Println(err)
+			return
+		}
+		logger.Printf("Deleted rollback data: %s\n", pathname)
+		updates = updates[1:]
+	}
+}
+
+func lowOnSpace(dirname string, minFreePercent uint) bool {
+	if minFreePercent < 1 {
+		return false
+	}
+	var statfs syscall.Statfs_t
+	if err := syscall.Statfs(dirname, &statfs); err != nil {
+		return false
+	}
+	return statfs.Bavail*100 < statfs.Blocks*uint64(minFreePercent)
+}
+
+func rollbackUpdate(rootDirectoryName, rollbackDir string,
+	triggersRunner TriggersRunner, logger log.Logger) (
+	string, bool, error) {
+	updates, err := listRollbackUpdates(rollbackDir)
+	if err != nil {
+		return "", false, err
+	}
+	if len(updates) < 1 {
+		return "", false, errors.New("no update to roll back")
+	}
+	dirname := path.Join(rollbackDir, updates[len(updates)-1])
+	var manifest rollbackManifest
+	err = json.ReadFromFile(path.Join(dirname, rollbackManifestFile), &manifest)
+	if err != nil {
+		return "", false, err
+	}
+	hadTriggerFailures := false
+	if triggersRunner != nil &&
+		triggersRunner(triggers.OrderForStop(manifest.Triggers), "stop",
+			logger) {
+		hadTriggerFailures = true
+	}
+	var lastError error
+	for index := len(manifest.Entries) - 1; index >= 0; index-- {
+		entry := manifest.Entries[index]
+		if err := entry.restore(rootDirectoryName, dirname); err != nil {
+			lastError = err
+			logger.Println(err)
+		} else {
+			logger.Printf("Restored: %s\n", entry.Pathname)
+		}
+	}
+	if err := manifest.restoreTriggers(dirname); err != nil {
+		lastError = err
+		logger.Println(err)
+	}
+	if triggersRunner != nil &&
+		triggersRunner(triggers.OrderForStart(manifest.Triggers), "start",
+			logger) {
+		hadTriggerFailures = true
+	}
+	if lastError != nil {
+		return "", hadTriggerFailures, lastError
+	}
+	if err := os.RemoveAll(dirname); err != nil {
+		logger.Println(err)
+	}
+	logger.Printf("Rolled back update made at: %s\n", manifest.Time)
+	return manifest.ImageName, hadTriggerFailures, nil
+}
+
+// restoreTriggers will restore the triggers file of subd, so that the triggers
+// of the image before the update are used as the old triggers next time.
+func (manifest *rollbackManifest) restoreTriggers(dirname string) error {
+	if manifest.TriggersFilename == "" {
+		return nil
+	}
+	if !manifest.SavedTriggers {
+		return fsutil.ForceRemove(manifest.TriggersFilename)
+	}
+	return fsutil.CopyFile(manifest.TriggersFilename,
+		path.Join(dirname, rollbackTriggersFile), 0644)
+}
+
+func (entry rollbackEntry) restore(rootDirectoryName, dirname string) error {
+	fullPathname := path.Join(rootDirectoryName, entry.Pathname)
+	if entry.Created {
+		return fsutil.ForceRemoveAll(fullPathname)
+	}
+	if entry.Metadata != nil {
+		return entry.Metadata.restore(fullPathname)
+	}
+	if err := fsutil.ForceRemoveAll(fullPathname); err != nil {
+		return err
+	}
+	return os.Rename(path.Join(dirname, entry.SavedName), fullPathname)
+}
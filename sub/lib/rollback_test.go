@@ -0,0 +1,70 @@
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/Symantec/Dominator/lib/log/testlogger"
+)
+
+func TestRollbackRestoresTriggers(t *testing.T) {
+	var tests = []struct {
+		name             string
+		previousTriggers []byte
+	}{
+		{"saved", []byte(`[{"Service": "old"}]`)},
+		{"none", nil},
+	}
+	for _, test := range tests {
+		logger := testlogger.New(t)
+		topDir, err := ioutil.TempDir("", "rollback_test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(topDir)
+		rootDir := path.Join(topDir, "root")
+		rollbackDir := path.Join(topDir, "rollback")
+		triggersFilename := path.Join(topDir, "triggers.previous")
+		if err := os.Mkdir(rootDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		recorder, err := newRollbackRecorder(RollbackOptions{
+			Directory:        rollbackDir,
+			MaxUpdates:       1,
+			TriggersFilename: triggersFilename,
+			PreviousTriggers: test.previousTriggers,
+		}, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The update writes the new triggers.
+		err = ioutil.WriteFile(triggersFilename, []byte(`[{"Service": "new"}]`),
+			0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := recorder.finish(nil, nil); err != nil {
+			t.Fatal(err)
+		}
+		_, _, err = rollbackUpdate(rootDir, rollbackDir, nil, logger)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		data, err := ioutil.ReadFile(triggersFilename)
+		if test.previousTriggers == nil {
+			if !os.IsNotExist(err) {
+				t.Errorf("%s: triggers file not removed: %v", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if string(data) != string(test.previousTriggers) {
+			t.Errorf("%s: triggers: %s != %s",
+				test.name, string(data), string(test.previousTriggers))
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"crypto/sha512"
 	"fmt"
 	"io"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/Symantec/Dominator/lib/filesystem"
+	"github.com/Symantec/Dominator/lib/filter"
 	"github.com/Symantec/Dominator/lib/fsutil"
 	"github.com/Symantec/Dominator/lib/hash"
 	"github.com/Symantec/Dominator/lib/log"
@@ -18,13 +20,58 @@ import (
 	"github.com/Symantec/Dominator/proto/sub"
 )
 
+func updateWithOptions(request sub.UpdateRequest, rootDirectoryName string,
+	objectsDir string, oldTriggers *triggers.Triggers,
+	skipFilter *filter.Filter, triggersRunner TriggersRunner,
+	options UpdateOptions, logger log.Logger) (
+	bool, time.Duration, error) {
+	if skipFilter == nil {
+		skipFilter = new(filter.Filter)
+	}
+	updateObj := &uType{
+		rootDirectoryName: rootDirectoryName,
+		objectsDir:        objectsDir,
+		skipFilter:        skipFilter,
+		runTriggers:       triggersRunner,
+		logger:            logger,
+	}
+	if options.JournalFilename != "" {
+		updateObj.createJournal(options.JournalFilename, request, oldTriggers)
+	}
+	rollbackOptions := options.Rollback
+	if rollbackOptions.MaxUpdates > 0 {
+		recorder, err := newRollbackRecorder(rollbackOptions, logger)
+		if err != nil {
+			logger.Printf("Error creating rollback data: %s\n", err)
+		} else {
+			updateObj.rollback = recorder
+		}
+	}
+	err := updateObj.update(request, oldTriggers)
+	updateObj.journal.remove()
+	if rollbackOptions.MaxUpdates > 0 {
+		pruneRollbackArea(rollbackOptions.Directory, rollbackOptions.MaxUpdates,
+			rollbackOptions.MinFreePercent, logger)
+	}
+	return updateObj.hadTriggerFailures, updateObj.fsChangeDuration, err
+}
+
 func (t *uType) update(request sub.UpdateRequest,
 	oldTriggers *triggers.Triggers) error {
 	if request.Triggers == nil {
 		request.Triggers = triggers.New()
 	}
-	t.copyFilesToCache(request.FilesToCopyToCache)
-	t.makeObjectCopies(request.MultiplyUsedObjects)
+	// Phases which completed before an interrupted update are skipped, but
+	// the paths are still matched against the triggers.
+	if t.journal.pending(phaseCopyToCache) {
+		t.copyFilesToCache(request.FilesToCopyToCache)
+		t.journal.complete(phaseCopyToCache)
+	}
+	if t.journal.pending(phaseMakeObjectCopies) {
+		t.makeObjectCopies(request.MultiplyUsedObjects)
+		t.journal.complete(phaseMakeObjectCopies)
+	}
+	var matchedOldTriggers []*triggers.Trigger
 	if t.runTriggers != nil &&
 		oldTriggers != nil && len(oldTriggers.Triggers) > 0 {
 		t.makeDirectories(request.DirectoriesToMake,
@@ -34,24 +81,43 @@ func (t *uType) update(request sub.UpdateRequest,
 		t.makeHardlinks(request.HardlinksToMake, oldTriggers, false)
 		t.doDeletes(request.PathsToDelete, oldTriggers, false)
 		t.changeInodes(request.InodesToChange, oldTriggers, false)
-		matchedOldTriggers := oldTriggers.GetMatchedTriggers()
-		if t.runTriggers(matchedOldTriggers, "stop", t.logger) {
+		matchedOldTriggers = oldTriggers.GetMatchedTriggers()
+		if t.journal.pending(phaseStopTriggers) &&
+			t.runTriggers(triggers.ReverseOrder(matchedOldTriggers), "stop",
+				t.logger) {
 			t.hadTriggerFailures = true
 		}
+		t.journal.complete(phaseStopTriggers)
 	}
 	fsChangeStartTime := time.Now()
-	t.makeDirectories(request.DirectoriesToMake, request.Triggers, true)
+	t.makeDirectories(request.DirectoriesToMake, request.Triggers,
+		t.journal.pending(phaseMakeDirectories))
+	t.journal.complete(phaseMakeDirectories)
 	t.makeInodes(request.InodesToMake, request.MultiplyUsedObjects,
-		request.Triggers, true)
-	t.makeHardlinks(request.HardlinksToMake, request.Triggers, true)
-	t.doDeletes(request.PathsToDelete, request.Triggers, true)
-	t.changeInodes(request.InodesToChange, request.Triggers, true)
+		request.Triggers, t.journal.pending(phaseMakeInodes))
+	t.journal.complete(phaseMakeInodes)
+	t.makeHardlinks(request.HardlinksToMake, request.Triggers,
+		t.journal.pending(phaseMakeHardlinks))
+	t.journal.complete(phaseMakeHardlinks)
+	t.doDeletes(request.PathsToDelete, request.Triggers,
+		t.journal.pending(phaseDoDeletes))
+	t.journal.complete(phaseDoDeletes)
+	t.changeInodes(request.InodesToChange, request.Triggers,
+		t.journal.pending(phaseChangeInodes))
+	t.journal.complete(phaseChangeInodes)
 	t.fsChangeDuration = time.Since(fsChangeStartTime)
 	matchedNewTriggers := request.Triggers.GetMatchedTriggers()
-	if t.runTriggers != nil &&
+	if t.runTriggers != nil && t.journal.pending(phaseStartTriggers) &&
 		t.runTriggers(matchedNewTriggers, "start", t.logger) {
 		t.hadTriggerFailures = true
 	}
+	t.journal.complete(phaseStartTriggers)
+	if t.rollback != nil {
+		err := t.rollback.finish(matchedOldTriggers, matchedNewTriggers)
+		if err != nil {
+			t.logger.Printf("Error writing rollback manifest: %s\n", err)
+		}
+	}
 	return t.lastError
 }
 
@@ -123,11 +189,12 @@ func (t *uType) makeInodes(inodesToMake []sub.Inode,
 		fullPathname := path.Join(t.rootDirectoryName, inode.Name)
 		triggers.Match(inode.Name)
 		if takeAction {
+			t.preserve(inode.Name, false)
 			var err error
 			switch inode := inode.GenericInode.(type) {
 			case *filesystem.RegularInode:
 				err = makeRegularInode(fullPathname, inode, multiplyUsedObjects,
-					t.objectsDir, t.logger)
+					t.objectsDir, t.resuming, t.logger)
 			case *filesystem.SymlinkInode:
 				err = makeSymlinkInode(fullPathname, inode, t.logger)
 			case *filesystem.SpecialInode:
@@ -142,7 +209,7 @@ func (t *uType) makeInodes(inodesToMake []sub.Inode,
 
 func makeRegularInode(fullPathname string,
 	inode *filesystem.RegularInode, multiplyUsedObjects map[hash.Hash]uint64,
-	objectsDir string, logger log.Logger) error {
+	objectsDir string, resuming bool, logger log.Logger) error {
 	var objectPathname string
 	if inode.Size > 0 {
 		objectPathname = path.Join(objectsDir,
@@ -166,7 +233,11 @@ func makeRegularInode(fullPathname string,
 			file.Close()
 		}
 	}
-	if err := fsutil.ForceRename(objectPathname, fullPathname); err != nil {
+	if resuming && inode.Size > 0 &&
+		isMadeInode(objectPathname, fullPathname, inode) {
+		// The object was moved into place before the update was interrupted.
+	} else if err := fsutil.ForceRename(objectPathname,
+		fullPathname); err != nil {
 		logger.Println(err)
 		return err
 	}
@@ -184,6 +255,28 @@ func makeRegularInode(fullPathname string,
 	return nil
 }
 
+// isMadeInode returns true if the object is missing and the file already has
+// the contents of the object.
+func isMadeInode(objectPathname, fullPathname string,
+	inode *filesystem.RegularInode) bool {
+	if _, err := os.Lstat(objectPathname); !os.IsNotExist(err) {
+		return false
+	}
+	file, err := os.Open(fullPathname)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	hasher := sha512.New()
+	if nCopied, err := io.Copy(hasher, file); err != nil ||
+		uint64(nCopied) != inode.Size {
+		return false
+	}
+	var hashVal hash.Hash
+	copy(hashVal[:], hasher.Sum(nil))
+	return hashVal == inode.Hash
+}
+
 func makeSymlinkInode(fullPathname string,
 	inode *filesystem.SymlinkInode, logger log.Logger) error {
 	if err := inode.Write(fullPathname); err != nil {
@@ -210,6 +303,7 @@ func (t *uType) makeHardlinks(hardlinksToMake []sub.Hardlink,
 	for _, hardlink := range hardlinksToMake {
 		triggers.Match(hardlink.NewLink)
 		if takeAction {
+			t.preserve(hardlink.NewLink, false)
 			targetPathname := path.Join(t.rootDirectoryName, hardlink.Target)
 			linkPathname := path.Join(t.rootDirectoryName, hardlink.NewLink)
 			// A Link directly to linkPathname will fail if it exists, so do a
@@ -239,6 +333,7 @@ func (t *uType) doDeletes(pathsToDelete []string, triggers *triggers.Triggers,
 		fullPathname := path.Join(t.rootDirectoryName, pathname)
 		triggers.Match(pathname)
 		if takeAction {
+			t.preserve(pathname, true)
 			if err := fsutil.ForceRemoveAll(fullPathname); err != nil {
 				t.lastError = err
 				t.logger.Println(err)
@@ -258,6 +353,7 @@ func (t *uType) makeDirectories(directoriesToMake []sub.Inode,
 		fullPathname := path.Join(t.rootDirectoryName, newdir.Name)
 		triggers.Match(newdir.Name)
 		if takeAction {
+			t.preserveMetadata(newdir.Name)
 			inode, ok := newdir.GenericInode.(*filesystem.DirectoryInode)
 			if !ok {
 				t.logger.Println("%s is not a directory!\n", newdir.Name)
@@ -280,6 +376,7 @@ func (t *uType) changeInodes(inodesToChange []sub.Inode,
 		fullPathname := path.Join(t.rootDirectoryName, inode.Name)
 		triggers.Match(inode.Name)
 		if takeAction {
+			t.preserveMetadata(inode.Name)
 			if err := filesystem.ForceWriteMetadata(inode,
 				fullPathname); err != nil {
 				t.lastError = err
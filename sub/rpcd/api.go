@@ -2,11 +2,13 @@ package rpcd
 
 import (
 	"io"
+	"path"
 	"sync"
 
 	"github.com/Symantec/Dominator/lib/log"
 	"github.com/Symantec/Dominator/lib/rateio"
 	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/sub"
 	"github.com/Symantec/Dominator/sub/scanner"
 	"github.com/Symantec/tricorder/go/tricorder"
 	"github.com/Symantec/tricorder/go/tricorder/units"
@@ -16,6 +18,8 @@ type rpcType struct {
 	scannerConfiguration         *scanner.Configuration
 	fileSystemHistory            *scanner.FileSystemHistory
 	objectsDir                   string
+	rollbackDir                  string
+	journalFilename              string
 	rootDir                      string
 	networkReaderContext         *rateio.ReaderContext
 	netbenchFilename             string
@@ -32,7 +36,12 @@ type rpcType struct {
 	lastFetchError               error
 	lastUpdateError              error
 	lastUpdateHadTriggerFailures bool
+	lastTriggerFailures          []sub.TriggerFailure
 	lastSuccessfulImageName      string
+	interruptedUpdate            *sub.InterruptedUpdate
+	pathLocks                    pathLocksType
+	peerServeSemaphore           chan struct{}
+	objectCache                  objectCacheType
 }
 
 type addObjectsHandlerType struct {
@@ -43,6 +52,7 @@ type addObjectsHandlerType struct {
 
 type HtmlWriter struct {
 	lastSuccessfulImageName *string
+	objectCache             *objectCacheType
 }
 
 func Setup(configuration *scanner.Configuration, fsh *scanner.FileSystemHistory,
@@ -51,17 +61,31 @@ func Setup(configuration *scanner.Configuration, fsh *scanner.FileSystemHistory,
 	netbenchFname string, oldTriggersFname string,
 	disableScannerFunction func(disableScanner bool),
 	rescanObjectCacheFunction func(), logger log.Logger) *HtmlWriter {
+	// The rollback area must not be in the object cache directory, since the
+	// object cache scanner deletes unexpected files.
+	rollbackDirname := path.Join(path.Dir(objectsDirname), "rollback")
+	journalFilename := path.Join(path.Dir(objectsDirname), "update.journal")
 	rpcObj := &rpcType{
 		scannerConfiguration:      configuration,
 		fileSystemHistory:         fsh,
 		objectsDir:                objectsDirname,
+		rollbackDir:               rollbackDirname,
+		journalFilename:           journalFilename,
 		rootDir:                   rootDirname,
 		networkReaderContext:      netReaderContext,
 		netbenchFilename:          netbenchFname,
 		oldTriggersFilename:       oldTriggersFname,
 		rescanObjectCacheFunction: rescanObjectCacheFunction,
 		disableScannerFunc:        disableScannerFunction,
-		logger:                    logger}
+		logger:                    logger,
+		peerServeSemaphore:        make(chan struct{}, *maxPeerServes)}
+	rpcObj.pathLocks.filename = path.Join(path.Dir(objectsDirname),
+		"path-locks.json")
+	rpcObj.checkInterruptedUpdate()
+	if !rpcObj.updateInProgress {
+		// Otherwise the policy is enforced after the resumed update.
+		rpcObj.enforceObjectCachePolicy()
+	}
 	srpc.RegisterName("Subd", rpcObj)
 	addObjectsHandler := &addObjectsHandlerType{
 		objectsDir:           objectsDirname,
@@ -70,7 +94,7 @@ func Setup(configuration *scanner.Configuration, fsh *scanner.FileSystemHistory,
 	srpc.RegisterName("ObjectServer", addObjectsHandler)
 	tricorder.RegisterMetric("/image-name", &rpcObj.lastSuccessfulImageName,
 		units.None, "name of the image for the last successful update")
-	return &HtmlWriter{&rpcObj.lastSuccessfulImageName}
+	return &HtmlWriter{&rpcObj.lastSuccessfulImageName, &rpcObj.objectCache}
 }
 
 func (hw *HtmlWriter) WriteHtml(writer io.Writer) {
@@ -35,5 +35,6 @@ func (t *rpcType) Cleanup(conn *srpc.Conn, request sub.CleanupRequest,
 			t.logger.Println(err)
 		}
 	}
+	t.checkObjectCache()
 	return nil
 }
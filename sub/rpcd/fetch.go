@@ -1,6 +1,7 @@
 package rpcd
 
 import (
+	"crypto/sha512"
 	"errors"
 	"flag"
 	"fmt"
@@ -19,9 +20,13 @@ import (
 	"github.com/Symantec/Dominator/lib/rateio"
 	"github.com/Symantec/Dominator/lib/srpc"
 	"github.com/Symantec/Dominator/proto/sub"
+	"github.com/Symantec/Dominator/sub/client"
 )
 
-const filePerms = syscall.S_IRUSR | syscall.S_IWUSR | syscall.S_IRGRP
+const (
+	filePerms       = syscall.S_IRUSR | syscall.S_IWUSR | syscall.S_IRGRP
+	peerDialTimeout = time.Second * 15
+)
 
 var (
 	exitOnFetchFailure = flag.Bool("exitOnFetchFailure", false,
@@ -73,9 +78,23 @@ func (t *rpcType) fetchAndUnlock(request sub.FetchRequest) error {
 
 func (t *rpcType) doFetch(request sub.FetchRequest) error {
 	defer t.clearFetchInProgress()
+	t.objectCache.deferEviction()
+	defer t.checkObjectCache()
 	objectServer := objectclient.NewObjectClient(request.ServerAddress)
 	defer objectServer.Close()
 	defer t.scannerConfiguration.BoostCpuLimit(t.logger)
+	if len(request.Peers) > 0 || len(request.Deltas) > 0 {
+		if len(request.Peers) > 0 {
+			request.Hashes = t.fetchFromPeers(request)
+		}
+		if len(request.Deltas) > 0 {
+			request.Hashes = t.fetchDeltas(request)
+		}
+		if len(request.Hashes) < 1 {
+			t.rescanObjectCacheFunction()
+			return nil
+		}
+	}
 	benchmark := false
 	linkSpeed, haveLinkSpeed := netspeed.GetSpeedToAddress(
 		request.ServerAddress)
@@ -144,6 +163,70 @@ func (t *rpcType) doFetch(request sub.FetchRequest) error {
 	return nil
 }
 
+// fetchFromPeers will fetch objects from the peers, returning the objects which
+// could not be fetched. Failures are not fatal, since the objects which remain
+// are fetched from the objectserver.
+func (t *rpcType) fetchFromPeers(request sub.FetchRequest) []hash.Hash {
+	wanted := make(map[hash.Hash]struct{}, len(request.Hashes))
+	for _, hashVal := range request.Hashes {
+		wanted[hashVal] = struct{}{}
+	}
+	for _, peer := range request.Peers {
+		hashes := make([]hash.Hash, 0, len(peer.Hashes))
+		for _, hashVal := range peer.Hashes {
+			if _, ok := wanted[hashVal]; ok {
+				hashes = append(hashes, hashVal)
+			}
+		}
+		if len(hashes) < 1 {
+			continue
+		}
+		t.logger.Printf("Fetch(%s) %d objects from peer\n",
+			peer.Address, len(hashes))
+		var totalLength uint64
+		timeStart := time.Now()
+		err := t.fetchFromPeer(peer.Address, hashes,
+			func(hashVal hash.Hash, length uint64) {
+				delete(wanted, hashVal)
+				totalLength += length
+			})
+		if err != nil {
+			t.logger.Printf("Error fetching from peer: %s: %s\n",
+				peer.Address, err)
+			continue
+		}
+		t.logger.Printf("Fetch(%s) complete. Read: %s in %s\n", peer.Address,
+			format.FormatBytes(totalLength),
+			format.Duration(time.Since(timeStart)))
+	}
+	remaining := make([]hash.Hash, 0, len(wanted))
+	for _, hashVal := range request.Hashes {
+		if _, ok := wanted[hashVal]; ok {
+			remaining = append(remaining, hashVal)
+		}
+	}
+	return remaining
+}
+
+func (t *rpcType) fetchFromPeer(address string, hashes []hash.Hash,
+	fetched func(hashVal hash.Hash, length uint64)) error {
+	srpcClient, err := srpc.DialHTTP("tcp", address, peerDialTimeout)
+	if err != nil {
+		return err
+	}
+	defer srpcClient.Close()
+	return client.GetObjects(srpcClient, hashes,
+		func(hashVal hash.Hash, reader io.Reader, length uint64) error {
+			err := readOneVerified(t.objectsDir, hashVal, length,
+				t.networkReaderContext.NewReader(reader))
+			if err != nil {
+				return err
+			}
+			fetched(hashVal, length)
+			return nil
+		})
+}
+
 func (t *rpcType) logFetch(request sub.FetchRequest, speed uint64) {
 	speedString := "unlimited speed"
 	if speed > 0 {
@@ -180,6 +263,24 @@ func readOne(objectsDir string, hash hash.Hash, length uint64,
 	return fsutil.CopyToFile(filename, filePerms, reader, length)
 }
 
+// readOneVerified is like readOne, except that the object is removed if it does
+// not match the hash. Peers are not trusted like the objectserver.
+func readOneVerified(objectsDir string, hashVal hash.Hash, length uint64,
+	reader io.Reader) error {
+	hasher := sha512.New()
+	err := readOne(objectsDir, hashVal, length, io.TeeReader(reader, hasher))
+	if err != nil {
+		return err
+	}
+	var readHash hash.Hash
+	copy(readHash[:], hasher.Sum(nil))
+	if readHash != hashVal {
+		os.Remove(path.Join(objectsDir, objectcache.HashToFilename(hashVal)))
+		return fmt.Errorf("hash mismatch for object: %x", hashVal)
+	}
+	return nil
+}
+
 func (t *rpcType) clearFetchInProgress() {
 	t.rwLock.Lock()
 	defer t.rwLock.Unlock()
@@ -0,0 +1,180 @@
+package rpcd
+
+import (
+	"bufio"
+	"crypto/sha512"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"syscall"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/format"
+	"github.com/Symantec/Dominator/lib/hash"
+	"github.com/Symantec/Dominator/lib/objectcache"
+	"github.com/Symantec/Dominator/lib/rsync"
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/objectserver"
+	"github.com/Symantec/Dominator/proto/sub"
+)
+
+// rateLimitedConn reads from a connection through a rate limited reader. It
+// implements io.ByteReader, so that a gob.Decoder does not read ahead of the
+// block data which follows the encoded messages.
+type rateLimitedConn struct {
+	conn   *srpc.Conn
+	reader *bufio.Reader
+}
+
+func (conn *rateLimitedConn) Flush() error {
+	return conn.conn.Flush()
+}
+
+func (conn *rateLimitedConn) Read(b []byte) (int, error) {
+	return conn.reader.Read(b)
+}
+
+func (conn *rateLimitedConn) ReadByte() (byte, error) {
+	return conn.reader.ReadByte()
+}
+
+func (conn *rateLimitedConn) Write(b []byte) (int, error) {
+	return conn.conn.Write(b)
+}
+
+// fetchDeltas will build objects from existing files and block deltas served
+// by the objectserver, returning the objects which were not built. Failures
+// are not fatal, since the objects which remain are fetched whole.
+func (t *rpcType) fetchDeltas(request sub.FetchRequest) []hash.Hash {
+	wanted := make(map[hash.Hash]struct{}, len(request.Hashes))
+	for _, hashVal := range request.Hashes {
+		wanted[hashVal] = struct{}{}
+	}
+	var srpcClient *srpc.Client
+	for _, delta := range request.Deltas {
+		if _, ok := wanted[delta.Hash]; !ok {
+			continue
+		}
+		if srpcClient == nil {
+			var err error
+			srpcClient, err = srpc.DialHTTP("tcp", request.ServerAddress, 0)
+			if err != nil {
+				t.logger.Printf("Error dialing: %s: %s\n",
+					request.ServerAddress, err)
+				break
+			}
+			defer srpcClient.Close()
+		}
+		timeStart := time.Now()
+		stats, err := t.fetchDelta(srpcClient, delta)
+		if err != nil {
+			t.logger.Printf("Error fetching delta for: %s: %s\n",
+				delta.BasePathname, err)
+			continue
+		}
+		delete(wanted, delta.Hash)
+		t.logger.Printf("Fetch(%s) delta for: %s. Read: %s in %s\n",
+			request.ServerAddress, delta.BasePathname,
+			format.FormatBytes(stats.NumRead),
+			format.Duration(time.Since(timeStart)))
+	}
+	remaining := make([]hash.Hash, 0, len(wanted))
+	for _, hashVal := range request.Hashes {
+		if _, ok := wanted[hashVal]; ok {
+			remaining = append(remaining, hashVal)
+		}
+	}
+	return remaining
+}
+
+// fetchDelta will copy the base file and then replace the blocks which differ
+// from the object. The result is checked against the hash before it is added
+// to the object cache.
+func (t *rpcType) fetchDelta(srpcClient *srpc.Client,
+	delta sub.FetchDelta) (rsync.Stats, error) {
+	baseFile, err := os.Open(path.Join(t.rootDir, delta.BasePathname))
+	if err != nil {
+		return rsync.Stats{}, err
+	}
+	defer baseFile.Close()
+	fi, err := baseFile.Stat()
+	if err != nil {
+		return rsync.Stats{}, err
+	}
+	if !fi.Mode().IsRegular() {
+		return rsync.Stats{}, errors.New("base is not a regular file")
+	}
+	filename := path.Join(t.objectsDir, objectcache.HashToFilename(delta.Hash))
+	if err := os.MkdirAll(path.Dir(filename), syscall.S_IRWXU); err != nil {
+		return rsync.Stats{}, err
+	}
+	tmpFilename := filename + "~"
+	tmpFile, err := os.OpenFile(tmpFilename, os.O_CREATE|os.O_TRUNC|os.O_RDWR,
+		filePerms)
+	if err != nil {
+		return rsync.Stats{}, err
+	}
+	defer os.Remove(tmpFilename)
+	defer tmpFile.Close()
+	rawConn, err := srpcClient.Call("ObjectServer.GetObjectDelta")
+	if err != nil {
+		return rsync.Stats{}, err
+	}
+	defer rawConn.Close()
+	conn := &rateLimitedConn{
+		conn:   rawConn,
+		reader: bufio.NewReader(t.networkReaderContext.NewReader(rawConn)),
+	}
+	encoder := gob.NewEncoder(conn)
+	decoder := gob.NewDecoder(conn)
+	err = encoder.Encode(objectserver.GetObjectDeltaRequest{Hash: delta.Hash})
+	if err != nil {
+		return rsync.Stats{}, err
+	}
+	if err := conn.Flush(); err != nil {
+		return rsync.Stats{}, err
+	}
+	var response objectserver.GetObjectDeltaResponse
+	if err := decoder.Decode(&response); err != nil {
+		return rsync.Stats{}, err
+	}
+	if response.Error != "" {
+		return rsync.Stats{}, errors.New(response.Error)
+	}
+	// Blocks which match are not sent, so start with a copy of the base file.
+	if _, err := io.Copy(tmpFile, baseFile); err != nil {
+		return rsync.Stats{}, err
+	}
+	if err := tmpFile.Truncate(int64(response.Size)); err != nil {
+		return rsync.Stats{}, err
+	}
+	if _, err := baseFile.Seek(0, io.SeekStart); err != nil {
+		return rsync.Stats{}, err
+	}
+	baseSize := uint64(fi.Size())
+	if baseSize > response.Size {
+		baseSize = response.Size
+	}
+	stats, err := rsync.GetBlocks(conn, decoder, encoder, baseFile, tmpFile,
+		response.Size, baseSize)
+	if err != nil {
+		return rsync.Stats{}, err
+	}
+	if _, err := tmpFile.Seek(0, io.SeekStart); err != nil {
+		return rsync.Stats{}, err
+	}
+	hasher := sha512.New()
+	if _, err := io.Copy(hasher, tmpFile); err != nil {
+		return rsync.Stats{}, err
+	}
+	var hashVal hash.Hash
+	copy(hashVal[:], hasher.Sum(nil))
+	if hashVal != delta.Hash {
+		return rsync.Stats{}, fmt.Errorf("hash mismatch for object: %x",
+			delta.Hash)
+	}
+	return stats, os.Rename(tmpFilename, filename)
+}
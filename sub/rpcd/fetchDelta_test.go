@@ -0,0 +1,51 @@
+package rpcd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/Symantec/Dominator/lib/rateio"
+	"github.com/Symantec/Dominator/lib/srpc"
+)
+
+func TestRateLimitedConnMixedReads(t *testing.T) {
+	var buffer bytes.Buffer
+	encoder := gob.NewEncoder(&buffer)
+	if err := encoder.Encode("header"); err != nil {
+		t.Fatal(err)
+	}
+	buffer.WriteString("raw block data")
+	if err := encoder.Encode("trailer"); err != nil {
+		t.Fatal(err)
+	}
+	rawConn := &srpc.Conn{ReadWriter: bufio.NewReadWriter(
+		bufio.NewReader(&buffer), bufio.NewWriter(ioutil.Discard))}
+	readerContext := rateio.NewReaderContext(1<<30, 100,
+		&rateio.ReadMeasurer{})
+	conn := &rateLimitedConn{
+		conn:   rawConn,
+		reader: bufio.NewReader(readerContext.NewReader(rawConn)),
+	}
+	decoder := gob.NewDecoder(conn)
+	var message string
+	if err := decoder.Decode(&message); err != nil {
+		t.Fatal(err)
+	} else if message != "header" {
+		t.Errorf("first message: %s != header", message)
+	}
+	data := make([]byte, len("raw block data"))
+	if _, err := io.ReadFull(conn, data); err != nil {
+		t.Fatal(err)
+	} else if string(data) != "raw block data" {
+		t.Errorf("raw data: %s", string(data))
+	}
+	if err := decoder.Decode(&message); err != nil {
+		t.Fatal(err)
+	} else if message != "trailer" {
+		t.Errorf("second message: %s != trailer", message)
+	}
+}
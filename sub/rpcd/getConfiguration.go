@@ -24,5 +24,8 @@ func (t *rpcType) getConfiguration() sub.Configuration {
 		t.scannerConfiguration.FsScanContext.GetContext().SpeedPercent()
 	configuration.ScanExclusionList =
 		t.scannerConfiguration.ScanFilter.FilterLines
+	configuration.ObjectCacheMaxBytes = *objectCacheMaxBytes
+	configuration.ObjectCacheMinFreeBytes = *objectCacheMinFreeBytes
+	configuration.ObjectCacheUsage = t.objectCache.getUsage()
 	return configuration
 }
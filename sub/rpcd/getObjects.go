@@ -0,0 +1,80 @@
+package rpcd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/Symantec/Dominator/lib/objectcache"
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/objectserver"
+)
+
+var (
+	maxPeerServes = flag.Uint("maxPeerServes", 2,
+		"Maximum number of peers to serve objects to concurrently (0: none)")
+)
+
+// GetObjects serves objects from the object cache to peer subs. If too many
+// peers are being served the request is rejected, so that the peer will fall
+// back to another peer or the objectserver.
+func (t *rpcType) GetObjects(conn *srpc.Conn, decoder srpc.Decoder,
+	encoder srpc.Encoder) error {
+	defer conn.Flush()
+	var request objectserver.GetObjectsRequest
+	var response objectserver.GetObjectsResponse
+	if err := decoder.Decode(&request); err != nil {
+		return err
+	}
+	select {
+	case t.peerServeSemaphore <- struct{}{}:
+		defer func() { <-t.peerServeSemaphore }()
+	default:
+		response.ResponseString = "too many peers being served"
+		return encoder.Encode(response)
+	}
+	files := make([]*os.File, 0, len(request.Hashes))
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+	for _, hashVal := range request.Hashes {
+		file, err := os.Open(path.Join(t.objectsDir,
+			objectcache.HashToFilename(hashVal)))
+		if err != nil {
+			if os.IsNotExist(err) {
+				err = fmt.Errorf("unknown object: %x", hashVal)
+			}
+			response.ResponseString = err.Error()
+			return encoder.Encode(response)
+		}
+		files = append(files, file)
+		fi, err := file.Stat()
+		if err != nil {
+			response.ResponseString = err.Error()
+			return encoder.Encode(response)
+		}
+		response.ObjectSizes = append(response.ObjectSizes, uint64(fi.Size()))
+	}
+	if err := encoder.Encode(response); err != nil {
+		return err
+	}
+	for index, file := range files {
+		nCopied, err := io.Copy(conn.Writer, file)
+		if err != nil {
+			t.logger.Printf("Error copying: %s\n", err)
+			return err
+		}
+		if uint64(nCopied) != response.ObjectSizes[index] {
+			return fmt.Errorf("expected length: %d, got: %d for: %x",
+				response.ObjectSizes[index], nCopied, request.Hashes[index])
+		}
+		t.touchObject(request.Hashes[index])
+	}
+	t.logger.Printf("GetObjects(): sent %d objects to peer: %s\n",
+		len(request.Hashes), conn.RemoteAddr())
+	return nil
+}
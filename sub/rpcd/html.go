@@ -3,9 +3,28 @@ package rpcd
 import (
 	"fmt"
 	"io"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/format"
 )
 
 func (hw *HtmlWriter) writeHtml(writer io.Writer) {
 	fmt.Fprintf(writer, "Image of last successful update: \"%s\"<br>\n",
 		*hw.lastSuccessfulImageName)
+	usage := hw.objectCache.getUsage()
+	fmt.Fprintf(writer, "Object cache: %d objects, %s",
+		usage.NumObjects, format.FormatBytes(usage.NumBytes))
+	if *objectCacheMaxBytes > 0 {
+		fmt.Fprintf(writer, ", max: %s",
+			format.FormatBytes(*objectCacheMaxBytes))
+	}
+	if *objectCacheMinFreeBytes > 0 {
+		fmt.Fprintf(writer, ", min free: %s",
+			format.FormatBytes(*objectCacheMinFreeBytes))
+	}
+	if usage.NumEvicted > 0 {
+		fmt.Fprintf(writer, ", %d evicted, last %s ago", usage.NumEvicted,
+			format.Duration(time.Since(usage.LastEviction)))
+	}
+	fmt.Fprintln(writer, "<br>")
 }
@@ -0,0 +1,63 @@
+package rpcd
+
+import (
+	"flag"
+	"os"
+	"time"
+
+	"github.com/Symantec/Dominator/sub/lib"
+)
+
+var (
+	resumeInterruptedUpdates = flag.Bool("resumeInterruptedUpdates", true,
+		"If true, complete an update which was interrupted, else report it")
+)
+
+// checkInterruptedUpdate will look for the journal of an update which did not
+// complete. The update is either resumed in the background or reported in
+// Poll responses until the next update.
+func (t *rpcType) checkInterruptedUpdate() {
+	if _, err := os.Stat(t.journalFilename); err != nil {
+		if !os.IsNotExist(err) {
+			t.logger.Println(err)
+		}
+		return
+	}
+	interruptedUpdate, err := lib.GetInterruptedUpdate(t.journalFilename)
+	if err != nil {
+		t.logger.Printf("Error reading update journal: %s\n", err)
+		return
+	}
+	t.logger.Printf(
+		"Found interrupted update to image: \"%s\" started at: %s\n",
+		interruptedUpdate.ImageName, interruptedUpdate.StartTime)
+	if *readOnly || *disableUpdates || !*resumeInterruptedUpdates {
+		t.interruptedUpdate = interruptedUpdate
+		return
+	}
+	t.updateInProgress = true
+	go t.resumeUpdateAndUnlock()
+}
+
+func (t *rpcType) resumeUpdateAndUnlock() {
+	defer t.clearUpdateInProgress()
+	defer t.scannerConfiguration.BoostCpuLimit(t.logger)
+	t.disableScannerFunc(true)
+	defer t.disableScannerFunc(false)
+	startTime := time.Now()
+	imageName, hadTriggerFailures, err := lib.ResumeUpdate(t.journalFilename,
+		t.rootDir, t.objectsDir, t.scannerConfiguration.ScanFilter,
+		t.runTriggers, t.logger)
+	t.lastUpdateHadTriggerFailures = hadTriggerFailures
+	t.lastUpdateError = err
+	if err != nil {
+		t.logger.Printf("Resumed update: last error: %s\n", err)
+		return
+	}
+	t.rwLock.Lock()
+	t.lastSuccessfulImageName = imageName
+	t.rwLock.Unlock()
+	t.logger.Printf("Resumed update completed in %s\n",
+		time.Since(startTime))
+	t.checkObjectCache()
+}
@@ -0,0 +1,172 @@
+package rpcd
+
+import (
+	"flag"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/format"
+	"github.com/Symantec/Dominator/lib/hash"
+	"github.com/Symantec/Dominator/lib/objectcache"
+	"github.com/Symantec/Dominator/proto/sub"
+)
+
+var (
+	objectCacheMaxBytes = flag.Uint64("objectCacheMaxBytes", 0,
+		"Maximum size of the object cache in bytes (0: unlimited)")
+	objectCacheMinFreeBytes = flag.Uint64("objectCacheMinFreeBytes", 0,
+		"Minimum free space to keep on the file-system by evicting objects from the object cache (0: none)")
+)
+
+// objectCacheType enforces the object cache policy. Objects are evicted least
+// recently used first, using the modification time of the object files, which
+// is updated when an object is fetched or served to a peer. Once objects have
+// been fetched, eviction is deferred until the next successful update, since the
+// sub cannot tell which of the cached objects the update will need.
+type objectCacheType struct {
+	mutex            sync.Mutex
+	evictionDeferred bool
+	usage            sub.ObjectCacheUsage
+}
+
+type cachedObject struct {
+	hashVal hash.Hash
+	size    uint64
+	mtime   time.Time
+}
+
+type cachedObjectList []cachedObject // Sorted by mtime.
+
+func (list cachedObjectList) Len() int {
+	return len(list)
+}
+
+func (list cachedObjectList) Less(left, right int) bool {
+	return list[left].mtime.Before(list[right].mtime)
+}
+
+func (list cachedObjectList) Swap(left, right int) {
+	list[left], list[right] = list[right], list[left]
+}
+
+func (oc *objectCacheType) getUsage() *sub.ObjectCacheUsage {
+	oc.mutex.Lock()
+	defer oc.mutex.Unlock()
+	usage := oc.usage
+	return &usage
+}
+
+// deferEviction will prevent eviction until allowEviction is called.
+func (oc *objectCacheType) deferEviction() {
+	oc.mutex.Lock()
+	defer oc.mutex.Unlock()
+	oc.evictionDeferred = true
+}
+
+func (oc *objectCacheType) allowEviction() {
+	oc.mutex.Lock()
+	defer oc.mutex.Unlock()
+	oc.evictionDeferred = false
+}
+
+// enforceObjectCachePolicy will evict objects until the policy is met (unless
+// eviction is deferred) and will update the usage. It returns true if objects
+// were evicted, in which case the object cache should be rescanned. It must not
+// be called while objects are being added or removed, including while an
+// update is in progress.
+func (t *rpcType) enforceObjectCachePolicy() bool {
+	hashes, err := objectcache.ScanObjectCache(t.objectsDir)
+	if err != nil {
+		t.logger.Printf("Error scanning object cache: %s\n", err)
+		return false
+	}
+	objects := make(cachedObjectList, 0, len(hashes))
+	var totalBytes uint64
+	for _, hashVal := range hashes {
+		var stat syscall.Stat_t
+		err := syscall.Stat(path.Join(t.objectsDir,
+			objectcache.HashToFilename(hashVal)), &stat)
+		if err != nil {
+			continue
+		}
+		object := cachedObject{
+			hashVal: hashVal,
+			size:    uint64(stat.Size),
+			mtime:   time.Unix(int64(stat.Mtim.Sec), int64(stat.Mtim.Nsec)),
+		}
+		objects = append(objects, object)
+		totalBytes += object.size
+	}
+	var bytesToEvict uint64
+	if *objectCacheMaxBytes > 0 && totalBytes > *objectCacheMaxBytes {
+		bytesToEvict = totalBytes - *objectCacheMaxBytes
+	}
+	if *objectCacheMinFreeBytes > 0 {
+		var statfs syscall.Statfs_t
+		if err := syscall.Statfs(t.objectsDir, &statfs); err != nil {
+			t.logger.Printf("Error getting file-system stats: %s\n", err)
+		} else {
+			freeBytes := statfs.Bavail * uint64(statfs.Bsize)
+			if freeBytes < *objectCacheMinFreeBytes &&
+				*objectCacheMinFreeBytes-freeBytes > bytesToEvict {
+				bytesToEvict = *objectCacheMinFreeBytes - freeBytes
+			}
+		}
+	}
+	t.objectCache.mutex.Lock()
+	defer t.objectCache.mutex.Unlock()
+	var numEvicted, bytesEvicted uint64
+	if bytesToEvict > 0 && t.objectCache.evictionDeferred {
+		t.logger.Printf(
+			"Object cache policy not met: %s, eviction deferred until update\n",
+			format.FormatBytes(bytesToEvict))
+	} else if bytesToEvict > 0 {
+		sort.Sort(objects)
+		for _, object := range objects {
+			if bytesEvicted >= bytesToEvict {
+				break
+			}
+			err := os.Remove(path.Join(t.objectsDir,
+				objectcache.HashToFilename(object.hashVal)))
+			if err != nil {
+				t.logger.Println(err)
+				continue
+			}
+			numEvicted++
+			bytesEvicted += object.size
+		}
+		if bytesEvicted < bytesToEvict {
+			t.logger.Printf("Object cache policy not met: %s remaining\n",
+				format.FormatBytes(bytesToEvict-bytesEvicted))
+		}
+	}
+	t.objectCache.usage.NumObjects = uint64(len(objects)) - numEvicted
+	t.objectCache.usage.NumBytes = totalBytes - bytesEvicted
+	if numEvicted < 1 {
+		return false
+	}
+	t.objectCache.usage.NumEvicted += numEvicted
+	t.objectCache.usage.LastEviction = time.Now()
+	t.logger.Printf("Evicted %d objects (%s) from the object cache\n",
+		numEvicted, format.FormatBytes(bytesEvicted))
+	return true
+}
+
+// checkObjectCache will enforce the object cache policy and rescan the object
+// cache if objects were evicted.
+func (t *rpcType) checkObjectCache() {
+	if t.enforceObjectCachePolicy() {
+		t.rescanObjectCacheFunction()
+	}
+}
+
+// touchObject marks the object as recently used.
+func (t *rpcType) touchObject(hashVal hash.Hash) {
+	now := time.Now()
+	os.Chtimes(path.Join(t.objectsDir, objectcache.HashToFilename(hashVal)),
+		now, now)
+}
@@ -0,0 +1,65 @@
+package rpcd
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/hash"
+	"github.com/Symantec/Dominator/lib/log/testlogger"
+	"github.com/Symantec/Dominator/lib/objectcache"
+)
+
+func writeTestObject(t *testing.T, objectsDir string, hashVal hash.Hash,
+	mtime time.Time) string {
+	filename := path.Join(objectsDir, objectcache.HashToFilename(hashVal))
+	if err := os.MkdirAll(path.Dir(filename), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filename, make([]byte, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filename, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestObjectCacheEviction(t *testing.T) {
+	objectsDir, err := ioutil.TempDir("", "objectCache_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(objectsDir)
+	now := time.Now()
+	oldFile := writeTestObject(t, objectsDir, hash.Hash{0xde, 0xad},
+		now.Add(-time.Hour))
+	newFile := writeTestObject(t, objectsDir, hash.Hash{0xbe, 0xef}, now)
+	savedMaxBytes := *objectCacheMaxBytes
+	defer func() { *objectCacheMaxBytes = savedMaxBytes }()
+	*objectCacheMaxBytes = 150
+	rpcObj := &rpcType{objectsDir: objectsDir, logger: testlogger.New(t)}
+	rpcObj.objectCache.deferEviction()
+	if rpcObj.enforceObjectCachePolicy() {
+		t.Error("objects evicted while eviction is deferred")
+	}
+	if usage := rpcObj.objectCache.getUsage(); usage.NumObjects != 2 {
+		t.Errorf("number of objects: %d != 2", usage.NumObjects)
+	}
+	rpcObj.objectCache.allowEviction()
+	if !rpcObj.enforceObjectCachePolicy() {
+		t.Fatal("no objects evicted")
+	}
+	if _, err := os.Stat(oldFile); !os.IsNotExist(err) {
+		t.Error("least recently used object not evicted")
+	}
+	if _, err := os.Stat(newFile); err != nil {
+		t.Errorf("most recently used object evicted: %s", err)
+	}
+	usage := rpcObj.objectCache.getUsage()
+	if usage.NumObjects != 1 || usage.NumBytes != 100 || usage.NumEvicted != 1 {
+		t.Errorf("unexpected usage: %+v", *usage)
+	}
+}
@@ -0,0 +1,243 @@
+package rpcd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/filter"
+	"github.com/Symantec/Dominator/lib/fsutil"
+	jsonlib "github.com/Symantec/Dominator/lib/json"
+	"github.com/Symantec/Dominator/lib/log"
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/sub"
+)
+
+// pathLocksType holds the path locks, which are stored in a JSON file so that
+// they may also be edited locally. The file is re-read when it changes.
+type pathLocksType struct {
+	filename string
+	mutex    sync.Mutex
+	modTime  time.Time
+	locks    []sub.PathLock
+}
+
+func (t *rpcType) LockPaths(conn *srpc.Conn, request sub.LockPathsRequest,
+	reply *sub.LockPathsResponse) error {
+	if *readOnly {
+		return errors.New("LockPaths() rejected due to read-only mode")
+	}
+	for _, lock := range request.Locks {
+		t.logger.Printf("LockPaths(%s): until %s by %s: %s\n",
+			lock.Pattern, lock.ExpiresAt.Format(time.RFC3339),
+			conn.Username(), lock.Reason)
+	}
+	for _, pattern := range request.UnlockPatterns {
+		t.logger.Printf("UnlockPaths(%s): by %s\n", pattern, conn.Username())
+	}
+	return t.pathLocks.update(request, conn.Username())
+}
+
+// get returns the unexpired locks.
+func (pl *pathLocksType) get(logger log.Logger) []sub.PathLock {
+	pl.mutex.Lock()
+	defer pl.mutex.Unlock()
+	if err := pl.load(); err != nil {
+		logger.Printf("Error loading path locks: %s\n", err)
+	}
+	return getUnexpiredLocks(pl.locks)
+}
+
+// load will read the locks file if it has changed. The mutex must be held.
+func (pl *pathLocksType) load() error {
+	fi, err := os.Stat(pl.filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			pl.locks = nil
+			pl.modTime = time.Time{}
+			return nil
+		}
+		return err
+	}
+	if fi.ModTime().Equal(pl.modTime) {
+		return nil
+	}
+	file, err := os.Open(pl.filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var locks []sub.PathLock
+	if err := json.NewDecoder(file).Decode(&locks); err != nil {
+		return errors.New("error decoding: " + pl.filename + ": " +
+			err.Error())
+	}
+	pl.locks = locks
+	pl.modTime = fi.ModTime()
+	return nil
+}
+
+func (pl *pathLocksType) update(request sub.LockPathsRequest,
+	username string) error {
+	for _, lock := range request.Locks {
+		if _, err := filter.New([]string{lock.Pattern}); err != nil {
+			return err
+		}
+		if !lock.ExpiresAt.After(time.Now()) {
+			return errors.New("path lock expiry is not in the future")
+		}
+		if lock.Reason == "" {
+			return errors.New("no reason given for path lock")
+		}
+	}
+	pl.mutex.Lock()
+	defer pl.mutex.Unlock()
+	if err := pl.load(); err != nil {
+		return err
+	}
+	removePatterns := make(map[string]struct{})
+	for _, pattern := range request.UnlockPatterns {
+		removePatterns[pattern] = struct{}{}
+	}
+	for _, lock := range request.Locks {
+		removePatterns[lock.Pattern] = struct{}{}
+	}
+	var locks []sub.PathLock
+	for _, lock := range getUnexpiredLocks(pl.locks) {
+		if _, ok := removePatterns[lock.Pattern]; !ok {
+			locks = append(locks, lock)
+		}
+	}
+	for _, lock := range request.Locks {
+		if lock.Username == "" {
+			lock.Username = username
+		}
+		locks = append(locks, lock)
+	}
+	if len(locks) < 1 {
+		if err := os.Remove(pl.filename); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		pl.locks = nil
+		pl.modTime = time.Time{}
+		return nil
+	}
+	buffer := &bytes.Buffer{}
+	if err := jsonlib.WriteWithIndent(buffer, "    ", locks); err != nil {
+		return err
+	}
+	writer, err := fsutil.CreateRenamingWriter(pl.filename, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(buffer.Bytes()); err != nil {
+		writer.Close() // The file is not replaced after a write error.
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	pl.modTime = time.Time{} // Force a re-read to get the new time.
+	return pl.load()
+}
+
+func getUnexpiredLocks(locks []sub.PathLock) []sub.PathLock {
+	var unexpiredLocks []sub.PathLock
+	now := time.Now()
+	for _, lock := range locks {
+		if lock.ExpiresAt.After(now) {
+			unexpiredLocks = append(unexpiredLocks, lock)
+		}
+	}
+	return unexpiredLocks
+}
+
+// filterRequest will remove changes to locked paths from the request. A path
+// is not deleted if it contains a locked path.
+func (pl *pathLocksType) filterRequest(request *sub.UpdateRequest,
+	rootDirectoryName string, logger log.Logger) {
+	locks := pl.get(logger)
+	if len(locks) < 1 {
+		return
+	}
+	patterns := make([]string, 0, len(locks))
+	for _, lock := range locks {
+		patterns = append(patterns, lock.Pattern)
+	}
+	lockFilter, err := filter.New(patterns)
+	if err != nil {
+		logger.Printf("Error compiling path locks: %s\n", err)
+		return
+	}
+	isLocked := func(pathname string) bool {
+		if lockFilter.Match(pathname) {
+			logger.Printf("Skipping locked path: %s\n", pathname)
+			return true
+		}
+		return false
+	}
+	inodesToMake := request.InodesToMake[:0]
+	for _, inode := range request.InodesToMake {
+		if !isLocked(inode.Name) {
+			inodesToMake = append(inodesToMake, inode)
+		}
+	}
+	request.InodesToMake = inodesToMake
+	hardlinksToMake := request.HardlinksToMake[:0]
+	for _, hardlink := range request.HardlinksToMake {
+		if !isLocked(hardlink.NewLink) {
+			hardlinksToMake = append(hardlinksToMake, hardlink)
+		}
+	}
+	request.HardlinksToMake = hardlinksToMake
+	pathsToDelete := request.PathsToDelete[:0]
+	for _, pathname := range request.PathsToDelete {
+		if isLocked(pathname) {
+			continue
+		}
+		if containsLockedPath(rootDirectoryName, pathname, lockFilter) {
+			logger.Printf("Skipping delete of: %s containing locked paths\n",
+				pathname)
+			continue
+		}
+		pathsToDelete = append(pathsToDelete, pathname)
+	}
+	request.PathsToDelete = pathsToDelete
+	directoriesToMake := request.DirectoriesToMake[:0]
+	for _, inode := range request.DirectoriesToMake {
+		if !isLocked(inode.Name) {
+			directoriesToMake = append(directoriesToMake, inode)
+		}
+	}
+	request.DirectoriesToMake = directoriesToMake
+	inodesToChange := request.InodesToChange[:0]
+	for _, inode := range request.InodesToChange {
+		if !isLocked(inode.Name) {
+			inodesToChange = append(inodesToChange, inode)
+		}
+	}
+	request.InodesToChange = inodesToChange
+}
+
+func containsLockedPath(rootDirectoryName, pathname string,
+	lockFilter *filter.Filter) bool {
+	found := false
+	filepath.Walk(path.Join(rootDirectoryName, pathname),
+		func(walkPath string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			pathname := path.Join("/", walkPath[len(rootDirectoryName):])
+			if lockFilter.Match(pathname) {
+				found = true
+				return errors.New("found")
+			}
+			return nil
+		})
+	return found
+}
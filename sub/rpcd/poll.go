@@ -35,8 +35,11 @@ func (t *rpcType) Poll(conn *srpc.Conn, decoder srpc.Decoder,
 			response.LastUpdateError = t.lastUpdateError.Error()
 		}
 		response.LastUpdateHadTriggerFailures = t.lastUpdateHadTriggerFailures
+		response.LastTriggerFailures = t.lastTriggerFailures
 	}
 	response.LastSuccessfulImageName = t.lastSuccessfulImageName
+	response.InterruptedUpdate = t.interruptedUpdate
+	response.PathLocks = t.pathLocks.get(t.logger)
 	response.FreeSpace = t.getFreeSpace()
 	t.rwLock.RUnlock()
 	response.StartTime = startTime
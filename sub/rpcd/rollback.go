@@ -0,0 +1,51 @@
+package rpcd
+
+import (
+	"flag"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/sub"
+	"github.com/Symantec/Dominator/sub/lib"
+)
+
+var (
+	rollbackMaxUpdates = flag.Uint("rollbackMaxUpdates", 0,
+		"Number of updates to keep rollback data for (0: disable rollback)")
+	rollbackMinFreePercent = flag.Uint("rollbackMinFreePercent", 10,
+		"Delete rollback data to keep this percentage of the file-system free")
+)
+
+func (t *rpcType) Rollback(conn *srpc.Conn, request sub.RollbackRequest,
+	reply *sub.RollbackResponse) error {
+	if err := t.getUpdateLock(); err != nil {
+		t.logger.Println(err)
+		return err
+	}
+	defer t.clearUpdateInProgress()
+	if conn.Username() == "" {
+		t.logger.Printf("Rollback()\n")
+	} else {
+		t.logger.Printf("Rollback(): by %s\n", conn.Username())
+	}
+	defer t.scannerConfiguration.BoostCpuLimit(t.logger)
+	t.disableScannerFunc(true)
+	defer t.disableScannerFunc(false)
+	startTime := time.Now()
+	fs := t.fileSystemHistory.FileSystem()
+	imageName, hadTriggerFailures, err := lib.RollbackUpdate(
+		fs.RootDirectoryName(), t.rollbackDir, t.runTriggers, t.logger)
+	t.lastUpdateHadTriggerFailures = hadTriggerFailures
+	t.lastUpdateError = err
+	if err != nil {
+		t.logger.Printf("Rollback(): error: %s\n", err)
+		return err
+	}
+	t.rwLock.Lock()
+	t.lastSuccessfulImageName = imageName
+	t.rwLock.Unlock()
+	t.logger.Printf("Rollback() to image: %s completed in %s\n",
+		imageName, time.Since(startTime))
+	reply.ImageName = imageName
+	return nil
+}
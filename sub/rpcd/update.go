@@ -2,12 +2,16 @@ package rpcd
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"syscall"
 	"time"
 
 	jsonlib "github.com/Symantec/Dominator/lib/json"
@@ -25,6 +29,16 @@ var (
 		"If true, refuse all Update requests. For debugging only")
 	disableTriggers = flag.Bool("disableTriggers", false,
 		"If true, do not run any triggers. For debugging only")
+	healthCheckTimeout = flag.Duration("healthCheckTimeout", time.Minute,
+		"Default time a trigger health check may run before it is killed")
+	triggerTimeout = flag.Duration("triggerTimeout", 10*time.Minute,
+		"Default time a trigger command may run before it is killed (0: none)")
+)
+
+const (
+	healthCheckRetryInterval = 2 * time.Second
+	maxTriggerFailureOutput  = 4096
+	outputWaitDelay          = 5 * time.Second
 )
 
 func (t *rpcType) Update(conn *srpc.Conn, request sub.UpdateRequest,
@@ -60,6 +74,8 @@ func (t *rpcType) getUpdateLock() error {
 	}
 	t.updateInProgress = true
 	t.lastUpdateError = nil
+	t.interruptedUpdate = nil // The journal will be replaced.
+	t.lastTriggerFailures = nil
 	return nil
 }
 
@@ -71,12 +87,10 @@ func (t *rpcType) updateAndUnlock(request sub.UpdateRequest,
 	defer t.disableScannerFunc(false)
 	startTime := time.Now()
 	oldTriggers := &triggers.MergeableTriggers{}
-	file, err := os.Open(t.oldTriggersFilename)
+	oldTriggersData, err := ioutil.ReadFile(t.oldTriggersFilename)
 	if err == nil {
-		decoder := json.NewDecoder(file)
 		var trig triggers.Triggers
-		err = decoder.Decode(&trig.Triggers)
-		file.Close()
+		err = json.Unmarshal(oldTriggersData, &trig.Triggers)
 		if err == nil {
 			oldTriggers.Merge(&trig)
 		} else {
@@ -87,7 +101,7 @@ func (t *rpcType) updateAndUnlock(request sub.UpdateRequest,
 		// Merge new triggers into old triggers. This supports initial
 		// Domination of a machine and when the old triggers are incomplete.
 		oldTriggers.Merge(request.Triggers)
-		file, err = os.Create(t.oldTriggersFilename)
+		file, err := os.Create(t.oldTriggersFilename)
 		if err == nil {
 			writer := bufio.NewWriter(file)
 			if err := jsonlib.WriteWithIndent(writer, "    ",
@@ -98,9 +112,24 @@ func (t *rpcType) updateAndUnlock(request sub.UpdateRequest,
 			file.Close()
 		}
 	}
-	hadTriggerFailures, fsChangeDuration, lastUpdateError := lib.Update(
-		request, rootDirectoryName, t.objectsDir, oldTriggers.ExportTriggers(),
-		t.scannerConfiguration.ScanFilter, runTriggers, t.logger)
+	t.pathLocks.filterRequest(&request, rootDirectoryName, t.logger)
+	t.rwLock.RLock()
+	updateOptions := lib.UpdateOptions{
+		JournalFilename: t.journalFilename,
+		Rollback: lib.RollbackOptions{
+			Directory:         t.rollbackDir,
+			PreviousImageName: t.lastSuccessfulImageName,
+			MaxUpdates:        *rollbackMaxUpdates,
+			MinFreePercent:    *rollbackMinFreePercent,
+			TriggersFilename:  t.oldTriggersFilename,
+			PreviousTriggers:  oldTriggersData,
+		},
+	}
+	t.rwLock.RUnlock()
+	hadTriggerFailures, fsChangeDuration, lastUpdateError :=
+		lib.UpdateWithOptions(request, rootDirectoryName, t.objectsDir,
+			oldTriggers.ExportTriggers(), t.scannerConfiguration.ScanFilter,
+			t.runTriggers, updateOptions, t.logger)
 	t.lastUpdateHadTriggerFailures = hadTriggerFailures
 	t.lastUpdateError = lastUpdateError
 	timeTaken := time.Since(startTime)
@@ -110,6 +139,8 @@ func (t *rpcType) updateAndUnlock(request sub.UpdateRequest,
 		t.rwLock.Lock()
 		t.lastSuccessfulImageName = request.ImageName
 		t.rwLock.Unlock()
+		t.objectCache.allowEviction()
+		t.checkObjectCache()
 	}
 	t.logger.Printf("Update() completed in %s (change window: %s)\n",
 		timeTaken, fsChangeDuration)
@@ -123,23 +154,24 @@ func (t *rpcType) clearUpdateInProgress() {
 }
 
 // Returns true if there were failures.
-func runTriggers(triggers []*triggers.Trigger, action string,
+func (t *rpcType) runTriggers(triggerList []*triggers.Trigger, action string,
 	logger log.Logger) bool {
 	doReboot := false
 	hadFailures := false
 	needRestart := false
+	var healthChecks []*triggers.Trigger
 	logPrefix := ""
 	if *disableTriggers {
 		logPrefix = "Disabled: "
 	}
 	ppid := fmt.Sprint(os.Getppid())
-	for _, trigger := range triggers {
+	for _, trigger := range triggerList {
 		if trigger.DoReboot && action == "start" {
 			doReboot = true
 			break
 		}
 	}
-	for _, trigger := range triggers {
+	for _, trigger := range triggerList {
 		if trigger.Service == "subd" {
 			// Never kill myself, just restart.
 			if action == "start" {
@@ -152,12 +184,29 @@ func runTriggers(triggers []*triggers.Trigger, action string,
 		if *disableTriggers {
 			continue
 		}
-		if !runCommand(logger,
-			"run-in-mntns", ppid, "service", trigger.Service, action) {
+		output, ok := runCommand(logger,
+			getTimeout(trigger.TimeoutSeconds, *triggerTimeout),
+			"run-in-mntns", ppid, "service", trigger.Service, action)
+		if !ok {
 			hadFailures = true
+			t.addTriggerFailure(trigger.Service, action, output)
 			if trigger.DoReboot && action == "start" {
 				doReboot = false
 			}
+		} else if trigger.HealthCheck != "" && action == "start" {
+			healthChecks = append(healthChecks, trigger)
+		}
+	}
+	// Health checks are run once all the services have been started, since
+	// they may depend on each other.
+	if !doReboot {
+		for _, trigger := range healthChecks {
+			logger.Printf("Health check: service %s: %s\n",
+				trigger.Service, trigger.HealthCheck)
+			if output, ok := runHealthCheck(logger, ppid, trigger); !ok {
+				hadFailures = true
+				t.addTriggerFailure(trigger.Service, "health check", output)
+			}
 		}
 	}
 	if doReboot {
@@ -165,32 +214,127 @@ func runTriggers(triggers []*triggers.Trigger, action string,
 		if *disableTriggers {
 			return hadFailures
 		}
-		if !runCommand(logger, "reboot") {
+		if output, ok := runCommand(logger, 0, "reboot"); !ok {
 			hadFailures = true
+			t.addTriggerFailure("", "reboot", output)
 		}
 		return hadFailures
 	} else if needRestart {
 		logger.Printf("%sAction: service subd restart\n", logPrefix)
-		if !runCommand(logger,
-			"run-in-mntns", ppid, "service", "subd", "restart") {
+		output, ok := runCommand(logger, *triggerTimeout,
+			"run-in-mntns", ppid, "service", "subd", "restart")
+		if !ok {
 			hadFailures = true
+			t.addTriggerFailure("subd", "restart", output)
 		}
 	}
 	return hadFailures
 }
 
-// Returns true on success, else false.
-func runCommand(logger log.Logger, name string, args ...string) bool {
+func (t *rpcType) addTriggerFailure(service, action string, output []byte) {
+	if len(output) > maxTriggerFailureOutput {
+		output = output[len(output)-maxTriggerFailureOutput:]
+	}
+	t.rwLock.Lock()
+	defer t.rwLock.Unlock()
+	t.lastTriggerFailures = append(t.lastTriggerFailures, sub.TriggerFailure{
+		Service: service,
+		Action:  action,
+		Output:  string(output),
+	})
+}
+
+func getTimeout(seconds uint, defaultTimeout time.Duration) time.Duration {
+	if seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultTimeout
+}
+
+// runHealthCheck will run the health check for the trigger until it succeeds or
+// the retries are used up. The output of the last failed attempt is returned.
+func runHealthCheck(logger log.Logger, ppid string,
+	trigger *triggers.Trigger) ([]byte, bool) {
+	timeout := getTimeout(trigger.HealthCheckTimeoutSeconds,
+		*healthCheckTimeout)
+	for retry := uint(0); ; retry++ {
+		output, ok := runCommand(logger, timeout,
+			"run-in-mntns", ppid, "/bin/sh", "-c", trigger.HealthCheck)
+		if ok {
+			return nil, true
+		}
+		if retry >= trigger.HealthCheckRetries {
+			return output, false
+		}
+		time.Sleep(healthCheckRetryInterval)
+	}
+}
+
+// Returns true on success, else false. The command is run in a new process
+// group, which services it starts inherit unless they create their own session.
+// The whole process group (including such services) is killed if the command
+// does not complete within timeout. A zero timeout means no limit. The combined
+// output is returned. Output written by started services which hold on to the
+// output pipe after the command exits is collected for at most outputWaitDelay.
+func runCommand(logger log.Logger, timeout time.Duration, name string,
+	args ...string) ([]byte, bool) {
 	cmd := exec.Command(name, args...)
-	if logs, err := cmd.CombinedOutput(); err != nil {
-		errMsg := "error running: " + name
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	var output bytes.Buffer
+	reader, writer, err := os.Pipe()
+	if err == nil {
+		cmd.Stdout = writer
+		cmd.Stderr = writer
+		err = cmd.Start()
+		writer.Close()
+		if err != nil {
+			reader.Close()
+		} else {
+			err = waitForCommand(cmd, timeout, reader, &output)
+		}
+	}
+	if err != nil {
+		errMsg := name
 		for _, arg := range args {
 			errMsg += " " + arg
 		}
 		errMsg += ": " + err.Error()
 		logger.Printf("error running: %s\n", errMsg)
-		logger.Println(string(logs))
-		return false
+		logger.Println(output.String())
+		return output.Bytes(), false
+	}
+	return output.Bytes(), true
+}
+
+// waitForCommand waits for the command to exit while copying its output from
+// reader. The output is collected until the pipe is closed or until
+// outputWaitDelay after the command exits, whichever comes first.
+func waitForCommand(cmd *exec.Cmd, timeout time.Duration, reader *os.File,
+	output *bytes.Buffer) error {
+	copyDone := make(chan struct{})
+	go func() {
+		io.Copy(output, reader)
+		close(copyDone)
+	}()
+	var timer *time.Timer
+	if timeout > 0 {
+		timer = time.AfterFunc(timeout, func() {
+			syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		})
+	}
+	err := cmd.Wait()
+	if timer != nil && !timer.Stop() {
+		err = fmt.Errorf("killed after timeout: %s", timeout)
+	}
+	outputTimer := time.NewTimer(outputWaitDelay)
+	select {
+	case <-copyDone:
+		outputTimer.Stop()
+		reader.Close()
+	case <-outputTimer.C:
+		// A started service is holding the pipe open: stop reading from it.
+		reader.Close()
+		<-copyDone
 	}
-	return true
+	return err
 }
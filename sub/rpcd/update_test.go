@@ -0,0 +1,36 @@
+package rpcd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Symantec/Dominator/lib/log/testlogger"
+)
+
+func TestRunCommandWithStartedService(t *testing.T) {
+	startTime := time.Now()
+	output, ok := runCommand(testlogger.New(t), 0, "sh", "-c",
+		"sleep 20 & echo started")
+	if !ok {
+		t.Fatalf("command failed: %s", string(output))
+	}
+	if !bytes.Contains(output, []byte("started")) {
+		t.Errorf("missing output: %s", string(output))
+	}
+	if duration := time.Since(startTime); duration > 15*time.Second {
+		t.Errorf("command took: %s", duration)
+	}
+}
+
+func TestRunCommandTimeout(t *testing.T) {
+	startTime := time.Now()
+	_, ok := runCommand(testlogger.New(t), time.Second, "sh", "-c",
+		"sleep 20 & sleep 20")
+	if ok {
+		t.Error("command did not fail")
+	}
+	if duration := time.Since(startTime); duration > 15*time.Second {
+		t.Errorf("command took: %s", duration)
+	}
+}
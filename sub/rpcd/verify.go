@@ -0,0 +1,78 @@
+package rpcd
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/Symantec/Dominator/imageserver/client"
+	"github.com/Symantec/Dominator/lib/filesystem"
+	"github.com/Symantec/Dominator/lib/image"
+	"github.com/Symantec/Dominator/lib/srpc"
+	"github.com/Symantec/Dominator/proto/sub"
+)
+
+func (t *rpcType) Verify(conn *srpc.Conn, request sub.VerifyRequest,
+	reply *sub.VerifyResponse) error {
+	if request.ImageServerAddress == "" {
+		return errors.New("no imageserver address")
+	}
+	scanCount := t.fileSystemHistory.ScanCount()
+	fs := t.fileSystemHistory.FileSystem()
+	if fs == nil {
+		return errors.New("no file-system scan yet")
+	}
+	imageClient, err := srpc.DialHTTP("tcp", request.ImageServerAddress, 0)
+	if err != nil {
+		return err
+	}
+	defer imageClient.Close()
+	img, err := client.GetImage(imageClient, request.ImageName)
+	if err != nil {
+		return err
+	}
+	if img == nil {
+		return errors.New("unknown image: " + request.ImageName)
+	}
+	if err := verifyFileSystem(&fs.FileSystem.FileSystem, img, reply); err != nil {
+		return err
+	}
+	reply.ScanCount = scanCount
+	t.logger.Printf(
+		"Verify(%s) by %s: %d added, %d missing, %d modified, %d changed\n",
+		request.ImageName, conn.Username(), len(reply.AddedPaths),
+		len(reply.MissingPaths), len(reply.ModifiedPaths),
+		len(reply.MetadataChanges))
+	return nil
+}
+
+// verifyFileSystem fills in the differences between the scanned file-system
+// and the image. Paths which are not in a sparse image are not reported.
+func verifyFileSystem(fs *filesystem.FileSystem, img *image.Image,
+	reply *sub.VerifyResponse) error {
+	// Work on a copy, since the scanned file-system is shared.
+	buffer := &bytes.Buffer{}
+	if err := fs.Encode(buffer); err != nil {
+		return err
+	}
+	subFS, err := filesystem.Decode(buffer)
+	if err != nil {
+		return err
+	}
+	if err := subFS.RebuildInodePointers(); err != nil {
+		return err
+	}
+	subFS.BuildEntryMap()
+	if err := img.FileSystem.RebuildInodePointers(); err != nil {
+		return err
+	}
+	img.FileSystem.BuildEntryMap()
+	drift := subFS.ComputeDrift(img.FileSystem, img.Filter, nil)
+	reply.AddedPaths = drift.AddedPaths
+	reply.MissingPaths = drift.MissingPaths
+	reply.ModifiedPaths = drift.ModifiedPaths
+	for _, change := range drift.MetadataChanges {
+		reply.MetadataChanges = append(reply.MetadataChanges,
+			sub.MetadataChange(change))
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package rpcd
+
+import (
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/Symantec/Dominator/lib/filesystem"
+	"github.com/Symantec/Dominator/lib/filter"
+	"github.com/Symantec/Dominator/lib/image"
+	"github.com/Symantec/Dominator/proto/sub"
+)
+
+func makeVerifyFileSystem(names ...string) *filesystem.FileSystem {
+	fs := &filesystem.FileSystem{
+		InodeTable: make(filesystem.InodeTable),
+		DirectoryInode: filesystem.DirectoryInode{
+			Mode: syscall.S_IFDIR | 0755,
+		},
+	}
+	for index, name := range names {
+		inodeNumber := uint64(index + 1)
+		fs.InodeTable[inodeNumber] = &filesystem.RegularInode{
+			Mode: syscall.S_IFREG | 0644,
+		}
+		fs.EntryList = append(fs.EntryList,
+			&filesystem.DirectoryEntry{Name: name, InodeNumber: inodeNumber})
+	}
+	return fs
+}
+
+func TestVerifyFileSystem(t *testing.T) {
+	emptyFilter, err := filter.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		name     string
+		filter   *filter.Filter
+		expected sub.VerifyResponse
+	}{
+		{
+			name: "sparse image",
+			expected: sub.VerifyResponse{
+				MissingPaths: []string{"/missing"},
+			},
+		},
+		{
+			name:   "full image",
+			filter: emptyFilter,
+			expected: sub.VerifyResponse{
+				AddedPaths:   []string{"/local"},
+				MissingPaths: []string{"/missing"},
+			},
+		},
+	}
+	for _, test := range tests {
+		img := &image.Image{
+			Filter:     test.filter,
+			FileSystem: makeVerifyFileSystem("file", "missing"),
+		}
+		var reply sub.VerifyResponse
+		err := verifyFileSystem(makeVerifyFileSystem("file", "local"), img,
+			&reply)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(reply, test.expected) {
+			t.Errorf("%s: reply: %+v, expected: %+v",
+				test.name, reply, test.expected)
+		}
+	}
+}
@@ -21,8 +21,11 @@ type Configuration struct {
 	CpuLimiter           *cpulimiter.CpuLimiter
 	DefaultCpuPercent    uint
 	FsScanContext        *fsrateio.ReaderContext
+	FullScanInterval     time.Duration // Used if WatchForChanges is true.
 	NetworkReaderContext *rateio.ReaderContext
 	ScanFilter           *filter.Filter
+	SubdDirectory        string // Relative to the root. Not watched.
+	WatchForChanges      bool   // If true, rescan only the changed paths.
 }
 
 func (configuration *Configuration) BoostCpuLimit(logger log.Logger) {
@@ -107,7 +110,7 @@ type FileSystem struct {
 func ScanFileSystem(rootDirectoryName string, cacheDirectoryName string,
 	configuration *Configuration) (*FileSystem, error) {
 	return scanFileSystem(rootDirectoryName, cacheDirectoryName, configuration,
-		&FileSystem{})
+		&FileSystem{}, nil)
 }
 
 func (fs *FileSystem) ScanObjectCache() error {
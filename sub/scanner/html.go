@@ -43,4 +43,9 @@ func (configuration *Configuration) writeHtml(writer io.Writer) {
 			ctx.SpeedPercent(), format.FormatBytes(ctx.MaximumSpeed()))
 	}
 	fmt.Fprintf(writer, "Network Speed: %s<br>\n", speed)
+	if configuration.WatchForChanges {
+		fmt.Fprintf(writer,
+			"Watching for changes, full scan interval: %s<br>\n",
+			format.Duration(configuration.FullScanInterval))
+	}
 }
@@ -5,6 +5,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Symantec/Dominator/lib/filesystem/scanner"
 	"github.com/Symantec/Dominator/lib/log"
 )
 
@@ -40,18 +41,44 @@ func scannerDaemon(rootDirectoryName string, cacheDirectoryName string,
 	loweredPriority := false
 	var oldFS FileSystem
 	var sleepUntil time.Time
+	cw := &changeWatcher{
+		rootDirectoryName: rootDirectoryName,
+		configuration:     configuration,
+		logger:            logger,
+	}
+	scanAfterDisable := false
 	for ; ; time.Sleep(time.Until(sleepUntil)) {
 		sleepUntil = time.Now().Add(time.Second)
+		changedPaths := cw.getChangedPaths()
+		if changedPaths != nil && len(changedPaths) < 1 && !scanAfterDisable {
+			// Nothing changed: only respond to disable requests.
+			if checkScanDisableRequest() {
+				disableScanAcknowledge <- true
+				<-disableScanAcknowledge
+				scanAfterDisable = true
+			}
+			continue
+		}
 		fs, err := scanFileSystem(rootDirectoryName, cacheDirectoryName,
-			configuration, &oldFS)
+			configuration, &oldFS, changedPaths)
 		if err != nil {
 			if err.Error() == "DisableScan" {
+				cw.putChangedPaths(changedPaths)
 				disableScanAcknowledge <- true
 				<-disableScanAcknowledge
+				scanAfterDisable = true
+				continue
+			}
+			if err == scanner.ErrRescanRequired {
+				cw.requireFullScan()
 				continue
 			}
+			cw.requireFullScan()
 			logger.Printf("Error scanning: %s\n", err)
 		} else {
+			// Always scan after being disabled, so that the scan count
+			// changes after an update.
+			scanAfterDisable = false
 			oldFS.InodeTable = fs.InodeTable
 			oldFS.DirectoryInode = fs.DirectoryInode
 			fsChannel <- fs
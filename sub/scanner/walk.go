@@ -6,7 +6,8 @@ import (
 )
 
 func scanFileSystem(rootDirectoryName string, cacheDirectoryName string,
-	configuration *Configuration, oldFS *FileSystem) (*FileSystem, error) {
+	configuration *Configuration, oldFS *FileSystem,
+	changedPaths map[string]struct{}) (*FileSystem, error) {
 	var fileSystem FileSystem
 	fileSystem.configuration = configuration
 	fileSystem.rootDirectoryName = rootDirectoryName
@@ -15,9 +16,17 @@ func scanFileSystem(rootDirectoryName string, cacheDirectoryName string,
 	if configuration.CpuLimiter != nil {
 		hasher = scanner.NewCpuLimitedHasher(configuration.CpuLimiter, hasher)
 	}
-	fs, err := scanner.ScanFileSystem(rootDirectoryName,
-		configuration.FsScanContext, configuration.ScanFilter,
-		checkScanDisableRequest, hasher, &oldFS.FileSystem)
+	var fs *scanner.FileSystem
+	var err error
+	if changedPaths == nil {
+		fs, err = scanner.ScanFileSystem(rootDirectoryName,
+			configuration.FsScanContext, configuration.ScanFilter,
+			checkScanDisableRequest, hasher, &oldFS.FileSystem)
+	} else {
+		fs, err = scanner.ScanFileSystemChanges(rootDirectoryName,
+			configuration.FsScanContext, configuration.ScanFilter,
+			checkScanDisableRequest, hasher, &oldFS.FileSystem, changedPaths)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,62 @@
+package scanner
+
+import (
+	"time"
+
+	"github.com/Symantec/Dominator/lib/log"
+)
+
+// changeWatcher decides whether the next scan may be limited to the paths
+// which changed. Full scans are still done periodically, since changes may be
+// missed (for example, writes to files which are held open).
+type changeWatcher struct {
+	rootDirectoryName string
+	configuration     *Configuration
+	logger            log.Logger
+	watcher           *fsWatcher
+	lastFullScanTime  time.Time
+}
+
+// getChangedPaths returns the paths which changed since the last scan, or nil
+// if a full scan is required.
+func (cw *changeWatcher) getChangedPaths() map[string]struct{} {
+	if cw.watcher != nil {
+		if cw.watcher.scanFilter == cw.configuration.ScanFilter &&
+			time.Since(cw.lastFullScanTime) <
+				cw.configuration.FullScanInterval {
+			changedPaths, rescanRequired := cw.watcher.getChanges()
+			if !rescanRequired {
+				return changedPaths
+			}
+		}
+		cw.watcher.close()
+		cw.watcher = nil
+	} else if !cw.configuration.WatchForChanges ||
+		time.Since(cw.lastFullScanTime) < cw.configuration.FullScanInterval {
+		// Not watching, or failed to set up watches recently.
+		return nil
+	}
+	// Watches are set up before the full scan, so that no changes are missed.
+	cw.lastFullScanTime = time.Now()
+	watcher, err := newFsWatcher(cw.rootDirectoryName,
+		cw.configuration.SubdDirectory, cw.configuration.ScanFilter,
+		cw.logger)
+	if err != nil {
+		cw.logger.Printf("Error watching for changes: %s\n", err)
+		return nil
+	}
+	cw.watcher = watcher
+	return nil
+}
+
+// putChangedPaths will restore changes which were not scanned.
+func (cw *changeWatcher) putChangedPaths(changedPaths map[string]struct{}) {
+	if cw.watcher != nil && changedPaths != nil {
+		cw.watcher.putChanges(changedPaths)
+	}
+}
+
+// requireFullScan will force the next scan to be a full scan.
+func (cw *changeWatcher) requireFullScan() {
+	cw.lastFullScanTime = time.Time{}
+}
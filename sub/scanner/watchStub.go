@@ -0,0 +1,27 @@
+// +build !linux
+
+package scanner
+
+import (
+	"errors"
+
+	"github.com/Symantec/Dominator/lib/filter"
+	"github.com/Symantec/Dominator/lib/log"
+)
+
+type fsWatcher struct {
+	scanFilter *filter.Filter
+}
+
+func newFsWatcher(rootDirectoryName, subdDirectory string,
+	scanFilter *filter.Filter, logger log.Logger) (*fsWatcher, error) {
+	return nil, errors.New("watching for changes is not supported")
+}
+
+func (w *fsWatcher) close() {}
+
+func (w *fsWatcher) getChanges() (map[string]struct{}, bool) {
+	return nil, true
+}
+
+func (w *fsWatcher) putChanges(changedPaths map[string]struct{}) {}
@@ -0,0 +1,213 @@
+package scanner
+
+import (
+	"errors"
+	"os"
+	"path"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/Symantec/Dominator/lib/filter"
+	"github.com/Symantec/Dominator/lib/fsutil"
+	"github.com/Symantec/Dominator/lib/log"
+	"github.com/Symantec/Dominator/lib/wsyscall"
+)
+
+const watchMask = syscall.IN_ATTRIB | syscall.IN_CLOSE_WRITE |
+	syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_DELETE_SELF |
+	syscall.IN_MODIFY | syscall.IN_MOVE_SELF | syscall.IN_MOVED_FROM |
+	syscall.IN_MOVED_TO | syscall.IN_DONT_FOLLOW | syscall.IN_ONLYDIR
+
+// fsWatcher uses inotify to record the paths which change. There is one watch
+// for each directory.
+type fsWatcher struct {
+	rootDirectoryName string
+	subdDirectory     string // Absolute pathname within the root.
+	scanFilter        *filter.Filter
+	logger            log.Logger
+	fd                int
+	file              *os.File // Wraps fd so that close interrupts reads.
+	dev               uint64
+	directories       map[int32]string // Key: watch descriptor.
+	mutex             sync.Mutex       // Protect everything below.
+	changedPaths      map[string]struct{}
+	closed            bool
+	rescanRequired    bool
+}
+
+// newFsWatcher will watch the directories below rootDirectoryName, except for
+// subdDirectory (relative to the root) and directories excluded by scanFilter.
+func newFsWatcher(rootDirectoryName, subdDirectory string,
+	scanFilter *filter.Filter, logger log.Logger) (*fsWatcher, error) {
+	var stat wsyscall.Stat_t
+	if err := wsyscall.Lstat(rootDirectoryName, &stat); err != nil {
+		return nil, err
+	}
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	watcher := &fsWatcher{
+		rootDirectoryName: rootDirectoryName,
+		subdDirectory:     path.Join("/", subdDirectory),
+		scanFilter:        scanFilter,
+		logger:            logger,
+		fd:                fd,
+		file:              os.NewFile(uintptr(fd), "inotify"),
+		dev:               stat.Dev,
+		directories:       make(map[int32]string),
+		changedPaths:      make(map[string]struct{}),
+	}
+	if err := watcher.addWatches("/"); err != nil {
+		watcher.file.Close()
+		if err == syscall.ENOSPC {
+			return nil, errors.New(
+				"too many directories: increase fs.inotify.max_user_watches")
+		}
+		return nil, err
+	}
+	logger.Printf("Watching %d directories for changes\n",
+		len(watcher.directories))
+	go watcher.readEvents()
+	return watcher, nil
+}
+
+// addWatches will watch the directory and the directories below it.
+func (w *fsWatcher) addWatches(dirname string) error {
+	if dirname == w.subdDirectory || (w.scanFilter != nil &&
+		dirname != "/" && w.scanFilter.Match(dirname)) {
+		return nil
+	}
+	fullDirname := path.Join(w.rootDirectoryName, dirname)
+	var stat wsyscall.Stat_t
+	if err := wsyscall.Lstat(fullDirname, &stat); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if stat.Mode&syscall.S_IFMT != syscall.S_IFDIR || stat.Dev != w.dev {
+		return nil
+	}
+	wd, err := syscall.InotifyAddWatch(w.fd, fullDirname, watchMask)
+	if err != nil {
+		if err == syscall.ENOENT || err == syscall.ENOTDIR {
+			return nil
+		}
+		return err
+	}
+	w.directories[int32(wd)] = dirname
+	names, err := fsutil.ReadDirnames(fullDirname, true)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := w.addWatches(path.Join(dirname, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *fsWatcher) close() {
+	w.mutex.Lock()
+	w.closed = true
+	w.mutex.Unlock()
+	w.file.Close()
+}
+
+// getChanges returns the paths which changed since the last call. If changes
+// may have been missed, rescanRequired is true.
+func (w *fsWatcher) getChanges() (map[string]struct{}, bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	changedPaths := w.changedPaths
+	w.changedPaths = make(map[string]struct{})
+	return changedPaths, w.rescanRequired
+}
+
+// putChanges will record changes again which were not scanned.
+func (w *fsWatcher) putChanges(changedPaths map[string]struct{}) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for pathname := range changedPaths {
+		w.changedPaths[pathname] = struct{}{}
+	}
+}
+
+func (w *fsWatcher) readEvents() {
+	buffer := make([]byte, 64<<10)
+	for {
+		nRead, err := w.file.Read(buffer)
+		if err != nil {
+			w.mutex.Lock()
+			closed := w.closed
+			w.rescanRequired = true
+			w.mutex.Unlock()
+			if !closed {
+				w.logger.Printf("Error reading inotify events: %s\n", err)
+			}
+			return
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= nRead; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buffer[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			nameEnd := nameStart + int(event.Len)
+			name := string(buffer[nameStart:nameEnd])
+			for len(name) > 0 && name[len(name)-1] == 0 {
+				name = name[:len(name)-1]
+			}
+			w.handleEvent(event, name)
+			offset = nameEnd
+		}
+	}
+}
+
+func (w *fsWatcher) handleEvent(event *syscall.InotifyEvent, name string) {
+	if event.Mask&syscall.IN_Q_OVERFLOW != 0 {
+		w.logger.Println("Inotify event queue overflowed")
+		w.setRescanRequired()
+		return
+	}
+	dirname, ok := w.directories[event.Wd]
+	if !ok {
+		return
+	}
+	if event.Mask&syscall.IN_IGNORED != 0 {
+		delete(w.directories, event.Wd)
+		return
+	}
+	pathname := dirname
+	if name != "" {
+		pathname = path.Join(dirname, name)
+		if w.scanFilter != nil && w.scanFilter.Match(pathname) {
+			return
+		}
+		if event.Mask&syscall.IN_ISDIR != 0 {
+			if event.Mask&syscall.IN_MOVED_FROM != 0 {
+				// The paths of the watched directories below have changed.
+				w.setRescanRequired()
+				return
+			}
+			if event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+				if err := w.addWatches(pathname); err != nil {
+					w.logger.Printf("Error adding watches: %s\n", err)
+					w.setRescanRequired()
+				}
+			}
+		}
+	} else if event.Mask&syscall.IN_MOVE_SELF != 0 {
+		w.setRescanRequired()
+		return
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.changedPaths[pathname] = struct{}{}
+}
+
+func (w *fsWatcher) setRescanRequired() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.rescanRequired = true
+}